@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slackbot"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/sethvargo/go-envconfig"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdPreview() *cobra.Command {
+	var o = &slackbot.PreviewOptions{}
+
+	var cmd = &cobra.Command{
+		Use:   "preview [pipelineactivity]",
+		Short: "Previews the slack messages which would be sent for a PipelineActivity without sending them",
+		Long:  ``,
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 {
+				o.ActivityName = args[0]
+			}
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	err := envconfig.Process(context.TODO(), &o.SlackOptions)
+	if err != nil {
+		log.Logger().Warnf("failed to process environment variables: %s", err.Error())
+	}
+
+	cmd.Flags().StringVarP(&o.File, "file", "f", "", "the YAML file of the PipelineActivity to preview rather than loading it from the cluster")
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", o.Dir, "the directory containing the .jx/gitops/source-config.yaml file. Defaults to the current directory")
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", "", "the namespace of the PipelineActivity")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", slackbot.PreviewOutputJSON, "the output format of the messages: 'json' or 'url' to output a Block Kit Builder link")
+	cmd.Flags().StringVarP(&o.SlackToken, "slack-token", "t", o.SlackToken, "the optional slack token used to lookup users")
+	cmd.Flags().StringVarP(&o.MessageFormat.DashboardURL, "dashboard-url", "", "", "the URL of the pipelines dashboard to link to")
+	return cmd
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slackbot"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/sethvargo/go-envconfig"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdReplay() *cobra.Command {
+	var o = &slackbot.ReplayOptions{}
+
+	var cmd = &cobra.Command{
+		Use:   "replay",
+		Short: "Resends the slack messages of historical PipelineActivities",
+		Long:  `Resends the messages of the PipelineActivities matching a label selector or time window in build number order. Useful for backfilling the notifications missed during an outage`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	err := envconfig.Process(context.TODO(), &o.SlackOptions)
+	if err != nil {
+		log.Logger().Warnf("failed to process environment variables: %s", err.Error())
+	}
+
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "the label selector of the PipelineActivities to replay")
+	cmd.Flags().DurationVarP(&o.Since, "since", "s", 0, "replay the PipelineActivities started within this duration such as 2h")
+	cmd.Flags().StringVarP(&o.From, "from", "", "", "replay the PipelineActivities started after this RFC3339 time")
+	cmd.Flags().StringVarP(&o.To, "to", "", "", "replay the PipelineActivities started before this RFC3339 time")
+	cmd.Flags().BoolVarP(&o.ForceNewMessages, "force-new", "", false, "create new messages rather than updating the existing messages")
+	cmd.Flags().BoolVarP(&o.IgnoreAgeCutoff, "ignore-age-cutoff", "", false, "create messages for pipelines which have not been updated in the last 24 hours")
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", o.Dir, "the directory to point to a git clone of your development repository. Mostly used for development and testing")
+	cmd.Flags().StringVarP(&o.GitURL, "git-url", "u", o.GitURL, "the git URL to clone for the dev cluster git repository")
+	cmd.Flags().StringVarP(&o.SlackToken, "slack-token", "t", o.SlackToken, "the slack token")
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "", false, "log the messages which would be sent rather than sending them and do not annotate PipelineActivities")
+	return cmd
+}
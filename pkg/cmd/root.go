@@ -25,6 +25,10 @@ func NewCmdRoot() *cobra.Command {
 		},
 	}
 	rootCmd.AddCommand(NewCmdRun())
+	rootCmd.AddCommand(NewCmdPreview())
+	rootCmd.AddCommand(NewCmdReplay())
+	rootCmd.AddCommand(NewCmdTestMessage())
+	rootCmd.AddCommand(NewCmdVerify())
 	return rootCmd
 }
 
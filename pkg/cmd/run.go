@@ -31,5 +31,10 @@ func NewCmdRun() *cobra.Command {
 	cmd.Flags().StringVarP(&o.Dir, "dir", "d", o.Dir, "the directory to point to a git clone of your development repository. Mostly used for development and testing")
 	cmd.Flags().StringVarP(&o.GitURL, "git-url", "u", o.GitURL, "the git URL to clone for the dev cluster git repository")
 	cmd.Flags().StringVarP(&o.SlackToken, "slack-token", "t", o.SlackToken, "the slack token")
+	cmd.Flags().IntVar(&o.HomePipelineCount, "home-pipelines", slackbot.DefaultHomePipelineCount, "the number of recent pipelines to show on the App Home tab, at most 74")
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "", false, "log the messages which would be sent rather than sending them and do not annotate PipelineActivities")
+	cmd.Flags().IntVarP(&o.Port, "port", "p", o.Port, "the port to listen on for slack commands if $SLACK_SIGNING_SECRET is defined")
+	cmd.Flags().StringSliceVarP(&o.WatchNamespaces, "namespaces", "", o.WatchNamespaces, "the namespaces to watch for PipelineActivities. Defaults to the current namespace")
+	cmd.Flags().BoolVarP(&o.WatchAllNamespaces, "all-namespaces", "A", o.WatchAllNamespaces, "watch PipelineActivities in all namespaces")
 	return cmd
 }
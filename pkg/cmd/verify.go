@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slackbot"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/sethvargo/go-envconfig"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdVerify() *cobra.Command {
+	var o = &slackbot.VerifyOptions{}
+
+	var cmd = &cobra.Command{
+		Use:   "verify",
+		Short: "Verifies the slack configuration of the repositories in the SourceConfig",
+		Long:  `Verifies the kind and pipeline of each slack configuration and that the channels exist and the bot is a member of them. Exits with a non zero status if there are any problems`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	err := envconfig.Process(context.TODO(), &o.SlackOptions)
+	if err != nil {
+		log.Logger().Warnf("failed to process environment variables: %s", err.Error())
+	}
+
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", o.Dir, "the directory containing the .jx/gitops/source-config.yaml file. Defaults to the current directory")
+	cmd.Flags().StringVarP(&o.SlackToken, "slack-token", "t", o.SlackToken, "the slack token used to verify the channels")
+	return cmd
+}
@@ -0,0 +1,273 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPort the default SMTP submission port
+	DefaultPort = 587
+
+	// DefaultTimeout the default time allowed to connect to the SMTP server and send an email
+	DefaultTimeout = 30 * time.Second
+)
+
+var textTemplate = texttemplate.Must(texttemplate.New("text").Parse(`{{ .Title }}
+
+Status: {{ .Status }}
+{{- if .Branch }}
+Branch: {{ .Branch }}{{ end }}
+{{- if .Version }}
+Version: {{ .Version }}{{ end }}
+{{- if .PullRequest }}
+Pull Request: {{ .PullRequest.URL }}{{ end }}
+{{- if .BuildURL }}
+Pipeline: {{ .BuildURL }}{{ end }}
+{{- if .BuildLogsURL }}
+Build Logs: {{ .BuildLogsURL }}{{ end }}
+{{- if .Steps }}
+
+Steps:
+{{- range .Steps }}
+  {{ .Status }} {{ .Name }}{{ if .Description }} {{ .Description }}{{ end }}
+{{- end }}{{ end }}
+`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<html>
+<body>
+<h2 style="color: {{ .Color }}">{{ if .BuildURL }}<a href="{{ .BuildURL }}">{{ .Title }}</a>{{ else }}{{ .Title }}{{ end }}</h2>
+<table>
+<tr><td><b>Status</b></td><td>{{ .Status }}</td></tr>
+{{- if .Branch }}
+<tr><td><b>Branch</b></td><td>{{ .Branch }}</td></tr>{{ end }}
+{{- if .Version }}
+<tr><td><b>Version</b></td><td>{{ .Version }}</td></tr>{{ end }}
+{{- if .PullRequest }}
+<tr><td><b>Pull Request</b></td><td><a href="{{ .PullRequest.URL }}">#{{ .PullRequest.Number }} {{ .PullRequest.Title }}</a></td></tr>{{ end }}
+{{- if .BuildLogsURL }}
+<tr><td><b>Build Logs</b></td><td><a href="{{ .BuildLogsURL }}">logs</a></td></tr>{{ end }}
+</table>
+{{- if .Steps }}
+<h3>Steps</h3>
+<ul>
+{{- range .Steps }}
+<li>{{ .Status }} <b>{{ .Name }}</b>{{ if .Description }} {{ .Description }}{{ end }}</li>
+{{- end }}
+</ul>{{ end }}
+</body>
+</html>
+`))
+
+// Client sends notifications as emails via SMTP
+type Client struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+
+	// Timeout the time allowed to connect to the server and send an email unless the context has an earlier deadline
+	Timeout time.Duration
+}
+
+// NewClient creates a new SMTP client
+func NewClient(host string, port int, from string) *Client {
+	if port == 0 {
+		port = DefaultPort
+	}
+	return &Client{
+		Host:    host,
+		Port:    port,
+		From:    from,
+		Timeout: DefaultTimeout,
+	}
+}
+
+// Notify emails the notification. The channel is a comma separated list of recipient email addresses
+func (c *Client) Notify(ctx context.Context, channel string, notification *notifiers.Notification) error {
+	var to []string
+	for _, address := range strings.Split(channel, ",") {
+		address = strings.TrimSpace(address)
+		if address != "" {
+			to = append(to, address)
+		}
+	}
+	if len(to) == 0 {
+		return errors.Errorf("no email recipients for %s", notification.Name)
+	}
+	msg, err := Render(c.From, to, notification)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if c.Username != "" {
+		auth = smtp.PlainAuth("", c.Username, c.Password, c.Host)
+	}
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	err = c.sendMail(ctx, addr, auth, to, msg)
+	if err != nil {
+		return errors.Wrapf(err, "failed to send email for %s via %s", notification.Name, addr)
+	}
+	return nil
+}
+
+// sendMail does the same as smtp.SendMail but gives up once the timeout or the deadline of the context passes
+// so that an SMTP server which does not respond cannot hold up the other notifications
+func (c *Client) sendMail(ctx context.Context, addr string, auth smtp.Auth, to []string, msg []byte) error {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	// closing the connection aborts the conversation if the context is cancelled before the deadline
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: c.Host, MinVersion: tls.VersionTLS12})
+		if err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.Errorf("the server does not support AUTH")
+		}
+		err = client.Auth(auth)
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(c.From)
+	if err != nil {
+		return err
+	}
+	for _, address := range to {
+		if strings.ContainsAny(address, "\r\n") {
+			return errors.Errorf("invalid email address %q", address)
+		}
+		err = client.Rcpt(address)
+		if err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(msg)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// Subject returns the subject of the email for the notification
+func Subject(notification *notifiers.Notification) string {
+	return fmt.Sprintf("[%s] %s", strings.ToLower(notification.Status), notification.Title)
+}
+
+// Render renders the notification as a multipart email with a plain text and HTML part
+func Render(from string, to []string, notification *notifiers.Notification) ([]byte, error) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+
+	text := &bytes.Buffer{}
+	err := textTemplate.Execute(text, notification)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to render text email for %s", notification.Name)
+	}
+	err = writePart(mw, "text/plain; charset=UTF-8", text.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	html := &bytes.Buffer{}
+	err = htmlTemplate.Execute(html, notification)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to render HTML email for %s", notification.Name)
+	}
+	err = writePart(mw, "text/html; charset=UTF-8", html.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	err = mw.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to close email for %s", notification.Name)
+	}
+
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", from)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", Subject(notification)))
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+func writePart(mw *multipart.Writer, contentType string, data []byte) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s part", contentType)
+	}
+	qp := quotedprintable.NewWriter(part)
+	_, err = qp.Write(data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s part", contentType)
+	}
+	return qp.Close()
+}
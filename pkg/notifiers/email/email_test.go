@@ -0,0 +1,43 @@
+package email_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/email"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailTimeout(t *testing.T) {
+	// a server which accepts connections but never replies
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "failed to listen")
+	defer l.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				<-done
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+	client := email.NewClient(addr.IP.String(), addr.Port, "jx@example.com")
+	client.Timeout = 100 * time.Millisecond
+
+	start := time.Now()
+	err = client.Notify(context.TODO(), "dev@example.com", &notifiers.Notification{Name: "myorg-myrepo-main-1", Status: "Failed"})
+	require.Error(t, err, "should fail to send the email")
+	assert.True(t, time.Since(start) < 10*time.Second, "should give up after the timeout but took %s", time.Since(start).String())
+}
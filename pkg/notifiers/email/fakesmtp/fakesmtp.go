@@ -0,0 +1,136 @@
+package fakesmtp
+
+import (
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Message an email received by the fake server
+type Message struct {
+	From string
+	To   []string
+	Data string
+}
+
+// FakeServer a local stand in for an SMTP server which records the emails it receives
+type FakeServer struct {
+	Listener net.Listener
+
+	lock     sync.Mutex
+	messages []Message
+}
+
+// NewFakeServer creates and starts a new fake SMTP server on a local port
+func NewFakeServer() (*FakeServer, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	f := &FakeServer{Listener: l}
+	go f.serve()
+	return f, nil
+}
+
+// Host returns the host of the server
+func (f *FakeServer) Host() string {
+	return f.Listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port of the server
+func (f *FakeServer) Port() int {
+	return f.Listener.Addr().(*net.TCPAddr).Port
+}
+
+// Close stops the server
+func (f *FakeServer) Close() {
+	_ = f.Listener.Close()
+}
+
+// Messages returns the messages received so far
+func (f *FakeServer) Messages() []Message {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]Message{}, f.messages...)
+}
+
+// AssertMessageCount asserts the number of emails received returning them
+func (f *FakeServer) AssertMessageCount(t *testing.T, expectedCount int, message string) []Message {
+	messages := f.Messages()
+	require.Len(t, messages, expectedCount, "emails for %s", message)
+	return messages
+}
+
+func (f *FakeServer) serve() {
+	for {
+		conn, err := f.Listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *FakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(code int, text string) bool {
+		return tp.PrintfLine("%s %s", strconv.Itoa(code), text) == nil
+	}
+	if !reply(220, "localhost fake SMTP") {
+		return
+	}
+	msg := Message{}
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			if !reply(250, "localhost") {
+				return
+			}
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			msg = Message{From: trimAddress(line[len("MAIL FROM:"):])}
+			reply(250, "OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			msg.To = append(msg.To, trimAddress(line[len("RCPT TO:"):]))
+			reply(250, "OK")
+		case command == "DATA":
+			if !reply(354, "end data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			lines, err := tp.ReadDotLines()
+			if err != nil {
+				return
+			}
+			msg.Data = strings.Join(lines, "\n")
+			f.lock.Lock()
+			f.messages = append(f.messages, msg)
+			f.lock.Unlock()
+			reply(250, "OK")
+		case command == "RSET", command == "NOOP":
+			reply(250, "OK")
+		case command == "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "command not implemented")
+		}
+	}
+}
+
+func trimAddress(text string) string {
+	text = strings.TrimSpace(text)
+	if idx := strings.Index(text, " "); idx > 0 {
+		text = text[:idx]
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(text, "<"), ">")
+}
@@ -0,0 +1,477 @@
+package mattermost
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+var (
+	slackLinkPattern    = regexp.MustCompile(`<((?:https?|mailto):[^|>]+)\|([^>]+)>`)
+	slackURLPattern     = regexp.MustCompile(`<((?:https?|mailto):[^>]+)>`)
+	slackMentionPattern = regexp.MustCompile(`<@([A-Za-z0-9]+)>`)
+)
+
+// APIClient sends messages via the Mattermost REST API so that, as with slack, messages can be updated, pinned,
+// threaded and sent directly to users. It implements slacker.Interface by rendering the slack message options as
+// Mattermost posts so that the notify logic is shared with slack
+type APIClient struct {
+	// URL the URL of the Mattermost server
+	URL string
+
+	// Token the personal access token of the bot account
+	Token string
+
+	// Team the name of the team whose channels are posted to
+	Team string
+
+	HTTPClient *http.Client
+
+	lock       sync.Mutex
+	botUserID  string
+	teamID     string
+	channelIDs map[string]string
+	usernames  map[string]string
+}
+
+var _ slacker.Interface = &APIClient{}
+
+// Error an error response from the Mattermost API
+type Error struct {
+	StatusCode int    `json:"status_code"`
+	ID         string `json:"id"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("mattermost API returned status %d %s: %s", e.StatusCode, e.ID, e.Message)
+}
+
+// Post a Mattermost post
+type Post struct {
+	ID        string                 `json:"id,omitempty"`
+	ChannelID string                 `json:"channel_id"`
+	RootID    string                 `json:"root_id,omitempty"`
+	Message   string                 `json:"message"`
+	Props     map[string]interface{} `json:"props,omitempty"`
+}
+
+// User a Mattermost user
+type User struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	Email     string `json:"email,omitempty"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+}
+
+// Channel a Mattermost channel
+type Channel struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"`
+	Type        string `json:"type,omitempty"`
+}
+
+// Team a Mattermost team
+type Team struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// NewAPIClient creates a new client for the Mattermost server using the access token of the bot account
+func NewAPIClient(serverURL, token, team string) *APIClient {
+	return &APIClient{
+		URL:        strings.TrimSuffix(serverURL, "/"),
+		Token:      token,
+		Team:       team,
+		HTTPClient: http.DefaultClient,
+		channelIDs: map[string]string{},
+		usernames:  map[string]string{},
+	}
+}
+
+// SendMessage creates or, with slack.MsgOptionUpdate, updates a post returning the channel ID and the post ID which
+// is used in place of the slack timestamp
+func (c *APIClient) SendMessage(channel string, options ...slack.MsgOption) (string, string, string, error) {
+	endpoint, values, err := slack.UnsafeApplyMsgOptions(c.Token, channel, "", options...)
+	if err != nil {
+		return "", "", "", errors.Wrapf(err, "failed to apply message options")
+	}
+	channelID, err := c.channelID(channel)
+	if err != nil {
+		return "", "", "", err
+	}
+	post, err := c.toPost(values)
+	if err != nil {
+		return "", "", "", err
+	}
+	post.ChannelID = channelID
+
+	if endpoint == "chat.update" {
+		post.ID = values.Get("ts")
+		result := &Post{}
+		err = c.do(http.MethodPut, "/posts/"+url.PathEscape(post.ID), post, result)
+		if err != nil {
+			return "", "", "", missingPostError(err, "cant_update_message")
+		}
+		return result.ChannelID, result.ID, "", nil
+	}
+	result := &Post{}
+	err = c.do(http.MethodPost, "/posts", post, result)
+	if err != nil {
+		return "", "", "", err
+	}
+	return result.ChannelID, result.ID, "", nil
+}
+
+// OpenConversation returns the direct message channel between the bot and the user
+func (c *APIClient) OpenConversation(params *slack.OpenConversationParameters) (*slack.Channel, bool, bool, error) {
+	if params == nil || len(params.Users) != 1 {
+		return nil, false, false, errors.Errorf("mattermost direct messages need exactly one user")
+	}
+	botUserID, err := c.botUser()
+	if err != nil {
+		return nil, false, false, err
+	}
+	result := &Channel{}
+	err = c.do(http.MethodPost, "/channels/direct", []string{botUserID, params.Users[0]}, result)
+	if err != nil {
+		return nil, false, false, errors.Wrapf(err, "failed to open a direct message channel with %s", params.Users[0])
+	}
+	return toSlackChannel(result), false, false, nil
+}
+
+// GetUserByEmail returns the user with the email address
+func (c *APIClient) GetUserByEmail(email string) (*slack.User, error) {
+	u := &User{}
+	err := c.do(http.MethodGet, "/users/email/"+url.PathEscape(email), nil, u)
+	if err != nil {
+		return nil, notFoundError(err, "users_not_found")
+	}
+	return c.toSlackUser(u), nil
+}
+
+// GetUserInfo returns the user with the ID
+func (c *APIClient) GetUserInfo(user string) (*slack.User, error) {
+	u := &User{}
+	err := c.do(http.MethodGet, "/users/"+url.PathEscape(user), nil, u)
+	if err != nil {
+		return nil, notFoundError(err, "user_not_found")
+	}
+	return c.toSlackUser(u), nil
+}
+
+// PublishView is not supported as Mattermost has no App Home tab
+func (c *APIClient) PublishView(string, slack.HomeTabViewRequest, string) (*slack.ViewResponse, error) {
+	return nil, errors.Errorf("mattermost does not support the App Home tab")
+}
+
+// GetConversations returns the channels of the team the bot is a member of in a single page
+func (c *APIClient) GetConversations(*slack.GetConversationsParameters) ([]slack.Channel, string, error) {
+	teamID, err := c.team()
+	if err != nil {
+		return nil, "", err
+	}
+	var channels []*Channel
+	err = c.do(http.MethodGet, "/users/me/teams/"+url.PathEscape(teamID)+"/channels", nil, &channels)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to list the channels of team %s", c.Team)
+	}
+	var answer []slack.Channel
+	for _, ch := range channels {
+		answer = append(answer, *toSlackChannel(ch))
+	}
+	return answer, "", nil
+}
+
+// AddPin pins the post whose ID is the timestamp of the item
+func (c *APIClient) AddPin(_ string, item slack.ItemRef) error {
+	err := c.do(http.MethodPost, "/posts/"+url.PathEscape(item.Timestamp)+"/pin", nil, nil)
+	if err != nil {
+		return missingPostError(err, "message_not_found")
+	}
+	return nil
+}
+
+// channelID returns the ID of the channel which is either a "#name" of a channel of the team or already an ID
+func (c *APIClient) channelID(channel string) (string, error) {
+	if !strings.HasPrefix(channel, "#") {
+		return channel, nil
+	}
+	name := strings.TrimPrefix(channel, "#")
+
+	c.lock.Lock()
+	id := c.channelIDs[name]
+	c.lock.Unlock()
+	if id != "" {
+		return id, nil
+	}
+	if c.Team == "" {
+		return "", errors.Errorf("no team configured to find mattermost channel %s", channel)
+	}
+	result := &Channel{}
+	err := c.do(http.MethodGet, "/teams/name/"+url.PathEscape(c.Team)+"/channels/name/"+url.PathEscape(name), nil, result)
+	if err != nil {
+		return "", notFoundError(err, "channel_not_found")
+	}
+	c.lock.Lock()
+	c.channelIDs[name] = result.ID
+	c.lock.Unlock()
+	return result.ID, nil
+}
+
+// botUser returns the ID of the user of the access token
+func (c *APIClient) botUser() (string, error) {
+	c.lock.Lock()
+	id := c.botUserID
+	c.lock.Unlock()
+	if id != "" {
+		return id, nil
+	}
+	u := &User{}
+	err := c.do(http.MethodGet, "/users/me", nil, u)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find the mattermost bot user")
+	}
+	c.lock.Lock()
+	c.botUserID = u.ID
+	c.lock.Unlock()
+	return u.ID, nil
+}
+
+// team returns the ID of the team
+func (c *APIClient) team() (string, error) {
+	c.lock.Lock()
+	id := c.teamID
+	c.lock.Unlock()
+	if id != "" {
+		return id, nil
+	}
+	t := &Team{}
+	err := c.do(http.MethodGet, "/teams/name/"+url.PathEscape(c.Team), nil, t)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find mattermost team %s", c.Team)
+	}
+	c.lock.Lock()
+	c.teamID = t.ID
+	c.lock.Unlock()
+	return t.ID, nil
+}
+
+// toPost renders the text, blocks and attachments of the slack message as a Mattermost post
+func (c *APIClient) toPost(values url.Values) (*Post, error) {
+	var lines []string
+	if text := values.Get("text"); text != "" {
+		lines = append(lines, c.toMarkdown(text))
+	}
+	if blocks := values.Get("blocks"); blocks != "" {
+		text, err := blocksText(blocks)
+		if err != nil {
+			return nil, err
+		}
+		if text != "" {
+			lines = append(lines, c.toMarkdown(text))
+		}
+	}
+	post := &Post{
+		RootID:  values.Get("thread_ts"),
+		Message: strings.Join(lines, "\n\n"),
+	}
+	if data := values.Get("attachments"); data != "" {
+		var attachments []slack.Attachment
+		err := json.Unmarshal([]byte(data), &attachments)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse attachments")
+		}
+		for i := range attachments {
+			c.toMarkdownAttachment(&attachments[i])
+		}
+		post.Props = map[string]interface{}{"attachments": attachments}
+	}
+	return post, nil
+}
+
+// toMarkdownAttachment converts the slack markup of the attachment to Mattermost markdown. Buttons become links as
+// Mattermost only supports buttons which call an integration
+func (c *APIClient) toMarkdownAttachment(a *slack.Attachment) {
+	a.Pretext = c.toMarkdown(a.Pretext)
+	a.Title = c.toMarkdown(a.Title)
+	a.Text = c.toMarkdown(a.Text)
+	a.Fallback = c.toMarkdown(a.Fallback)
+	for i := range a.Fields {
+		a.Fields[i].Value = c.toMarkdown(a.Fields[i].Value)
+	}
+	var links []string
+	for _, action := range a.Actions {
+		if action.URL != "" {
+			links = append(links, "["+action.Text+"]("+action.URL+")")
+		}
+	}
+	if len(links) > 0 {
+		a.Text = strings.TrimSpace(a.Text + "\n" + strings.Join(links, " | "))
+	}
+	a.Actions = nil
+	a.CallbackID = ""
+}
+
+// toMarkdown converts the slack links and mentions in the text to Mattermost markdown
+func (c *APIClient) toMarkdown(text string) string {
+	if !strings.Contains(text, "<") {
+		return text
+	}
+	text = slackLinkPattern.ReplaceAllString(text, "[$2]($1)")
+	text = slackURLPattern.ReplaceAllString(text, "$1")
+	return slackMentionPattern.ReplaceAllStringFunc(text, func(mention string) string {
+		id := slackMentionPattern.FindStringSubmatch(mention)[1]
+		username := c.username(id)
+		if username == "" {
+			return mention
+		}
+		return "@" + username
+	})
+}
+
+// username returns the username of the user ID or an empty string if the user cannot be found
+func (c *APIClient) username(id string) string {
+	c.lock.Lock()
+	username, ok := c.usernames[id]
+	c.lock.Unlock()
+	if ok {
+		return username
+	}
+	u, err := c.GetUserInfo(id)
+	if err == nil {
+		username = u.Name
+	}
+	c.lock.Lock()
+	c.usernames[id] = username
+	c.lock.Unlock()
+	return username
+}
+
+func (c *APIClient) toSlackUser(u *User) *slack.User {
+	c.lock.Lock()
+	c.usernames[u.ID] = u.Username
+	c.lock.Unlock()
+	return &slack.User{
+		ID:       u.ID,
+		Name:     u.Username,
+		RealName: strings.TrimSpace(u.FirstName + " " + u.LastName),
+		Profile: slack.UserProfile{
+			Email: u.Email,
+		},
+	}
+}
+
+func toSlackChannel(ch *Channel) *slack.Channel {
+	answer := &slack.Channel{}
+	answer.ID = ch.ID
+	answer.Name = ch.Name
+	return answer
+}
+
+// blocksText returns the markdown of the text of the section, header and context blocks
+func blocksText(data string) (string, error) {
+	var blocks []struct {
+		Text *struct {
+			Text string `json:"text"`
+		} `json:"text"`
+		Elements []struct {
+			Text string `json:"text"`
+		} `json:"elements"`
+	}
+	err := json.Unmarshal([]byte(data), &blocks)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse blocks")
+	}
+	var lines []string
+	for _, b := range blocks {
+		if b.Text != nil && b.Text.Text != "" {
+			lines = append(lines, b.Text.Text)
+		}
+		for _, e := range b.Elements {
+			if e.Text != "" {
+				lines = append(lines, e.Text)
+			}
+		}
+	}
+	return strings.Join(lines, "\n\n"), nil
+}
+
+// do invokes the API method, writing the body as JSON and reading the JSON result if there is one
+func (c *APIClient) do(method, path string, body, result interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal request")
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, c.URL+"/api/v4"+path, reader)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create request")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to invoke %s %s", method, path)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the response of %s %s", method, path)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &Error{}
+		_ = json.Unmarshal(data, apiErr)
+		apiErr.StatusCode = resp.StatusCode
+		return apiErr
+	}
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	err = json.Unmarshal(data, result)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the response of %s %s", method, path)
+	}
+	return nil
+}
+
+// notFoundError returns the slack error with the code if the resource was not found so that it is described the
+// same way as slack errors
+func notFoundError(err error, code string) error {
+	if apiErr, ok := err.(*Error); ok && apiErr.StatusCode == http.StatusNotFound {
+		return slack.SlackErrorResponse{Err: code}
+	}
+	return err
+}
+
+// missingPostError returns the slack error for a post which has been deleted or, with the code, cannot be edited
+func missingPostError(err error, code string) error {
+	if apiErr, ok := err.(*Error); ok {
+		switch apiErr.StatusCode {
+		case http.StatusNotFound:
+			return slack.SlackErrorResponse{Err: "message_not_found"}
+		case http.StatusForbidden:
+			return slack.SlackErrorResponse{Err: code}
+		}
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package mattermost_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/mattermost"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/mattermost/fakemattermost"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIClientSendMessage(t *testing.T) {
+	server := fakemattermost.NewFakeServer("engineering")
+	defer server.Close()
+	builds := server.AddChannel("builds")
+	server.AddUser(&mattermost.User{ID: "u1", Username: "jstrachan", Email: "james@example.com"})
+
+	client := mattermost.NewAPIClient(server.URL(), "token", "engineering")
+
+	attachment := slack.Attachment{
+		Color: "#3AA3E3",
+		Title: "<https://github.com/myorg/myrepo|myorg/myrepo> by <@u1>",
+		Actions: []slack.AttachmentAction{
+			{Type: "button", Text: "Logs", URL: "https://dashboard.example.com/logs"},
+		},
+	}
+	channelID, postID, _, err := client.SendMessage("#builds", slack.MsgOptionAttachments(attachment))
+	require.NoError(t, err, "failed to send message")
+	assert.Equal(t, builds.ID, channelID, "channel ID")
+
+	posts := server.AssertPostCount(t, builds.ID, 1, "create")
+	attachments := posts[0].Props["attachments"].([]interface{})
+	require.Len(t, attachments, 1, "attachments")
+	rendered := attachments[0].(map[string]interface{})
+	assert.Equal(t, "[myorg/myrepo](https://github.com/myorg/myrepo) by @jstrachan", rendered["title"], "title")
+	assert.Equal(t, "[Logs](https://dashboard.example.com/logs)", rendered["text"], "buttons become links")
+	assert.Nil(t, rendered["actions"], "actions")
+
+	attachment.Color = "#36a64f"
+	_, updatedID, _, err := client.SendMessage(channelID, slack.MsgOptionUpdate(postID), slack.MsgOptionAttachments(attachment))
+	require.NoError(t, err, "failed to update message")
+	assert.Equal(t, postID, updatedID, "updated post ID")
+	posts = server.AssertPostCount(t, builds.ID, 1, "update")
+	assert.Equal(t, "#36a64f", posts[0].Props["attachments"].([]interface{})[0].(map[string]interface{})["color"], "updated color")
+
+	_, _, _, err = client.SendMessage(channelID, slack.MsgOptionText("thread", false), slack.MsgOptionTS(postID))
+	require.NoError(t, err, "failed to reply in thread")
+	posts = server.AssertPostCount(t, builds.ID, 2, "thread")
+	assert.Equal(t, postID, posts[1].RootID, "thread root")
+
+	err = client.AddPin(channelID, slack.NewRefToMessage(channelID, postID))
+	require.NoError(t, err, "failed to pin")
+	assert.Equal(t, []string{postID}, server.Pinned(), "pinned posts")
+
+	server.DeletePost(postID)
+	_, _, _, err = client.SendMessage(channelID, slack.MsgOptionUpdate(postID), slack.MsgOptionText("gone", false))
+	require.Error(t, err, "should fail to update a deleted post")
+	assert.Equal(t, "message_not_found", err.Error(), "deleted posts are reported as missing slack messages")
+}
+
+func TestAPIClientDirectMessage(t *testing.T) {
+	server := fakemattermost.NewFakeServer("engineering")
+	defer server.Close()
+	server.AddUser(&mattermost.User{ID: "u1", Username: "jstrachan", Email: "james@example.com"})
+
+	client := mattermost.NewAPIClient(server.URL(), "token", "engineering")
+
+	user, err := client.GetUserByEmail("james@example.com")
+	require.NoError(t, err, "failed to find user by email")
+	assert.Equal(t, "u1", user.ID, "user ID")
+
+	_, err = client.GetUserByEmail("nobody@example.com")
+	require.Error(t, err, "should fail for an unknown email")
+	assert.Equal(t, "users_not_found", err.Error(), "unknown users are reported as slack errors")
+
+	channel, _, _, err := client.OpenConversation(&slack.OpenConversationParameters{Users: []string{user.ID}})
+	require.NoError(t, err, "failed to open direct message channel")
+
+	_, _, _, err = client.SendMessage(channel.ID, slack.MsgOptionText("please review <https://github.com/myorg/myrepo/pull/1|#1>", false))
+	require.NoError(t, err, "failed to send direct message")
+	posts := server.AssertPostCount(t, channel.ID, 1, "direct message")
+	assert.Equal(t, "please review [#1](https://github.com/myorg/myrepo/pull/1)", posts[0].Message, "message")
+}
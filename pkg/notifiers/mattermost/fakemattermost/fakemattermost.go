@@ -0,0 +1,37 @@
+package fakemattermost
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/mattermost"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/require"
+)
+
+// FakeMattermost the fake mattermost webhook which records the rendered messages
+type FakeMattermost struct {
+	Messages map[string][]*slack.WebhookMessage
+}
+
+// NewFakeMattermost creates a new fake mattermost
+func NewFakeMattermost() *FakeMattermost {
+	return &FakeMattermost{}
+}
+
+// Notify renders the notification and records it against the channel
+func (f *FakeMattermost) Notify(_ context.Context, channel string, notification *notifiers.Notification) error {
+	if f.Messages == nil {
+		f.Messages = map[string][]*slack.WebhookMessage{}
+	}
+	f.Messages[channel] = append(f.Messages[channel], mattermost.ToWebhookMessage(channel, notification))
+	return nil
+}
+
+// AssertMessageCount asserts the message count for the given channel returning the messages
+func (f *FakeMattermost) AssertMessageCount(t *testing.T, channel string, expectedCount int, message string) []*slack.WebhookMessage {
+	messages := f.Messages[channel]
+	require.Len(t, messages, expectedCount, "mattermost messages for channel %s for %s", channel, message)
+	return messages
+}
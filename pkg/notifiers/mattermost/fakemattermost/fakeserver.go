@@ -0,0 +1,224 @@
+package fakemattermost
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/mattermost"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// BotUserID the ID of the user of the access token
+	BotUserID = "bot-user"
+
+	// TeamID the ID of the team of the fake server
+	TeamID = "team-id"
+)
+
+// FakeServer a local stand in for the subset of the Mattermost REST API used by mattermost.APIClient which records
+// the posts it receives
+type FakeServer struct {
+	Server *httptest.Server
+
+	// Team the name of the team
+	Team string
+
+	// Channels the channels of the team by name
+	Channels map[string]*mattermost.Channel
+
+	// Users the users by ID
+	Users map[string]*mattermost.User
+
+	lock   sync.Mutex
+	posts  map[string]*mattermost.Post
+	order  []string
+	pinned []string
+	count  int
+}
+
+// NewFakeServer creates and starts a new fake Mattermost server for the team
+func NewFakeServer(team string) *FakeServer {
+	f := &FakeServer{
+		Team:     team,
+		Channels: map[string]*mattermost.Channel{},
+		Users: map[string]*mattermost.User{
+			BotUserID: {ID: BotUserID, Username: "jenkins-x"},
+		},
+		posts: map[string]*mattermost.Post{},
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	return f
+}
+
+// URL returns the URL of the server
+func (f *FakeServer) URL() string {
+	return f.Server.URL
+}
+
+// Close stops the server
+func (f *FakeServer) Close() {
+	f.Server.Close()
+}
+
+// AddChannel adds a channel to the team
+func (f *FakeServer) AddChannel(name string) *mattermost.Channel {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	ch := &mattermost.Channel{ID: "channel-" + name, Name: name, Type: "O"}
+	f.Channels[name] = ch
+	return ch
+}
+
+// AddUser adds a user
+func (f *FakeServer) AddUser(user *mattermost.User) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.Users[user.ID] = user
+}
+
+// DeletePost deletes the post so that updating it fails
+func (f *FakeServer) DeletePost(id string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.posts, id)
+}
+
+// Posts returns the current version of the posts in the order they were created
+func (f *FakeServer) Posts() []mattermost.Post {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var answer []mattermost.Post
+	for _, id := range f.order {
+		if p := f.posts[id]; p != nil {
+			answer = append(answer, *p)
+		}
+	}
+	return answer
+}
+
+// Pinned returns the IDs of the pinned posts
+func (f *FakeServer) Pinned() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]string{}, f.pinned...)
+}
+
+// AssertPostCount asserts the number of posts in the channel returning them
+func (f *FakeServer) AssertPostCount(t *testing.T, channelID string, expectedCount int, message string) []mattermost.Post {
+	var answer []mattermost.Post
+	for _, p := range f.Posts() {
+		if p.ChannelID == channelID {
+			answer = append(answer, p)
+		}
+	}
+	require.Len(t, answer, expectedCount, "mattermost posts in channel %s for %s", channelID, message)
+	return answer
+}
+
+func (f *FakeServer) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") == "" {
+		writeError(w, http.StatusUnauthorized, "api.context.session_expired.app_error")
+		return
+	}
+	path := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v4"), "/"), "/")
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && len(path) == 2 && path[0] == "users":
+		f.writeUser(w, path[1])
+	case r.Method == http.MethodGet && len(path) == 3 && path[0] == "users" && path[1] == "email":
+		for _, u := range f.Users {
+			if u.Email != "" && u.Email == path[2] {
+				writeJSON(w, u)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "app.user.missing_account.const")
+	case r.Method == http.MethodGet && len(path) == 3 && path[0] == "teams" && path[1] == "name" && path[2] == f.Team:
+		writeJSON(w, &mattermost.Team{ID: TeamID, Name: f.Team})
+	case r.Method == http.MethodGet && len(path) == 6 && path[0] == "teams" && path[2] == f.Team && path[3] == "channels":
+		ch := f.Channels[path[5]]
+		if ch == nil {
+			writeError(w, http.StatusNotFound, "app.channel.get_by_name.missing.app_error")
+			return
+		}
+		writeJSON(w, ch)
+	case r.Method == http.MethodGet && len(path) == 5 && path[0] == "users" && path[2] == "teams" && path[3] == TeamID:
+		var channels []*mattermost.Channel
+		for _, ch := range f.Channels {
+			channels = append(channels, ch)
+		}
+		writeJSON(w, channels)
+	case r.Method == http.MethodPost && len(path) == 2 && path[0] == "channels" && path[1] == "direct":
+		var users []string
+		if json.NewDecoder(r.Body).Decode(&users) != nil || len(users) != 2 {
+			writeError(w, http.StatusBadRequest, "api.context.invalid_body_param.app_error")
+			return
+		}
+		writeJSON(w, &mattermost.Channel{ID: "dm-" + users[1], Name: users[0] + "__" + users[1], Type: "D"})
+	case r.Method == http.MethodPost && len(path) == 1 && path[0] == "posts":
+		post := &mattermost.Post{}
+		if json.NewDecoder(r.Body).Decode(post) != nil {
+			writeError(w, http.StatusBadRequest, "api.context.invalid_body_param.app_error")
+			return
+		}
+		f.count++
+		post.ID = fmt.Sprintf("post-%d", f.count)
+		f.posts[post.ID] = post
+		f.order = append(f.order, post.ID)
+		writeJSON(w, post)
+	case r.Method == http.MethodPut && len(path) == 2 && path[0] == "posts":
+		if f.posts[path[1]] == nil {
+			writeError(w, http.StatusNotFound, "app.post.get.app_error")
+			return
+		}
+		post := &mattermost.Post{}
+		if json.NewDecoder(r.Body).Decode(post) != nil {
+			writeError(w, http.StatusBadRequest, "api.context.invalid_body_param.app_error")
+			return
+		}
+		post.ID = path[1]
+		f.posts[post.ID] = post
+		writeJSON(w, post)
+	case r.Method == http.MethodPost && len(path) == 3 && path[0] == "posts" && path[2] == "pin":
+		if f.posts[path[1]] == nil {
+			writeError(w, http.StatusNotFound, "app.post.get.app_error")
+			return
+		}
+		f.pinned = append(f.pinned, path[1])
+		writeJSON(w, map[string]string{"status": "OK"})
+	default:
+		writeError(w, http.StatusNotFound, "api.context.404.app_error")
+	}
+}
+
+func (f *FakeServer) writeUser(w http.ResponseWriter, id string) {
+	if id == "me" {
+		id = BotUserID
+	}
+	u := f.Users[id]
+	if u == nil {
+		writeError(w, http.StatusNotFound, "app.user.missing_account.const")
+		return
+	}
+	writeJSON(w, u)
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, id string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(&mattermost.Error{StatusCode: status, ID: id, Message: http.StatusText(status)})
+}
@@ -0,0 +1,107 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// DefaultUsername the username the messages are posted as
+	DefaultUsername = "jenkins-x"
+)
+
+// Client sends notifications to a Mattermost incoming webhook which accepts slack compatible payloads
+type Client struct {
+	WebhookURL string
+	Username   string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a new client for the given incoming webhook URL
+func NewClient(webhookURL string) *Client {
+	return &Client{
+		WebhookURL: webhookURL,
+		Username:   DefaultUsername,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Notify posts the notification to the webhook
+func (c *Client) Notify(ctx context.Context, channel string, notification *notifiers.Notification) error {
+	msg := ToWebhookMessage(channel, notification)
+	msg.Username = c.Username
+	err := slack.PostWebhookCustomHTTPContext(ctx, c.WebhookURL, c.HTTPClient, msg)
+	if err != nil {
+		return errors.Wrapf(err, "failed to post mattermost notification for %s", notification.Name)
+	}
+	return nil
+}
+
+// ToWebhookMessage renders the notification as a slack compatible attachment using Mattermost markdown
+func ToWebhookMessage(channel string, n *notifiers.Notification) *slack.WebhookMessage {
+	title := n.Title
+	if n.PullRequest != nil && n.PullRequest.URL != "" {
+		title += fmt.Sprintf(" : [PR #%d](%s)", n.PullRequest.Number, n.PullRequest.URL)
+	}
+
+	var fields []slack.AttachmentField
+	if n.Status != "" {
+		fields = append(fields, slack.AttachmentField{Title: "Status", Value: n.Status, Short: true})
+	}
+	if n.Branch != "" {
+		fields = append(fields, slack.AttachmentField{Title: "Branch", Value: n.Branch, Short: true})
+	}
+	if n.Version != "" {
+		fields = append(fields, slack.AttachmentField{Title: "Version", Value: n.Version, Short: true})
+	}
+	if n.PullRequest != nil && n.PullRequest.Author != "" {
+		fields = append(fields, slack.AttachmentField{Title: "Author", Value: n.PullRequest.Author, Short: true})
+	}
+
+	var links []string
+	if n.BuildURL != "" {
+		links = append(links, markdownLink("Pipeline", n.BuildURL))
+	}
+	if n.BuildLogsURL != "" {
+		links = append(links, markdownLink("Build Logs", n.BuildLogsURL))
+	}
+	if n.ReleaseNotesURL != "" {
+		links = append(links, markdownLink("Release Notes", n.ReleaseNotesURL))
+	}
+
+	var lines []string
+	for _, step := range n.Steps {
+		line := fmt.Sprintf("* %s **%s**", step.Status, step.Name)
+		if step.Description != "" {
+			line += " " + step.Description
+		}
+		lines = append(lines, line)
+	}
+	if len(links) > 0 {
+		lines = append(lines, strings.Join(links, " | "))
+	}
+
+	return &slack.WebhookMessage{
+		Channel: strings.TrimPrefix(channel, "#"),
+		Attachments: []slack.Attachment{
+			{
+				Fallback:  n.Title + " " + n.Status,
+				Color:     n.Color(),
+				Title:     title,
+				TitleLink: n.BuildURL,
+				Text:      strings.Join(lines, "\n"),
+				Fields:    fields,
+			},
+		},
+	}
+}
+
+func markdownLink(text, url string) string {
+	return "[" + text + "](" + url + ")"
+}
@@ -0,0 +1,68 @@
+package mattermost_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/mattermost"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMattermostNotify(t *testing.T) {
+	var received *slack.WebhookMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method, "method")
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"), "content type")
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err, "failed to read body")
+
+		received = &slack.WebhookMessage{}
+		err = json.Unmarshal(body, received)
+		require.NoError(t, err, "failed to parse message %s", string(body))
+	}))
+	defer server.Close()
+
+	client := mattermost.NewClient(server.URL)
+	err := client.Notify(context.TODO(), "#builds", &notifiers.Notification{
+		Name:     "myorg-myrepo-main-3",
+		Title:    "Release myorg/myrepo (Build #3)",
+		Status:   "Succeeded",
+		Branch:   "main",
+		Version:  "1.2.3",
+		BuildURL: "https://dashboard/myorg/myrepo/main/3",
+		Steps: []notifiers.Step{
+			{Name: "Build", Status: "Succeeded"},
+			{Name: "Promote", Status: "Succeeded", Description: "to staging"},
+		},
+	})
+	require.NoError(t, err, "failed to notify")
+
+	require.NotNil(t, received, "received message")
+	assert.Equal(t, "builds", received.Channel, "channel")
+	assert.Equal(t, mattermost.DefaultUsername, received.Username, "username")
+	require.Len(t, received.Attachments, 1, "attachments")
+	attachment := received.Attachments[0]
+	assert.Equal(t, "Release myorg/myrepo (Build #3)", attachment.Title, "title")
+	assert.Equal(t, "https://dashboard/myorg/myrepo/main/3", attachment.TitleLink, "title link")
+	assert.Equal(t, "#36a64f", attachment.Color, "color")
+	assert.Equal(t, "* Succeeded **Build**\n* Succeeded **Promote** to staging\n[Pipeline](https://dashboard/myorg/myrepo/main/3)", attachment.Text, "text")
+}
+
+func TestMattermostNotifyFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := mattermost.NewClient(server.URL)
+	err := client.Notify(context.TODO(), "", &notifiers.Notification{Name: "myorg-myrepo-main-4", Status: "Failed"})
+	require.Error(t, err, "should fail to notify")
+	assert.Contains(t, err.Error(), "myorg-myrepo-main-4", "error")
+}
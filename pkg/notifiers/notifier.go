@@ -0,0 +1,91 @@
+package notifiers
+
+import (
+	"context"
+)
+
+const (
+	// KindSlack the default kind of connection which uses the slack API
+	KindSlack = "slack"
+
+	// KindMattermost sends notifications via the Mattermost API or, without a server URL, to a Mattermost
+	// incoming webhook
+	KindMattermost = "mattermost"
+
+	// KindTeams sends notifications to a Microsoft Teams incoming webhook
+	KindTeams = "teams"
+)
+
+// Notifier sends pipeline notifications to an incoming webhook. It only covers the single notification of a
+// completed pipeline which incoming webhooks support; backends with an API such as slack and Mattermost implement
+// slacker.Interface instead so that they support message updates, threads and direct messages
+type Notifier interface {
+	// Notify sends the notification to the given channel. Backends which are bound to a single channel
+	// such as incoming webhooks may ignore the channel
+	Notify(ctx context.Context, channel string, notification *Notification) error
+}
+
+// Notification a backend independent description of a pipeline which each backend renders in its own way
+type Notification struct {
+	// Name the name of the PipelineActivity
+	Name string `json:"name"`
+
+	// Title a plain text summary of the pipeline such as 'Release myorg/myrepo (Build #3)'
+	Title string `json:"title"`
+
+	// Status the status of the pipeline such as Running, Succeeded or Failed
+	Status string `json:"status"`
+
+	Owner           string       `json:"owner,omitempty"`
+	Repository      string       `json:"repository,omitempty"`
+	Branch          string       `json:"branch,omitempty"`
+	Build           string       `json:"build,omitempty"`
+	Pipeline        string       `json:"pipeline,omitempty"`
+	Context         string       `json:"context,omitempty"`
+	Version         string       `json:"version,omitempty"`
+	GitURL          string       `json:"gitUrl,omitempty"`
+	BuildURL        string       `json:"buildUrl,omitempty"`
+	BuildLogsURL    string       `json:"buildLogsUrl,omitempty"`
+	ReleaseNotesURL string       `json:"releaseNotesUrl,omitempty"`
+	PullRequest     *PullRequest `json:"pullRequest,omitempty"`
+	Steps           []Step       `json:"steps,omitempty"`
+}
+
+// PullRequest the pull request a pipeline was triggered by
+type PullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Author string `json:"author,omitempty"`
+}
+
+// Step a stage, step or promotion of a pipeline
+type Step struct {
+	Name        string `json:"name"`
+	Status      string `json:"status,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// IsFailed returns true if the pipeline failed or errored
+func (n *Notification) IsFailed() bool {
+	return n.Status == "Failed" || n.Status == "Error"
+}
+
+// IsSucceeded returns true if the pipeline succeeded
+func (n *Notification) IsSucceeded() bool {
+	return n.Status == "Succeeded"
+}
+
+// Color returns the conventional hex color for the status of the pipeline
+func (n *Notification) Color() string {
+	switch {
+	case n.IsFailed():
+		return "#d00000"
+	case n.IsSucceeded():
+		return "#36a64f"
+	case n.Status == "Running":
+		return "#3AA3E3"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,32 @@
+package faketeams
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/teams"
+	"github.com/stretchr/testify/require"
+)
+
+// FakeTeams the fake Microsoft Teams webhook which records the rendered messages
+type FakeTeams struct {
+	Messages []*teams.Message
+}
+
+// NewFakeTeams creates a new fake teams webhook
+func NewFakeTeams() *FakeTeams {
+	return &FakeTeams{}
+}
+
+// Notify renders the notification and records it
+func (f *FakeTeams) Notify(_ context.Context, _ string, notification *notifiers.Notification) error {
+	f.Messages = append(f.Messages, teams.ToMessage(notification))
+	return nil
+}
+
+// AssertMessageCount asserts the number of messages sent returning them
+func (f *FakeTeams) AssertMessageCount(t *testing.T, expectedCount int, message string) []*teams.Message {
+	require.Len(t, f.Messages, expectedCount, "teams messages for %s", message)
+	return f.Messages
+}
@@ -0,0 +1,188 @@
+package teams
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AdaptiveCardContentType the content type of an adaptive card attachment
+	AdaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+
+	adaptiveCardSchema  = "http://adaptivecards.io/schemas/adaptive-card.json"
+	adaptiveCardVersion = "1.2"
+)
+
+// Message the payload of a Microsoft Teams incoming webhook
+type Message struct {
+	Type        string       `json:"type"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// Attachment an attachment of a message
+type Attachment struct {
+	ContentType string        `json:"contentType"`
+	Content     *AdaptiveCard `json:"content"`
+}
+
+// AdaptiveCard the subset of an adaptive card we render
+type AdaptiveCard struct {
+	Schema  string    `json:"$schema"`
+	Type    string    `json:"type"`
+	Version string    `json:"version"`
+	Body    []Element `json:"body"`
+	Actions []Action  `json:"actions,omitempty"`
+}
+
+// Element a TextBlock or FactSet element of an adaptive card
+type Element struct {
+	Type   string `json:"type"`
+	Text   string `json:"text,omitempty"`
+	Weight string `json:"weight,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Color  string `json:"color,omitempty"`
+	Wrap   bool   `json:"wrap,omitempty"`
+	Facts  []Fact `json:"facts,omitempty"`
+}
+
+// Fact a name and value in a FactSet
+type Fact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// Action an action button of an adaptive card
+type Action struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// Client sends notifications to a Microsoft Teams incoming webhook
+type Client struct {
+	WebhookURL string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a new client for the given incoming webhook URL
+func NewClient(webhookURL string) *Client {
+	return &Client{
+		WebhookURL: webhookURL,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Notify posts the notification to the webhook. The channel is ignored as an incoming webhook is bound to a channel
+func (c *Client) Notify(ctx context.Context, _ string, notification *notifiers.Notification) error {
+	data, err := json.Marshal(ToMessage(notification))
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal teams message for %s", notification.Name)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.WebhookURL, bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create teams request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to post teams notification for %s", notification.Name)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("failed to post teams notification for %s: status %d %s", notification.Name, resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// ToMessage renders the notification as an adaptive card
+func ToMessage(n *notifiers.Notification) *Message {
+	title := n.Title
+	if n.PullRequest != nil && n.PullRequest.URL != "" {
+		title += fmt.Sprintf(" : [PR #%d](%s)", n.PullRequest.Number, n.PullRequest.URL)
+	}
+	color := "Default"
+	switch {
+	case n.IsFailed():
+		color = "Attention"
+	case n.IsSucceeded():
+		color = "Good"
+	}
+	body := []Element{
+		{
+			Type:   "TextBlock",
+			Text:   title,
+			Weight: "Bolder",
+			Size:   "Medium",
+			Color:  color,
+			Wrap:   true,
+		},
+	}
+
+	var facts []Fact
+	if n.Status != "" {
+		facts = append(facts, Fact{Title: "Status", Value: n.Status})
+	}
+	if n.Branch != "" {
+		facts = append(facts, Fact{Title: "Branch", Value: n.Branch})
+	}
+	if n.Version != "" {
+		facts = append(facts, Fact{Title: "Version", Value: n.Version})
+	}
+	if n.PullRequest != nil && n.PullRequest.Author != "" {
+		facts = append(facts, Fact{Title: "Author", Value: n.PullRequest.Author})
+	}
+	if len(facts) > 0 {
+		body = append(body, Element{Type: "FactSet", Facts: facts})
+	}
+
+	for _, step := range n.Steps {
+		text := fmt.Sprintf("%s **%s**", step.Status, step.Name)
+		if step.Description != "" {
+			text += " " + step.Description
+		}
+		body = append(body, Element{Type: "TextBlock", Text: text, Wrap: true})
+	}
+
+	var actions []Action
+	if n.BuildURL != "" {
+		actions = append(actions, openURLAction("Pipeline", n.BuildURL))
+	}
+	if n.BuildLogsURL != "" {
+		actions = append(actions, openURLAction("Build Logs", n.BuildLogsURL))
+	}
+	if n.ReleaseNotesURL != "" {
+		actions = append(actions, openURLAction("Release Notes", n.ReleaseNotesURL))
+	}
+
+	return &Message{
+		Type: "message",
+		Attachments: []Attachment{
+			{
+				ContentType: AdaptiveCardContentType,
+				Content: &AdaptiveCard{
+					Schema:  adaptiveCardSchema,
+					Type:    "AdaptiveCard",
+					Version: adaptiveCardVersion,
+					Body:    body,
+					Actions: actions,
+				},
+			},
+		},
+	}
+}
+
+func openURLAction(title, url string) Action {
+	return Action{
+		Type:  "Action.OpenUrl",
+		Title: title,
+		URL:   url,
+	}
+}
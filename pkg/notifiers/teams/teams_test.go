@@ -0,0 +1,74 @@
+package teams_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/teams"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamsNotify(t *testing.T) {
+	var received *teams.Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method, "method")
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"), "content type")
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err, "failed to read body")
+
+		received = &teams.Message{}
+		err = json.Unmarshal(body, received)
+		require.NoError(t, err, "failed to parse message %s", string(body))
+	}))
+	defer server.Close()
+
+	client := teams.NewClient(server.URL)
+	err := client.Notify(context.TODO(), "", &notifiers.Notification{
+		Name:     "myorg-myrepo-pr-5-1",
+		Title:    "Pull Request myorg/myrepo (Build #1)",
+		Status:   "Failed",
+		Branch:   "PR-5",
+		BuildURL: "https://dashboard/myorg/myrepo/PR-5/1",
+		PullRequest: &notifiers.PullRequest{
+			Number: 5,
+			URL:    "https://github.com/myorg/myrepo/pull/5",
+			Author: "myuser",
+		},
+		Steps: []notifiers.Step{
+			{Name: "Build", Status: "Failed"},
+		},
+	})
+	require.NoError(t, err, "failed to notify")
+
+	require.NotNil(t, received, "received message")
+	assert.Equal(t, "message", received.Type, "type")
+	require.Len(t, received.Attachments, 1, "attachments")
+	attachment := received.Attachments[0]
+	assert.Equal(t, teams.AdaptiveCardContentType, attachment.ContentType, "content type")
+	require.NotNil(t, attachment.Content, "card")
+	card := attachment.Content
+	require.NotEmpty(t, card.Body, "card body")
+	assert.Equal(t, "Pull Request myorg/myrepo (Build #1) : [PR #5](https://github.com/myorg/myrepo/pull/5)", card.Body[0].Text, "title")
+	assert.Equal(t, "Attention", card.Body[0].Color, "title color")
+	require.Len(t, card.Actions, 1, "actions")
+	assert.Equal(t, "https://dashboard/myorg/myrepo/PR-5/1", card.Actions[0].URL, "pipeline action")
+}
+
+func TestTeamsNotifyFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("invalid card"))
+	}))
+	defer server.Close()
+
+	client := teams.NewClient(server.URL)
+	err := client.Notify(context.TODO(), "", &notifiers.Notification{Name: "myorg-myrepo-main-2", Status: "Succeeded"})
+	require.Error(t, err, "should fail to notify")
+	assert.Contains(t, err.Error(), "status 400 invalid card", "error")
+}
@@ -0,0 +1,32 @@
+package fakewebhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/webhook"
+	"github.com/stretchr/testify/require"
+)
+
+// FakeWebhook the fake webhook which records the CloudEvents
+type FakeWebhook struct {
+	Events []*webhook.Event
+}
+
+// NewFakeWebhook creates a new fake webhook
+func NewFakeWebhook() *FakeWebhook {
+	return &FakeWebhook{}
+}
+
+// Notify creates the CloudEvent for the notification and records it
+func (f *FakeWebhook) Notify(_ context.Context, _ string, notification *notifiers.Notification) error {
+	f.Events = append(f.Events, webhook.NewEvent(webhook.DefaultSource, notification))
+	return nil
+}
+
+// AssertEventCount asserts the number of events sent returning them
+func (f *FakeWebhook) AssertEventCount(t *testing.T, expectedCount int, message string) []*webhook.Event {
+	require.Len(t, f.Events, expectedCount, "webhook events for %s", message)
+	return f.Events
+}
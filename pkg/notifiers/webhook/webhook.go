@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ContentType the content type of a structured mode CloudEvent
+	ContentType = "application/cloudevents+json"
+
+	// SignatureHeader the header containing the HMAC SHA256 signature of the body when a secret is configured
+	SignatureHeader = "X-Signature-256"
+
+	// EventType the CloudEvent type of a pipeline notification
+	EventType = "io.jenkins-x.pipeline.notification.v1"
+
+	// DefaultSource the default CloudEvent source
+	DefaultSource = "/jenkins-x/jx-slack"
+
+	// DefaultMaxRetries the default number of times a failed delivery is retried
+	DefaultMaxRetries = 3
+
+	// DefaultBackoff the default delay before the first retry which doubles on each retry
+	DefaultBackoff = time.Second
+
+	specVersion = "1.0"
+)
+
+// Event a structured mode CloudEvent containing a pipeline notification
+type Event struct {
+	SpecVersion     string                  `json:"specversion"`
+	ID              string                  `json:"id"`
+	Source          string                  `json:"source"`
+	Type            string                  `json:"type"`
+	Subject         string                  `json:"subject,omitempty"`
+	Time            string                  `json:"time,omitempty"`
+	DataContentType string                  `json:"datacontenttype"`
+	Data            *notifiers.Notification `json:"data"`
+}
+
+// Client sends notifications as CloudEvents to a HTTP endpoint retrying failed deliveries and appending
+// the events which could not be delivered to a dead letter file
+type Client struct {
+	URL            string
+	Secret         []byte
+	Source         string
+	MaxRetries     int
+	Backoff        time.Duration
+	DeadLetterFile string
+	HTTPClient     *http.Client
+}
+
+// NewClient creates a new client for the given URL and HMAC secret
+func NewClient(url string, secret []byte) *Client {
+	return &Client{
+		URL:        url,
+		Secret:     secret,
+		Source:     DefaultSource,
+		MaxRetries: DefaultMaxRetries,
+		Backoff:    DefaultBackoff,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// NewEvent creates the CloudEvent for the notification. The ID is derived from the pipeline and its status so that
+// subscribers can ignore redelivered events
+func NewEvent(source string, notification *notifiers.Notification) *Event {
+	if source == "" {
+		source = DefaultSource
+	}
+	return &Event{
+		SpecVersion:     specVersion,
+		ID:              notification.Name + "-" + strings.ToLower(notification.Status),
+		Source:          source,
+		Type:            EventType,
+		Subject:         notification.Name,
+		Time:            time.Now().UTC().Format(time.RFC3339),
+		DataContentType: "application/json",
+		Data:            notification,
+	}
+}
+
+// Sign returns the value of the signature header for the body
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notify sends the notification as a CloudEvent. The channel is ignored
+func (c *Client) Notify(ctx context.Context, _ string, notification *notifiers.Notification) error {
+	body, err := json.Marshal(NewEvent(c.Source, notification))
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal CloudEvent for %s", notification.Name)
+	}
+
+	backoff := c.Backoff
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = c.send(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= c.MaxRetries {
+			break
+		}
+		log.Logger().Warnf("failed to send CloudEvent for %s to %s, retrying in %s: %s", notification.Name, c.URL, backoff.String(), err.Error())
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(backoff):
+			backoff *= 2
+			continue
+		}
+		break
+	}
+
+	deadLetterErr := c.deadLetter(body)
+	if deadLetterErr != nil {
+		log.Logger().Warnf("failed to write to dead letter file %s: %s", c.DeadLetterFile, deadLetterErr.Error())
+	}
+	return errors.Wrapf(err, "failed to send CloudEvent for %s to %s", notification.Name, c.URL)
+}
+
+// DeadLetter writes the notification to the dead letter file without trying to send it, e.g. when the queue of
+// the webhook is full
+func (c *Client) DeadLetter(notification *notifiers.Notification) error {
+	body, err := json.Marshal(NewEvent(c.Source, notification))
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal CloudEvent for %s", notification.Name)
+	}
+	return c.deadLetter(body)
+}
+
+// send posts the event returning true if the delivery can be retried
+func (c *Client) send(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", ContentType)
+	if len(c.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(c.Secret, body))
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	err = errors.Errorf("status %d %s", resp.StatusCode, string(data))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// deadLetter logs the event which could not be delivered and appends it to the dead letter file if there is one
+func (c *Client) deadLetter(body []byte) error {
+	log.Logger().Errorf("dead letter CloudEvent for %s: %s", c.URL, string(body))
+	if c.DeadLetterFile == "" {
+		return nil
+	}
+	f, err := os.OpenFile(c.DeadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(body, '\n'))
+	return err
+}
@@ -0,0 +1,92 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookRetriesAndSigns(t *testing.T) {
+	secret := []byte("my-secret")
+
+	var lock sync.Mutex
+	attempts := 0
+	var received *webhook.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err, "failed to read body")
+		assert.Equal(t, webhook.ContentType, r.Header.Get("Content-Type"), "content type")
+		assert.Equal(t, webhook.Sign(secret, body), r.Header.Get(webhook.SignatureHeader), "signature")
+
+		received = &webhook.Event{}
+		err = json.Unmarshal(body, received)
+		require.NoError(t, err, "failed to parse event %s", string(body))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	client := webhook.NewClient(server.URL, secret)
+	client.Backoff = time.Millisecond
+
+	err := client.Notify(context.TODO(), "", &notifiers.Notification{
+		Name:       "myorg-myrepo-main-3",
+		Status:     "Failed",
+		Owner:      "myorg",
+		Repository: "myrepo",
+		Branch:     "main",
+		Build:      "3",
+	})
+	require.NoError(t, err, "failed to notify")
+
+	assert.Equal(t, 2, attempts, "attempts")
+	require.NotNil(t, received, "received event")
+	assert.Equal(t, "1.0", received.SpecVersion, "specversion")
+	assert.Equal(t, webhook.EventType, received.Type, "type")
+	assert.Equal(t, "myorg-myrepo-main-3-failed", received.ID, "id")
+	require.NotNil(t, received.Data, "data")
+	assert.Equal(t, "myrepo", received.Data.Repository, "data.repository")
+}
+
+func TestWebhookDeadLetter(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	deadLetterFile := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+	client := webhook.NewClient(server.URL, nil)
+	client.Backoff = time.Millisecond
+	client.MaxRetries = 2
+	client.DeadLetterFile = deadLetterFile
+
+	err := client.Notify(context.TODO(), "", &notifiers.Notification{Name: "myorg-myrepo-main-4", Status: "Succeeded"})
+	require.Error(t, err, "should fail to notify")
+	assert.Equal(t, 3, attempts, "attempts")
+
+	data, err := ioutil.ReadFile(deadLetterFile)
+	require.NoError(t, err, "failed to read dead letter file")
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1, "dead letters")
+	assert.Contains(t, lines[0], "myorg-myrepo-main-4-succeeded", "dead letter")
+}
@@ -3,6 +3,7 @@ package slackbot
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -10,74 +11,156 @@ import (
 	informers "github.com/jenkins-x/jx-api/v4/pkg/client/informers/externalversions"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/pkg/errors"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
-func (o *Options) getPipelineActivities(ctx context.Context, org string, repo string, prn int) (*jenkinsv1.PipelineActivityList, error) {
-	return o.JXClient.JenkinsV1().PipelineActivities(o.Namespace).List(ctx, metav1.ListOptions{
+// getPipelineActivities returns the PipelineActivities of the pull request. There are none without a JXClient, e.g. when
+// previewing a PipelineActivity from a file
+func (o *Options) getPipelineActivities(ctx context.Context, ns string, org string, repo string, prn int) (*jenkinsv1.PipelineActivityList, error) {
+	if o.JXClient == nil {
+		return &jenkinsv1.PipelineActivityList{}, nil
+	}
+	return o.JXClient.JenkinsV1().PipelineActivities(ns).List(ctx, metav1.ListOptions{
 		LabelSelector: fmt.Sprintf("owner=%s, branch=PR-%d, repository=%s", org, prn, repo),
 	})
 }
 
+// previousPipelineFailed returns true if the last terminated build of the pipeline before the activity failed using
+// the recorded state of the pipeline if there is one or else the previous PipelineActivity
 func (o *Options) previousPipelineFailed(activity *jenkinsv1.PipelineActivity) (bool, error) {
-	build := activity.Spec.Build
-	if build == "" || build == "1" {
+	buildNumber, err := strconv.Atoi(activity.Spec.Build)
+	if err != nil || buildNumber <= 1 {
 		return false, nil
 	}
-	buildNumber, err := strconv.Atoi(build)
+	ctx := context.TODO()
+	if status, ok := o.previousPipelineState(ctx, activity, buildNumber); ok {
+		return isFailedStatus(status), nil
+	}
+	previous, err := o.previousPipelineActivity(ctx, activity, buildNumber)
 	if err != nil {
-		return false, nil
+		return false, err
 	}
-	if buildNumber <= 1 {
+	if previous == nil {
 		return false, nil
 	}
+	return isFailedStatus(previous.Spec.Status), nil
+}
 
-	// lets use the previous build number
-	name := activity.Name
-	idx := strings.LastIndex(name, "-")
-	if idx <= 0 {
-		return false, nil
+// previousPipelineActivity returns the terminated PipelineActivity of the same pipeline and context with the highest
+// build number below the given build or nil if there is none
+func (o *Options) previousPipelineActivity(ctx context.Context, activity *jenkinsv1.PipelineActivity, buildNumber int) (*jenkinsv1.PipelineActivity, error) {
+	history, err := o.pipelineHistory(ctx, activity, buildNumber)
+	if err != nil || len(history) == 0 {
+		return nil, err
 	}
-	previousName := name[0:idx] + "-" + strconv.Itoa(buildNumber-1)
+	return &history[0], nil
+}
 
-	ctx := context.TODO()
-	previous, err := o.JXClient.JenkinsV1().PipelineActivities(o.Namespace).Get(ctx, previousName, metav1.GetOptions{})
+// pipelineHistory returns the terminated PipelineActivities of the same pipeline and context as the activity with a
+// build number below the given build, newest first. There is no history without a JXClient
+func (o *Options) pipelineHistory(ctx context.Context, activity *jenkinsv1.PipelineActivity, buildNumber int) ([]jenkinsv1.PipelineActivity, error) {
+	if o.JXClient == nil {
+		return nil, nil
+	}
+	ns := o.activityNamespace(activity)
+	selector := pipelineSelector(activity)
+	list, err := o.JXClient.JenkinsV1().PipelineActivities(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
 	if err != nil {
-		if apierrors.IsNotFound(err) {
-			return false, nil
+		return nil, errors.Wrapf(err, "failed to list PipelineActivities in namespace %s with selector %s", ns, selector)
+	}
+	details := CreatePipelineDetails(activity)
+	var answer []jenkinsv1.PipelineActivity
+	for i := range list.Items {
+		a := &list.Items[i]
+		if a.Name == activity.Name || !a.Spec.Status.IsTerminated() {
+			continue
+		}
+		d := CreatePipelineDetails(a)
+		if d.GitOwner != details.GitOwner || d.GitRepository != details.GitRepository || d.BranchName != details.BranchName || d.Context != details.Context {
+			continue
 		}
-		return false, errors.Wrapf(err, "failed to find PipelineActivity %s in namespace %s", previousName, o.Namespace)
+		build, err := strconv.Atoi(d.Build)
+		if err != nil || build >= buildNumber {
+			continue
+		}
+		answer = append(answer, *a)
 	}
-	if previous == nil {
-		return false, nil
+	sort.Sort(sort.Reverse(byBuildNumber(answer)))
+	return answer, nil
+}
+
+// pipelineSelector returns the label selector of the PipelineActivities of the same pipeline as the activity
+func pipelineSelector(activity *jenkinsv1.PipelineActivity) string {
+	details := CreatePipelineDetails(activity)
+	labels := activity.Labels
+	selector := []string{
+		"owner=" + labelOrDefault(labels, "owner", details.GitOwner),
+		"repository=" + labelOrDefault(labels, "repository", details.GitRepository),
+		"branch=" + labelOrDefault(labels, "branch", details.BranchName),
 	}
-	if previous.Spec.Status == jenkinsv1.ActivityStatusTypeFailed || previous.Spec.Status == jenkinsv1.ActivityStatusTypeError {
-		// TODO should we record the previous message so we can update it?
-		return true, nil
+	if details.Context != "" {
+		selector = append(selector, "context="+labelOrDefault(labels, "context", details.Context))
 	}
-	return false, nil
+	return strings.Join(selector, ",")
 }
 
-// WatchActivities watches for pipeline activities
+func labelOrDefault(labels map[string]string, key, defaultValue string) string {
+	if value := labels[key]; value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// WatchActivities watches for pipeline activities in the watched namespaces
 func (o *Options) WatchActivities() chan struct{} {
-	log.Logger().Infof("Watching pipeline activities in namespace %s and slackbot config %s", o.Namespace, o.Name)
+	stopper := make(chan struct{})
+	o.startHandlerQueue(stopper)
+	o.watchActivities(stopper)
+	<-stopper
+	return stopper
+}
 
-	factory := informers.NewSharedInformerFactoryWithOptions(o.JXClient, 0, informers.WithNamespace(o.Namespace))
+// watchActivities starts the informers of the watched namespaces which run until the stopper is closed
+func (o *Options) watchActivities(stopper chan struct{}) {
+	if o.namespaceSources == nil {
+		o.namespaceSources = &namespaceSourceConfigs{}
+	}
+	if o.Config != nil && len(o.Config.Environments) > 0 {
+		o.WatchEnvironments(stopper)
+	}
+	if o.Config != nil && o.Config.Watchdog != nil {
+		go o.RunWatchdog(stopper)
+	}
+	for _, ns := range o.watchNamespaces() {
+		if ns == metav1.NamespaceAll {
+			log.Logger().Infof("Watching pipeline activities in all namespaces")
+		} else {
+			log.Logger().Infof("Watching pipeline activities in namespace %s", ns)
+		}
 
-	informer := factory.Jenkins().V1().PipelineActivities().Informer()
+		factory := informers.NewSharedInformerFactoryWithOptions(o.JXClient, 0, informers.WithNamespace(ns))
 
-	stopper := make(chan struct{})
+		informer := factory.Jenkins().V1().PipelineActivities().Informer()
 
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    o.onObj,
-		UpdateFunc: o.onUpdate,
-	})
+		// the informers of each namespace run concurrently so lets process their events one at a time
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				o.serialize(func() {
+					o.onObj(obj)
+				})
+			},
+			UpdateFunc: func(_ interface{}, newObj interface{}) {
+				o.serialize(func() {
+					o.onObj(newObj)
+				})
+			},
+		})
 
-	//go informer.Run(stopper)
-	informer.Run(stopper)
-	return stopper
+		go informer.Run(stopper)
+	}
 }
 
 func (o *Options) onObj(obj interface{}) {
@@ -96,7 +179,3 @@ func (o *Options) onObj(obj interface{}) {
 		log.Logger().Warnf("%v\n", err)
 	}
 }
-
-func (o Options) onUpdate(_ interface{}, newObj interface{}) {
-	o.onObj(newObj)
-}
@@ -0,0 +1,56 @@
+package slackbot
+
+import (
+	"sync"
+)
+
+// lruCache remembers the values of the most recently used keys
+type lruCache struct {
+	lock    sync.Mutex
+	size    int
+	keys    []string
+	entries map[string]interface{}
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:    size,
+		entries: map[string]interface{}{},
+	}
+}
+
+// get returns the value of the key and true if it has been added
+func (c *lruCache) get(key string) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	value, ok := c.entries[key]
+	if ok {
+		c.touch(key)
+	}
+	return value, ok
+}
+
+// put adds the value of the key, which may be nil, removing the least recently used key if full
+func (c *lruCache) put(key string, value interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.entries[key]; !ok && len(c.keys) >= c.size {
+		delete(c.entries, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+	c.entries[key] = value
+	c.touch(key)
+}
+
+// touch moves the key to the end of the keys
+func (c *lruCache) touch(key string) {
+	for i, k := range c.keys {
+		if k == key {
+			c.keys = append(c.keys[:i], c.keys[i+1:]...)
+			break
+		}
+	}
+	c.keys = append(c.keys, key)
+}
@@ -0,0 +1,33 @@
+package slackbot
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCache(t *testing.T) {
+	size := 3
+	c := newLRUCache(size)
+	_, ok := c.get("key-0")
+	assert.False(t, ok, "should not find an unknown key")
+
+	c.put("key-0", nil)
+	value, ok := c.get("key-0")
+	assert.True(t, ok, "should remember a nil value")
+	assert.Nil(t, value, "nil value")
+
+	for i := 1; i <= size; i++ {
+		// lets keep using the first key so that it is not removed
+		_, ok = c.get("key-0")
+		require.True(t, ok, "should remember the recently used key")
+		c.put(fmt.Sprintf("key-%d", i), i)
+	}
+	_, ok = c.get("key-1")
+	assert.False(t, ok, "should remove the least recently used key")
+	value, ok = c.get(fmt.Sprintf("key-%d", size))
+	require.True(t, ok, "should remember the latest key")
+	assert.Equal(t, size, value, "latest value")
+}
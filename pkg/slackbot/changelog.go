@@ -0,0 +1,268 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	"github.com/jenkins-x/go-scm/scm"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// maxChangelogCommits the maximum number of commits listed in a release message
+	maxChangelogCommits = 20
+
+	// maxChangelogPages the maximum number of pages of commits fetched looking for the previous release
+	maxChangelogPages = 5
+
+	// maxChangelogTagPages the maximum number of pages of tags fetched looking for the tags of the releases
+	maxChangelogTagPages = 10
+
+	// maxChangelogCacheSize the number of releases whose changelog is remembered
+	maxChangelogCacheSize = 100
+
+	changelogPageSize = 100
+)
+
+var pullRequestCommitPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\(#(\d+)\)\s*$`),
+	regexp.MustCompile(`^Merge pull request #(\d+)`),
+}
+
+// createChangelogAttachment returns an attachment listing the commits since the previous release of a successful
+// release pipeline or nil if it is not a release or the commits cannot be found. The changelog of a release is only
+// looked up once and not at all for releases older than the age cutoff
+func (o *Options) createChangelogAttachment(ctx context.Context, activity *jenkinsv1.PipelineActivity) *slack.Attachment {
+	spec := &activity.Spec
+	if o.ScmClient == nil || spec.Version == "" || spec.GitURL == "" || spec.Status != jenkinsv1.ActivityStatusTypeSucceeded {
+		return nil
+	}
+	if prn, _, err := getPullRequestNumber(activity); err != nil || prn > 0 {
+		return nil
+	}
+	if o.changelogs == nil {
+		o.changelogs = newLRUCache(maxChangelogCacheSize)
+	}
+	key := o.SlackUserResolver.Connection + "/" + activity.Namespace + "/" + activity.Name + "/" + spec.Version
+	if value, ok := o.changelogs.get(key); ok {
+		attachment, _ := value.(*slack.Attachment)
+		return attachment
+	}
+	if !o.withinAgeCutoff(activity) {
+		return nil
+	}
+	attachment := o.lookupChangelogAttachment(ctx, activity)
+	o.changelogs.put(key, attachment)
+	return attachment
+}
+
+// lookupChangelogAttachment finds the commits of the release and the slack users of their authors
+func (o *Options) lookupChangelogAttachment(ctx context.Context, activity *jenkinsv1.PipelineActivity) *slack.Attachment {
+	spec := &activity.Spec
+	commits, err := o.releaseCommits(ctx, activity)
+	if err != nil {
+		log.Logger().Warnf("failed to find the changes in %s: %s", activity.Name, err.Error())
+		return nil
+	}
+	if len(commits) == 0 {
+		return nil
+	}
+	resolver := &users.GitUserResolver{
+		GitProvider: o.ScmClient,
+	}
+	mentions := map[string]string{}
+	mention := func(author scm.Signature) string {
+		key := author.Login + "/" + author.Email
+		if m, ok := mentions[key]; ok {
+			return m
+		}
+		m := o.mentionCommitAuthor(author, resolver)
+		mentions[key] = m
+		return m
+	}
+	return &slack.Attachment{
+		CallbackID: "changelog:" + activity.Name,
+		Title:      fmt.Sprintf("Changes in %s", spec.Version),
+		TitleLink:  spec.ReleaseNotesURL,
+		Text:       changelogText(spec.GitURL, commits, mention),
+		MarkdownIn: []string{"text"},
+	}
+}
+
+// releaseCommits returns the commits between the previous release tag and the tag of the version of the activity,
+// newest first
+func (o *Options) releaseCommits(ctx context.Context, activity *jenkinsv1.PipelineActivity) ([]*scm.Commit, error) {
+	gitInfo, err := giturl.ParseGitURL(activity.Spec.GitURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse git URL %s", activity.Spec.GitURL)
+	}
+	fullName := scm.Join(gitInfo.Organisation, gitInfo.Name)
+	var tags []*scm.Reference
+	for page := 1; page <= maxChangelogTagPages; page++ {
+		pageTags, _, err := o.ScmClient.Git.ListTags(ctx, fullName, scm.ListOptions{Page: page, Size: changelogPageSize})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the tags of %s", fullName)
+		}
+		tags = append(tags, pageTags...)
+		if len(pageTags) < changelogPageSize {
+			break
+		}
+	}
+	current, previous := releaseTags(tags, activity.Spec.Version)
+	if current == nil {
+		return nil, errors.Errorf("no tag found for version %s of %s", activity.Spec.Version, fullName)
+	}
+
+	var answer []*scm.Commit
+	for page := 1; page <= maxChangelogPages; page++ {
+		commits, _, err := o.ScmClient.Git.ListCommits(ctx, fullName, scm.CommitListOptions{
+			Ref:  current.Sha,
+			Page: page,
+			Size: changelogPageSize,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the commits of %s at %s", fullName, current.Name)
+		}
+		for _, c := range commits {
+			if previous != nil && c.Sha == previous.Sha {
+				return answer, nil
+			}
+			answer = append(answer, c)
+		}
+		if len(commits) < changelogPageSize {
+			break
+		}
+	}
+	if previous != nil {
+		log.Logger().Debugf("did not find the previous release %s of %s in the last %d commits", previous.Name, fullName, len(answer))
+	}
+	return answer, nil
+}
+
+// releaseTags returns the tag of the given version and the tag of the highest version before it if there is one
+func releaseTags(tags []*scm.Reference, version string) (*scm.Reference, *scm.Reference) {
+	target := parseVersion(version)
+	if target == nil {
+		return nil, nil
+	}
+	var current, previous *scm.Reference
+	var previousVersion []int
+	for _, tag := range tags {
+		v := parseVersion(tagName(tag))
+		if v == nil {
+			continue
+		}
+		c := compareVersions(v, target)
+		switch {
+		case c == 0:
+			current = tag
+		case c < 0 && (previousVersion == nil || compareVersions(v, previousVersion) > 0):
+			previous = tag
+			previousVersion = v
+		}
+	}
+	return current, previous
+}
+
+func tagName(tag *scm.Reference) string {
+	return strings.TrimPrefix(tag.Name, "refs/tags/")
+}
+
+// parseVersion parses a version such as v1.2.3 returning nil if it is not a numeric release version
+func parseVersion(version string) []int {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	var answer []int
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil
+		}
+		answer = append(answer, n)
+	}
+	return answer
+}
+
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		x, y := 0, 0
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// changelogText renders the commits as a list with links to the commits and pull requests
+func changelogText(gitURL string, commits []*scm.Commit, mention func(author scm.Signature) string) string {
+	repoURL := strings.TrimSuffix(gitURL, ".git")
+	var lines []string
+	for i, c := range commits {
+		if i >= maxChangelogCommits {
+			lines = append(lines, fmt.Sprintf("... and %d more commits", len(commits)-maxChangelogCommits))
+			break
+		}
+		message := strings.TrimSpace(strings.SplitN(c.Message, "\n", 2)[0])
+		line := "• "
+		if len(c.Sha) >= 7 {
+			line += mergeShaText(gitURL, c.Sha) + " "
+		}
+		line += message
+		if prn := commitPullRequest(message); prn != "" && repoURL != "" {
+			line += " " + link("#"+prn, stringhelpers.UrlJoin(repoURL, "pull", prn))
+		}
+		if author := mention(c.Author); author != "" {
+			line += " by " + author
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commitPullRequest returns the number of the pull request a commit message refers to or an empty string
+func commitPullRequest(message string) string {
+	for _, r := range pullRequestCommitPatterns {
+		m := r.FindStringSubmatch(message)
+		if len(m) > 1 {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// mentionCommitAuthor returns a mention of the slack user of the commit author falling back to their git name
+func (o *Options) mentionCommitAuthor(author scm.Signature, resolver *users.GitUserResolver) string {
+	name := author.Login
+	if name == "" {
+		name = author.Name
+	}
+	id, err := o.resolveGitUserToSlackUser(&scm.User{
+		Login: author.Login,
+		Name:  author.Name,
+		Email: author.Email,
+	}, resolver)
+	if err != nil {
+		log.Logger().Debugf("failed to resolve the slack user of %s: %s", name, err.Error())
+	}
+	if id != "" {
+		return mentionUser(id)
+	}
+	return name
+}
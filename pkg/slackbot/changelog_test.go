@@ -0,0 +1,118 @@
+package slackbot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	"github.com/jenkins-x/go-scm/scm"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReleaseTags(t *testing.T) {
+	tags := []*scm.Reference{
+		{Name: "v1.0.0", Sha: "aaa"},
+		{Name: "v1.2.0", Sha: "ccc"},
+		{Name: "v1.10.0", Sha: "eee"},
+		{Name: "v1.9.0", Sha: "ddd"},
+		{Name: "v1.1.0", Sha: "bbb"},
+		{Name: "latest", Sha: "fff"},
+	}
+	current, previous := releaseTags(tags, "1.10.0")
+	require.NotNil(t, current, "current tag")
+	require.NotNil(t, previous, "previous tag")
+	assert.Equal(t, "v1.10.0", current.Name, "current tag")
+	assert.Equal(t, "v1.9.0", previous.Name, "previous tag")
+
+	current, previous = releaseTags(tags, "1.0.0")
+	require.NotNil(t, current, "current tag")
+	assert.Equal(t, "aaa", current.Sha, "current tag")
+	assert.Nil(t, previous, "first release")
+
+	current, _ = releaseTags(tags, "2.0.0")
+	assert.Nil(t, current, "untagged version")
+}
+
+func TestChangelogText(t *testing.T) {
+	commits := []*scm.Commit{
+		{
+			Sha:     "1234567890abcdef",
+			Message: "fix: handle empty channels (#42)\n\nsome details",
+			Author:  scm.Signature{Login: "alice"},
+		},
+		{
+			Sha:     "abcdef1234567890",
+			Message: "Merge pull request #41 from bob/feature",
+			Author:  scm.Signature{Login: "bob"},
+		},
+		{
+			Sha:     "fedcba0987654321",
+			Message: "chore: tidy",
+			Author:  scm.Signature{Name: "Carol"},
+		},
+	}
+	mention := func(author scm.Signature) string {
+		if author.Login == "alice" {
+			return "@alice"
+		}
+		return author.Name
+	}
+	text := changelogText("https://github.com/myorg/myrepo.git", commits, mention)
+	lines := strings.Split(text, "\n")
+	require.Len(t, lines, 3, "lines")
+	assert.Equal(t, "• <https://github.com/myorg/myrepo/commit/1234567890abcdef|1234567> fix: handle empty channels (#42) <https://github.com/myorg/myrepo/pull/42|#42> by @alice", lines[0])
+	assert.Equal(t, "• <https://github.com/myorg/myrepo/commit/abcdef1234567890|abcdef1> Merge pull request #41 from bob/feature <https://github.com/myorg/myrepo/pull/41|#41>", lines[1])
+	assert.Equal(t, "• <https://github.com/myorg/myrepo/commit/fedcba0987654321|fedcba0> chore: tidy by Carol", lines[2])
+
+	var many []*scm.Commit
+	for i := 0; i < maxChangelogCommits+5; i++ {
+		many = append(many, &scm.Commit{Message: "change"})
+	}
+	lines = strings.Split(changelogText("", many, mention), "\n")
+	require.Len(t, lines, maxChangelogCommits+1, "lines")
+	assert.Equal(t, "... and 5 more commits", lines[maxChangelogCommits], "last line")
+}
+
+func TestChangelogOnlyForReleases(t *testing.T) {
+	scmClient, _ := fakescm.NewDefault()
+	o := &Options{
+		ScmClient: scmClient,
+	}
+	ctx := context.TODO()
+
+	pr := testpipelines.CreateTestPipelineActivity("jx", "myorg", "myrepo", "PR-1", "pr", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+	pr.Spec.Version = "0.0.0-SNAPSHOT-PR-1-1"
+	assert.Nil(t, o.createChangelogAttachment(ctx, pr), "pull request")
+
+	failed := testpipelines.CreateTestPipelineActivity("jx", "myorg", "myrepo", "main", "release", "2", jenkinsv1.ActivityStatusTypeFailed)
+	failed.Spec.Version = "1.0.1"
+	assert.Nil(t, o.createChangelogAttachment(ctx, failed), "failed release")
+
+	noVersion := testpipelines.CreateTestPipelineActivity("jx", "myorg", "myrepo", "main", "release", "3", jenkinsv1.ActivityStatusTypeSucceeded)
+	assert.Nil(t, o.createChangelogAttachment(ctx, noVersion), "release without a version")
+}
+
+func TestChangelogIgnoresOldReleases(t *testing.T) {
+	scmClient, _ := fakescm.NewDefault()
+	o := &Options{
+		ScmClient: scmClient,
+	}
+	old := testpipelines.CreateTestPipelineActivity("jx", "myorg", "myrepo", "main", "release", "4", jenkinsv1.ActivityStatusTypeSucceeded)
+	old.Spec.Version = "1.0.4"
+	old.Spec.GitURL = "https://github.com/myorg/myrepo.git"
+	completed := metav1.NewTime(time.Now().Add(-48 * time.Hour))
+	old.CreationTimestamp = completed
+	old.Spec.StartedTimestamp = &completed
+	old.Spec.CompletedTimestamp = &completed
+
+	assert.Nil(t, o.createChangelogAttachment(context.TODO(), old), "old release")
+	require.NotNil(t, o.changelogs, "changelog cache")
+	_, ok := o.changelogs.get("/jx/" + old.Name + "/1.0.4")
+	assert.False(t, ok, "should not look up the changelog of an old release")
+}
@@ -0,0 +1,103 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+)
+
+// NotifyKindChanges only notifies when a build breaks, is fixed or is still failing so that a success following
+// a success is not notified
+const NotifyKindChanges v1alpha1.NotifyKind = "changes"
+
+// PipelineChange how the outcome of a terminated build compares with the previous builds of the same branch and
+// context
+type PipelineChange struct {
+	// Failed whether the build failed
+	Failed bool
+
+	// PreviousFailed whether the previous build failed
+	PreviousFailed bool
+
+	// Failures the number of consecutive failed builds up to and including this one
+	Failures int
+}
+
+// Changed returns true if the build broke, was fixed or is still failing
+func (c *PipelineChange) Changed() bool {
+	return c.Failed || c.PreviousFailed
+}
+
+// Label returns the label of the change such as "Fixed", "Broken" or "Still failing (3rd time)"
+func (c *PipelineChange) Label() string {
+	switch {
+	case c.Failed && c.Failures > 1:
+		return fmt.Sprintf("Still failing (%s time)", ordinal(c.Failures))
+	case c.Failed:
+		return "Broken"
+	case c.PreviousFailed:
+		return "Fixed"
+	default:
+		return ""
+	}
+}
+
+// pipelineChange compares the outcome of the activity with the previous builds of the pipeline using the recorded
+// state of the pipeline if there is one or else the previous PipelineActivities
+func (o *Options) pipelineChange(ctx context.Context, activity *jenkinsv1.PipelineActivity) (*PipelineChange, error) {
+	change := &PipelineChange{
+		Failed: isFailedStatus(activity.Spec.Status),
+	}
+	buildNumber, err := strconv.Atoi(activity.Spec.Build)
+	if err != nil {
+		change.setPreviousFailures(0)
+		return change, nil
+	}
+	if state := o.pipelineState(ctx, activity); state != nil {
+		if failures, ok := state.FailuresBefore(buildNumber); ok {
+			change.setPreviousFailures(failures)
+			return change, nil
+		}
+	}
+	history, err := o.pipelineHistory(ctx, activity, buildNumber)
+	if err != nil {
+		return nil, err
+	}
+	failures := 0
+	for i := range history {
+		if !isFailedStatus(history[i].Spec.Status) {
+			break
+		}
+		failures++
+	}
+	change.setPreviousFailures(failures)
+	return change, nil
+}
+
+func (c *PipelineChange) setPreviousFailures(failures int) {
+	c.PreviousFailed = failures > 0
+	if c.Failed {
+		c.Failures = failures + 1
+	}
+}
+
+// ordinal returns the number with its English ordinal suffix such as 1st, 2nd, 3rd or 11th
+func ordinal(n int) string {
+	suffix := "th"
+	switch n % 100 {
+	case 11, 12, 13:
+	default:
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
@@ -0,0 +1,121 @@
+package slackbot
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNotifyKindChanges(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+	channel := "#builds"
+
+	statuses := []jenkinsv1.ActivityStatusType{
+		jenkinsv1.ActivityStatusTypeSucceeded,
+		jenkinsv1.ActivityStatusTypeSucceeded,
+		jenkinsv1.ActivityStatusTypeFailed,
+		jenkinsv1.ActivityStatusTypeFailed,
+		jenkinsv1.ActivityStatusTypeError,
+		jenkinsv1.ActivityStatusTypeSucceeded,
+	}
+	expectedLabels := []string{"", "", "Broken", "Still failing (2nd time)", "Still failing (3rd time)", "Fixed"}
+
+	var activities []*jenkinsv1.PipelineActivity
+	var jxObjects []runtime.Object
+	for i, status := range statuses {
+		pa := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", strconv.Itoa(i+1), status)
+		activities = append(activities, pa)
+		jxObjects = append(jxObjects, pa)
+	}
+
+	scmClient, _ := fakescm.NewDefault()
+	slackClient := fakeslack.NewFakeSlack()
+	cfg := &v1alpha1.SlackNotify{
+		Channel:  channel,
+		Kind:     NotifyKindChanges,
+		Pipeline: v1alpha1.PipelineKindRelease,
+	}
+	o := &Options{
+		KubeClient:  fake.NewSimpleClientset(),
+		JXClient:    fakejx.NewSimpleClientset(jxObjects...),
+		ScmClient:   scmClient,
+		SlackClient: slackClient,
+		SourceConfigs: &v1alpha1.SourceConfig{
+			Spec: v1alpha1.SourceConfigSpec{
+				Groups: []v1alpha1.RepositoryGroup{
+					{
+						Provider: "https://fake.git",
+						Owner:    owner,
+						Repositories: []v1alpha1.Repository{
+							{
+								Name:  repo,
+								Slack: cfg,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	o.Namespace = ns
+
+	for i, pa := range activities {
+		expected := expectedLabels[i]
+		decision, err := o.DecideNotifyPipeline(pa, cfg)
+		require.NoError(t, err, "failed to decide if build %s should be notified", pa.Spec.Build)
+		assert.Equal(t, expected, decision.ChangeLabel(), "label for build %s", pa.Spec.Build)
+		assert.Equal(t, expected != "", decision.Notify, "should notify build %s", pa.Spec.Build)
+		assert.Equal(t, expected != "", o.shouldSendPipelineMessage(pa, cfg), "should notify build %s", pa.Spec.Build)
+	}
+
+	err := o.PipelineMessage(activities[4])
+	require.NoError(t, err, "failed to process pipeline %s", activities[4].Name)
+	require.Len(t, slackClient.Messages[channel], 1, "messages for channel %s", channel)
+
+	_, values, err := slack.UnsafeApplyMsgOptions("fakeToken", channel, "fakeapiurl", slackClient.Messages[channel][0].Options...)
+	require.NoError(t, err, "failed to render message")
+	assert.Contains(t, values.Get("attachments"), "Still failing (3rd time): Pipeline", "attachments")
+
+	err = o.PipelineMessage(activities[1])
+	require.NoError(t, err, "failed to process pipeline %s", activities[1].Name)
+	require.Len(t, slackClient.Messages[channel], 1, "should not notify a success following a success")
+}
+
+func TestPipelineChangeUsesState(t *testing.T) {
+	state := &PipelineState{}
+	for i, status := range []jenkinsv1.ActivityStatusType{
+		jenkinsv1.ActivityStatusTypeSucceeded,
+		jenkinsv1.ActivityStatusTypeFailed,
+		jenkinsv1.ActivityStatusTypeFailed,
+	} {
+		state.Record(i+1, status)
+	}
+	assert.Equal(t, 2, state.Failures, "consecutive failures")
+
+	failures, known := state.FailuresBefore(4)
+	assert.True(t, known, "failures before build 4")
+	assert.Equal(t, 2, failures, "failures before build 4")
+
+	failures, known = state.FailuresBefore(3)
+	assert.True(t, known, "failures before build 3")
+	assert.Equal(t, 1, failures, "failures before build 3")
+}
+
+func TestOrdinal(t *testing.T) {
+	for n, expected := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 102: "102nd", 111: "111th"} {
+		assert.Equal(t, expected, ordinal(n), "ordinal of %d", n)
+	}
+}
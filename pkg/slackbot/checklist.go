@@ -0,0 +1,142 @@
+package slackbot
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/slack-go/slack"
+)
+
+// useChecklist returns true if the pull request pipeline of the activity should be shown in a checklist message
+func (o *Options) useChecklist(activity *jenkinsv1.PipelineActivity) bool {
+	if o.Config == nil || o.Config.Checklist == nil {
+		return false
+	}
+	prn, _, err := getPullRequestNumber(activity)
+	if err != nil || prn <= 0 {
+		return false
+	}
+	repositories := o.Config.Checklist.Repositories
+	return len(repositories) == 0 || matchesRepository(repositories, activity)
+}
+
+// checklistActivities returns the activities of the same pull request commit as the activity, the first one created
+// which the checklist message is stored against and the latest build of each context sorted by context
+func (o *Options) checklistActivities(ctx context.Context, activity *jenkinsv1.PipelineActivity) (*jenkinsv1.PipelineActivity, []*jenkinsv1.PipelineActivity, []jenkinsv1.PipelineActivity, error) {
+	_, _, prActivities, err := o.findPipelineActivities(ctx, activity)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sha := activity.Spec.LastCommitSHA
+	var all []jenkinsv1.PipelineActivity
+	for i := range prActivities {
+		switch {
+		case prActivities[i].Name == activity.Name:
+			// the listed activity could be older than the one being notified
+			all = append(all, *activity)
+		case prActivities[i].Spec.LastCommitSHA == sha:
+			all = append(all, prActivities[i])
+		}
+	}
+
+	first := oldestActivity(all)
+	latest := map[string]*jenkinsv1.PipelineActivity{}
+	for i := range all {
+		a := &all[i]
+		pipelineCtx := CreatePipelineDetails(a).Context
+		if current := latest[pipelineCtx]; current == nil || isEarlierBuild(current, a) {
+			latest[pipelineCtx] = a
+		}
+	}
+	var contexts []*jenkinsv1.PipelineActivity
+	for _, a := range latest {
+		contexts = append(contexts, a)
+	}
+	sort.Slice(contexts, func(i, j int) bool {
+		return CreatePipelineDetails(contexts[i]).Context < CreatePipelineDetails(contexts[j]).Context
+	})
+	return first, contexts, all, nil
+}
+
+// createChecklistMessage creates the message listing the status of each context of the pull request commit of the
+// activity returning the activity the message is stored against, all the activities of the commit and whether the
+// message should be created if it is missing which, like other pipeline messages, is only if a context was recently
+// updated so that the checklists of old commits are not posted on startup
+func (o *Options) createChecklistMessage(ctx context.Context, activity *jenkinsv1.PipelineActivity, pr *scm.PullRequest) (*jenkinsv1.PipelineActivity, []jenkinsv1.PipelineActivity, []slack.MsgOption, bool, error) {
+	first, contexts, all, err := o.checklistActivities(ctx, activity)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+
+	messageText := repositoryName(activity)
+	if pr != nil && pr.Link != "" {
+		messageText += " : PR " + link(pullRequestName(pr.Link), pr.Link)
+	} else if prn, _, err := getPullRequestNumber(activity); err == nil {
+		messageText += " : PR-" + strconv.Itoa(prn)
+	}
+	if sha := activity.Spec.LastCommitSHA; len(sha) >= 7 {
+		messageText += " " + mergeShaText(activity.Spec.GitURL, sha)
+	}
+
+	attachments := []slack.Attachment{
+		{
+			CallbackID: "checklist:" + first.Name,
+			Color:      attachmentColor(checklistStatus(contexts)),
+			Title:      messageText,
+		},
+	}
+	for _, a := range contexts {
+		attachments = append(attachments, o.createChecklistAttachment(ctx, a))
+	}
+	options := []slack.MsgOption{
+		slack.MsgOptionAttachments(attachments...),
+	}
+	return first, all, options, o.withinAgeCutoff(contexts...), nil
+}
+
+// createChecklistAttachment creates the line of the checklist for the latest build of a context
+func (o *Options) createChecklistAttachment(ctx context.Context, activity *jenkinsv1.PipelineActivity) slack.Attachment {
+	status := pipelineStatus(activity)
+	pipelineCtx := activity.Spec.Context
+	if pipelineCtx == "" {
+		pipelineCtx = "Build"
+	}
+	text := pipelineCtx + " " + link("#"+activity.Spec.Build, o.pipelineBuildURL(activity))
+	if emoji := o.statusString(status); emoji != "" {
+		text = emoji + " " + text
+	}
+	if duration, ok := activityDuration(activity); ok {
+		text += " in " + formatDuration(duration)
+	}
+	if failure := o.describeFailure(ctx, activity); failure != "" {
+		text += " " + failure
+	}
+	if warning := stuckWarning(activity, time.Now()); warning != "" {
+		text += "\n" + warning
+	}
+	return slack.Attachment{
+		CallbackID: "pipelineactivity:" + activity.Name,
+		Color:      attachmentColor(status),
+		Text:       text,
+	}
+}
+
+// checklistStatus returns the overall status of the contexts which is failed if any failed, running while any are
+// still running and succeeded once they have all succeeded
+func checklistStatus(contexts []*jenkinsv1.PipelineActivity) jenkinsv1.ActivityStatusType {
+	answer := jenkinsv1.ActivityStatusTypeSucceeded
+	for _, a := range contexts {
+		status := pipelineStatus(a)
+		switch {
+		case isFailedStatus(status):
+			return status
+		case status != jenkinsv1.ActivityStatusTypeSucceeded:
+			answer = jenkinsv1.ActivityStatusTypeRunning
+		}
+	}
+	return answer
+}
@@ -0,0 +1,155 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestChecklistMessage(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+	channel := "#builds"
+	sha := "1234567890abcdef"
+	now := time.Now()
+
+	createActivity := func(pipelineCtx, build, commit string, status jenkinsv1.ActivityStatusType, age time.Duration) *jenkinsv1.PipelineActivity {
+		pa := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "PR-1", pipelineCtx, build, status)
+		pa.Spec.LastCommitSHA = commit
+		pa.CreationTimestamp = metav1.NewTime(now.Add(-age))
+		return pa
+	}
+	build := createActivity("pr-build", "1", sha, jenkinsv1.ActivityStatusTypeRunning, 2*time.Minute)
+	lint := createActivity("lint", "1", sha, jenkinsv1.ActivityStatusTypeSucceeded, time.Minute)
+
+	o, slackClient := createChecklistOptions(ns, owner, repo, channel, build, lint)
+
+	err := o.PipelineMessage(lint)
+	require.NoError(t, err, "failed to process %s", lint.Name)
+	require.Len(t, slackClient.Messages[channel], 1, "should post one checklist message")
+
+	_, values, err := slack.UnsafeApplyMsgOptions("fakeToken", channel, "fakeapiurl", slackClient.Messages[channel][0].Options...)
+	require.NoError(t, err, "failed to render message")
+	attachments := values.Get("attachments")
+	assert.Contains(t, attachments, "checklist:"+build.Name, "the message is stored against the first activity")
+	assert.Contains(t, attachments, "lint", "attachments")
+	assert.Contains(t, attachments, "pr-build", "attachments")
+
+	// the other context updates its line of the same message
+	build.Spec.Status = jenkinsv1.ActivityStatusTypeFailed
+	err = o.PipelineMessage(build)
+	require.NoError(t, err, "failed to process %s", build.Name)
+	require.Len(t, slackClient.Messages[channel], 2, "should update the checklist message")
+
+	_, values, err = slack.UnsafeApplyMsgOptions("fakeToken", channel, "fakeapiurl", slackClient.Messages[channel][1].Options...)
+	require.NoError(t, err, "failed to render message")
+	assert.Equal(t, slackClient.Messages[channel][0].Timestamp, values.Get("ts"), "should update the first message")
+	assert.Contains(t, values.Get("attachments"), `"color":"danger"`, "a failed context fails the checklist")
+
+	// a new commit gets a new checklist
+	lint2 := createActivity("lint", "2", "fedcba0987654321", jenkinsv1.ActivityStatusTypeRunning, 0)
+	_, err = o.JXClient.JenkinsV1().PipelineActivities(ns).Create(context.TODO(), lint2, metav1.CreateOptions{})
+	require.NoError(t, err, "failed to create %s", lint2.Name)
+	err = o.PipelineMessage(lint2)
+	require.NoError(t, err, "failed to process %s", lint2.Name)
+	require.Len(t, slackClient.Messages[channel], 3, "should post a message for the new commit")
+
+	_, values, err = slack.UnsafeApplyMsgOptions("fakeToken", channel, "fakeapiurl", slackClient.Messages[channel][2].Options...)
+	require.NoError(t, err, "failed to render message")
+	assert.Empty(t, values.Get("ts"), "should be a new message")
+	assert.NotContains(t, values.Get("attachments"), "pr-build", "the new commit has only been linted")
+}
+
+func TestChecklistMessageIgnoresOldCommits(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+	channel := "#builds"
+	old := metav1.NewTime(time.Now().Add(-48 * time.Hour))
+
+	build := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "PR-1", "pr-build", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+	lint := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "PR-1", "lint", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+	for _, a := range []*jenkinsv1.PipelineActivity{build, lint} {
+		a.Spec.LastCommitSHA = "1234567890abcdef"
+		a.CreationTimestamp = old
+		a.Spec.StartedTimestamp = &old
+		a.Spec.CompletedTimestamp = &old
+	}
+	o, slackClient := createChecklistOptions(ns, owner, repo, channel, build, lint)
+
+	err := o.PipelineMessage(lint)
+	require.NoError(t, err, "failed to process %s", lint.Name)
+	assert.Empty(t, slackClient.Messages[channel], "should not post a checklist for an old commit")
+
+	o.IgnoreAgeCutoff = true
+	err = o.PipelineMessage(lint)
+	require.NoError(t, err, "failed to process %s", lint.Name)
+	assert.Len(t, slackClient.Messages[channel], 1, "should post a checklist when ignoring the age cutoff")
+}
+
+func TestChecklistStatus(t *testing.T) {
+	create := func(status jenkinsv1.ActivityStatusType) *jenkinsv1.PipelineActivity {
+		return testpipelines.CreateTestPipelineActivity("jx", "myorg", "myrepo", "PR-1", "lint", "1", status)
+	}
+	succeeded := create(jenkinsv1.ActivityStatusTypeSucceeded)
+	running := create(jenkinsv1.ActivityStatusTypeRunning)
+	failed := create(jenkinsv1.ActivityStatusTypeFailed)
+
+	assert.Equal(t, jenkinsv1.ActivityStatusTypeSucceeded, checklistStatus([]*jenkinsv1.PipelineActivity{succeeded, succeeded}))
+	assert.Equal(t, jenkinsv1.ActivityStatusTypeRunning, checklistStatus([]*jenkinsv1.PipelineActivity{succeeded, running}))
+	assert.Equal(t, jenkinsv1.ActivityStatusTypeFailed, checklistStatus([]*jenkinsv1.PipelineActivity{running, failed}))
+}
+
+func createChecklistOptions(ns, owner, repo, channel string, activities ...*jenkinsv1.PipelineActivity) (*Options, *fakeslack.FakeSlack) {
+	var objects []runtime.Object
+	for _, a := range activities {
+		objects = append(objects, a)
+	}
+	scmClient, _ := fakescm.NewDefault()
+	slackClient := fakeslack.NewFakeSlack()
+	o := &Options{
+		KubeClient:  fake.NewSimpleClientset(),
+		JXClient:    fakejx.NewSimpleClientset(objects...),
+		ScmClient:   scmClient,
+		SlackClient: slackClient,
+		SourceConfigs: &v1alpha1.SourceConfig{
+			Spec: v1alpha1.SourceConfigSpec{
+				Groups: []v1alpha1.RepositoryGroup{
+					{
+						Provider: "https://fake.git",
+						Owner:    owner,
+						Repositories: []v1alpha1.Repository{
+							{
+								Name: repo,
+								Slack: &v1alpha1.SlackNotify{
+									Channel:  channel,
+									Kind:     v1alpha1.NotifyKindAlways,
+									Pipeline: v1alpha1.PipelineKindAll,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Config: &Config{
+			Checklist: &Checklist{},
+		},
+	}
+	o.Namespace = ns
+	return o, slackClient
+}
@@ -0,0 +1,131 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+const notifyCommandHelp = "Usage: `/jx notify [setting]` where setting is one of:\n" +
+	"• `only-failures on|off` - only direct message me about failed pipelines\n" +
+	"• `mute owner/repo` - stop notifying me about a repository (wildcards such as `owner/*` are allowed)\n" +
+	"• `unmute owner/repo` - start notifying me about a repository again\n" +
+	"• `quiet 22:00-07:00 [Europe/London]` - do not direct message me during these hours\n" +
+	"• `quiet off` - disable quiet hours\n" +
+	"• `dm` - send me direct messages\n" +
+	"• `mention` - mention me in the channel message instead of direct messages\n" +
+	"Run `/jx notify` on its own to see your current settings"
+
+// HandleCommand processes a slash command returning the ephemeral response to show the user
+func (o *Options) HandleCommand(cmd *slack.SlashCommand) (*slack.Msg, error) {
+	args := strings.Fields(cmd.Text)
+	if len(args) == 0 {
+		return ephemeralMessage(notifyCommandHelp), nil
+	}
+	switch args[0] {
+	case "notify":
+		text, err := o.handleNotifyCommand(context.TODO(), cmd.UserID, args[1:])
+		if err != nil {
+			return nil, err
+		}
+		return ephemeralMessage(text), nil
+	default:
+		return ephemeralMessage(fmt.Sprintf("unknown command `%s`\n%s", args[0], notifyCommandHelp)), nil
+	}
+}
+
+// handleNotifyCommand updates the notification preferences of the user returning the text to reply with
+func (o *Options) handleNotifyCommand(ctx context.Context, userID string, args []string) (string, error) {
+	if o.Preferences == nil {
+		return "", errors.Errorf("no preference store configured")
+	}
+	prefs, err := o.Preferences.Get(ctx, userID)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load preferences of user %s", userID)
+	}
+	if len(args) == 0 {
+		return "Your notification settings:\n" + describePreferences(prefs), nil
+	}
+
+	switch args[0] {
+	case "help":
+		return notifyCommandHelp, nil
+	case "only-failures":
+		if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+			return "Usage: `/jx notify only-failures on|off`", nil
+		}
+		prefs.OnlyFailures = args[1] == "on"
+	case "mute":
+		if len(args) != 2 {
+			return "Usage: `/jx notify mute owner/repo`", nil
+		}
+		if !stringsContain(prefs.MutedRepositories, args[1]) {
+			prefs.MutedRepositories = append(prefs.MutedRepositories, args[1])
+		}
+	case "unmute":
+		if len(args) != 2 {
+			return "Usage: `/jx notify unmute owner/repo`", nil
+		}
+		var repos []string
+		for _, r := range prefs.MutedRepositories {
+			if r != args[1] {
+				repos = append(repos, r)
+			}
+		}
+		prefs.MutedRepositories = repos
+	case "quiet":
+		if len(args) == 2 && args[1] == "off" {
+			prefs.QuietHours = nil
+			break
+		}
+		if len(args) < 2 || len(args) > 3 {
+			return "Usage: `/jx notify quiet 22:00-07:00 [time zone]` or `/jx notify quiet off`", nil
+		}
+		times := strings.SplitN(args[1], "-", 2)
+		if len(times) != 2 {
+			return "Usage: `/jx notify quiet 22:00-07:00 [time zone]` or `/jx notify quiet off`", nil
+		}
+		quiet := &QuietHours{
+			Start: times[0],
+			End:   times[1],
+		}
+		if len(args) == 3 {
+			quiet.TimeZone = args[2]
+		}
+		_, err = quiet.Contains(time.Now())
+		if err != nil {
+			return fmt.Sprintf("Invalid quiet hours: %s", err.Error()), nil
+		}
+		prefs.QuietHours = quiet
+	case DeliveryDirectMessage, DeliveryMention:
+		prefs.Delivery = args[0]
+	default:
+		return fmt.Sprintf("Unknown setting `%s`\n%s", args[0], notifyCommandHelp), nil
+	}
+
+	err = o.Preferences.Save(ctx, userID, prefs)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to save preferences of user %s", userID)
+	}
+	return "Updated your notification settings:\n" + describePreferences(prefs), nil
+}
+
+func ephemeralMessage(text string) *slack.Msg {
+	return &slack.Msg{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         text,
+	}
+}
+
+func stringsContain(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
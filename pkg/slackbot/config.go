@@ -0,0 +1,326 @@
+package slackbot
+
+import (
+	"path/filepath"
+
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConfigFileName the name of the optional jx-slack configuration file in the .jx directory of the
+	// development git repository
+	ConfigFileName = "slack.yaml"
+
+	// sourceConfigFileName the name of the SourceConfig file in the .jx/gitops directory of the development git
+	// repository
+	sourceConfigFileName = "source-config.yaml"
+)
+
+// Config the jx-slack configuration which cannot be expressed in the slack block of the SourceConfig
+type Config struct {
+	// Connections the additional named slack workspaces which a SourceConfig slack channel can refer to
+	// using the "connection:#channel" syntax
+	Connections []Connection `json:"connections,omitempty"`
+
+	// Webhooks the HTTP endpoints which are sent a CloudEvent for each pipeline notification
+	Webhooks []Webhook `json:"webhooks,omitempty"`
+
+	// Emails the email notifications such as failed release pipelines for people who do not use slack
+	Emails []Email `json:"emails,omitempty"`
+
+	// Environments the channels notified when a new version of an app is promoted to an environment
+	Environments []EnvironmentNotify `json:"environments,omitempty"`
+
+	// EnvironmentStatus the channels with a pinned message showing the current version of each application in
+	// each environment
+	EnvironmentStatus []EnvironmentStatusChannel `json:"environmentStatus,omitempty"`
+
+	// Watchdog warns when pipelines have been running or pending for too long
+	Watchdog *Watchdog `json:"watchdog,omitempty"`
+
+	// Durations configures how the duration of a pipeline is compared with its previous builds
+	Durations *Durations `json:"durations,omitempty"`
+
+	// TestReports the JUnit reports summarised in the messages of completed pipelines
+	TestReports *TestReports `json:"testReports,omitempty"`
+
+	// Checklist posts one message per pull request commit listing the status of each pipeline context rather than a
+	// message per pipeline
+	Checklist *Checklist `json:"checklist,omitempty"`
+
+	// Reviews configures the approvals shown in pull request review messages
+	Reviews *Reviews `json:"reviews,omitempty"`
+}
+
+// Connection a named connection to a slack workspace or another chat backend
+type Connection struct {
+	// Name the name used to refer to this connection in the SourceConfig slack channel
+	Name string `json:"name"`
+
+	// Kind the kind of backend: slack, mattermost or teams. Defaults to slack
+	Kind string `json:"kind,omitempty"`
+
+	// URL the slack API URL, which defaults to the public slack API, or the URL of the Mattermost server. Mattermost
+	// connections without a URL use an incoming webhook
+	URL string `json:"url,omitempty"`
+
+	// Team the name of the Mattermost team whose channels are posted to via the Mattermost API
+	Team string `json:"team,omitempty"`
+
+	// TokenSecret the name of the Secret in the namespace which contains the bot token or, for mattermost without
+	// a URL and teams, the incoming webhook URL
+	TokenSecret string `json:"tokenSecret"`
+
+	// TokenKey the key of the token in the Secret. Defaults to "token" for slack and the Mattermost API and "url"
+	// for the webhook kinds
+	TokenKey string `json:"tokenKey,omitempty"`
+
+	// TeamID the ID of the slack workspace of the connection so that its events, such as opening the App Home
+	// tab, are handled with this connection
+	TeamID string `json:"teamId,omitempty"`
+}
+
+// Webhook a HTTP endpoint which receives pipeline notifications as CloudEvents
+type Webhook struct {
+	// Name the name of the webhook
+	Name string `json:"name"`
+
+	// URL the URL the CloudEvents are posted to
+	URL string `json:"url"`
+
+	// Secret the optional name of the Secret in the namespace containing the key used to sign the events
+	Secret string `json:"secret,omitempty"`
+
+	// SecretKey the key in the Secret. Defaults to "secret"
+	SecretKey string `json:"secretKey,omitempty"`
+
+	// MaxRetries the number of times a failed delivery is retried
+	MaxRetries *int `json:"maxRetries,omitempty"`
+
+	// DeadLetterFile the optional file the events which could not be delivered are appended to
+	DeadLetterFile string `json:"deadLetterFile,omitempty"`
+
+	// Repositories the repositories (in owner/name form, wildcards allowed) to notify on. Defaults to all repositories
+	Repositories []string `json:"repositories,omitempty"`
+
+	// Notify the same rules as the SourceConfig slack block. Defaults to every status of every pipeline
+	Notify *v1alpha1.SlackNotify `json:"notify,omitempty"`
+}
+
+// Email sends emails via SMTP for the pipelines which match the notify rules
+type Email struct {
+	// Name the name of the email notification
+	Name string `json:"name"`
+
+	// Host the SMTP server host
+	Host string `json:"host"`
+
+	// Port the SMTP server port. Defaults to 587
+	Port int `json:"port,omitempty"`
+
+	// From the sender address
+	From string `json:"from"`
+
+	// Secret the optional name of the Secret in the namespace containing the username and password keys used to
+	// authenticate with the SMTP server
+	Secret string `json:"secret,omitempty"`
+
+	// Users the names of the Jenkins X Users whose email address are sent the emails
+	Users []string `json:"users,omitempty"`
+
+	// Addresses additional email addresses to send the emails to
+	Addresses []string `json:"addresses,omitempty"`
+
+	// Repositories the repositories (in owner/name form, wildcards allowed) to notify on. Defaults to all repositories
+	Repositories []string `json:"repositories,omitempty"`
+
+	// Notify the same rules as the SourceConfig slack block. Defaults to failed release pipelines
+	Notify *v1alpha1.SlackNotify `json:"notify,omitempty"`
+}
+
+// EnvironmentNotify posts a message to a channel when a new version of an app is released to an environment
+type EnvironmentNotify struct {
+	// Channel the channel to post to which may be prefixed with a connection name
+	Channel string `json:"channel"`
+
+	// Environments the names of the environments to notify on. Defaults to all permanent environments
+	Environments []string `json:"environments,omitempty"`
+}
+
+// Matches returns true if the environment is notified
+func (e *EnvironmentNotify) Matches(environment string) bool {
+	return len(e.Environments) == 0 || stringsContain(e.Environments, environment)
+}
+
+// EnvironmentStatusChannel a channel with a pinned message which is edited in place whenever a promotion completes
+type EnvironmentStatusChannel struct {
+	// Channel the channel to post to which may be prefixed with a connection name
+	Channel string `json:"channel"`
+
+	// Environments the names of the environments shown as columns in order. Defaults to every environment promoted to
+	Environments []string `json:"environments,omitempty"`
+
+	// Repositories the repositories (in owner/name form, wildcards allowed) shown. Defaults to all repositories
+	Repositories []string `json:"repositories,omitempty"`
+}
+
+// Watchdog periodically checks for pipelines which have been running or pending for too long. The thresholds here
+// are the defaults which a repository can override in the watchdog block of its slack configuration in the
+// SourceConfig
+type Watchdog struct {
+	// Interval how often the PipelineActivities are checked. Defaults to 5 minutes
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// MaxAge how long ago a pipeline can have started and still be checked. Defaults to 24 hours
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+
+	// Running how long a pipeline can run before a warning. Defaults to 1 hour
+	Running *metav1.Duration `json:"running,omitempty"`
+
+	// Pending how long a pipeline can be pending without a pod before a warning. Defaults to 1 hour
+	Pending *metav1.Duration `json:"pending,omitempty"`
+
+	// Repositories overrides the thresholds for some repositories. The watchdog block of the slack configuration
+	// of a repository in the SourceConfig takes precedence
+	Repositories []WatchdogThresholds `json:"repositories,omitempty"`
+}
+
+// WatchdogThresholds the thresholds of the repositories which take longer or shorter than the defaults. It is also
+// the watchdog block of the slack configuration of a repository in the SourceConfig where the repositories are
+// not used
+type WatchdogThresholds struct {
+	// Repositories the repositories (in owner/name form, wildcards allowed) the thresholds apply to
+	Repositories []string `json:"repositories,omitempty"`
+
+	// Running how long a pipeline can run before a warning. Defaults to the watchdog running threshold
+	Running *metav1.Duration `json:"running,omitempty"`
+
+	// Pending how long a pipeline can be pending without a pod before a warning. Defaults to the watchdog pending
+	// threshold
+	Pending *metav1.Duration `json:"pending,omitempty"`
+}
+
+// Durations configures the comparison of the duration of a pipeline with the median of its previous builds
+type Durations struct {
+	// Builds the number of previous successful builds the median is taken from. Defaults to 10
+	Builds int `json:"builds,omitempty"`
+
+	// Regression the percentage over the median at which a pipeline is flagged as slower. Defaults to 50
+	Regression int `json:"regression,omitempty"`
+}
+
+// TestReports configures where the JUnit XML report of a pipeline is read from and how it is shown
+type TestReports struct {
+	// Annotation the PipelineActivity annotation containing the URL of the report. Defaults to
+	// tests.slack.jenkins-x.io/report
+	Annotation string `json:"annotation,omitempty"`
+
+	// Path the path of the report relative to the build logs URL such as junit.xml. The build logs are not checked
+	// if this is empty
+	Path string `json:"path,omitempty"`
+
+	// MaxFailures the maximum number of failing tests listed. Defaults to 5
+	MaxFailures int `json:"maxFailures,omitempty"`
+
+	// Thread lists the failing tests in a reply to the pipeline message rather than in the message
+	Thread bool `json:"thread,omitempty"`
+}
+
+// Checklist configures which pull requests get a single checklist message for all of their pipeline contexts
+type Checklist struct {
+	// Repositories the repositories (in owner/name form, wildcards allowed) using checklists. Defaults to all
+	// repositories
+	Repositories []string `json:"repositories,omitempty"`
+}
+
+// Reviews configures how many approvals the pull requests of each repository need
+type Reviews struct {
+	// RequiredApprovals the number of approvals a pull request needs. Defaults to 1
+	RequiredApprovals int `json:"requiredApprovals,omitempty"`
+
+	// Repositories overrides the number of approvals for some repositories
+	Repositories []RequiredApprovals `json:"repositories,omitempty"`
+}
+
+// RequiredApprovals the number of approvals needed by the pull requests of some repositories
+type RequiredApprovals struct {
+	// Repositories the repositories (in owner/name form, wildcards allowed) the number applies to
+	Repositories []string `json:"repositories"`
+
+	// RequiredApprovals the number of approvals a pull request needs. Defaults to the number of the reviews
+	RequiredApprovals int `json:"requiredApprovals"`
+}
+
+// LoadConfig loads the jx-slack configuration from the .jx directory of the given development git
+// repository directory returning an empty configuration if there is no file
+func LoadConfig(dir string) (*Config, error) {
+	config := &Config{}
+	path := filepath.Join(dir, ".jx", ConfigFileName)
+	exists, err := files.FileExists(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+	if !exists {
+		return config, nil
+	}
+	err = yamls.LoadFile(path, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load jx-slack config file %s", path)
+	}
+	return config, nil
+}
+
+// repositoryWatchdogConfig the watchdog blocks of the slack configurations of the groups and repositories of a
+// SourceConfig. The SlackNotify type belongs to jx-gitops so the file is read again to find them
+type repositoryWatchdogConfig struct {
+	Spec struct {
+		Slack  *repositoryWatchdogs `json:"slack,omitempty"`
+		Groups []struct {
+			Owner        string               `json:"owner"`
+			Slack        *repositoryWatchdogs `json:"slack,omitempty"`
+			Repositories []struct {
+				Name  string               `json:"name"`
+				Slack *repositoryWatchdogs `json:"slack,omitempty"`
+			} `json:"repositories,omitempty"`
+		} `json:"groups,omitempty"`
+	} `json:"spec"`
+}
+
+type repositoryWatchdogs struct {
+	Watchdog *WatchdogThresholds `json:"watchdog,omitempty"`
+}
+
+// LoadRepositoryWatchdogs loads the watchdog thresholds of the repositories in the SourceConfig in the given dir
+// by their owner/name. As with the rest of the slack configuration, repositories without their own watchdog block
+// inherit the one of their group or of the SourceConfig
+func LoadRepositoryWatchdogs(dir string) (map[string]*WatchdogThresholds, error) {
+	answer := map[string]*WatchdogThresholds{}
+	path := filepath.Join(dir, ".jx", "gitops", sourceConfigFileName)
+	exists, err := files.FileExists(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+	if !exists {
+		return answer, nil
+	}
+	config := &repositoryWatchdogConfig{}
+	err = yamls.LoadFile(path, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the watchdog thresholds from %s", path)
+	}
+	for _, g := range config.Spec.Groups {
+		for _, r := range g.Repositories {
+			for _, slackConfig := range []*repositoryWatchdogs{r.Slack, g.Slack, config.Spec.Slack} {
+				if slackConfig != nil && slackConfig.Watchdog != nil {
+					answer[g.Owner+"/"+r.Name] = slackConfig.Watchdog
+					break
+				}
+			}
+		}
+	}
+	return answer, nil
+}
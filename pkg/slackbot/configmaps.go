@@ -0,0 +1,84 @@
+package slackbot
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var invalidConfigMapKeyChars = regexp.MustCompile(`[^-._a-zA-Z0-9]+`)
+
+// configMapKey converts the text such as a channel name into a valid ConfigMap key
+func configMapKey(text string) string {
+	return invalidConfigMapKeyChars.ReplaceAllString(text, "-")
+}
+
+// loadConfigMapValue unmarshals the YAML value of the key in the ConfigMap returning false if there is no value
+func loadConfigMapValue(ctx context.Context, kubeClient kubernetes.Interface, ns, name, key string, value interface{}) (bool, error) {
+	cm, err := kubeClient.CoreV1().ConfigMaps(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to find ConfigMap %s in namespace %s", name, ns)
+	}
+	text := cm.Data[key]
+	if text == "" {
+		return false, nil
+	}
+	err = yaml.Unmarshal([]byte(text), value)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to parse %s in ConfigMap %s", key, name)
+	}
+	return true, nil
+}
+
+// saveConfigMapValue stores the value as YAML in the key of the ConfigMap, lazily creating the ConfigMap if required
+func saveConfigMapValue(ctx context.Context, kubeClient kubernetes.Interface, ns, name, key string, value interface{}) error {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", key)
+	}
+	return updateConfigMapData(ctx, kubeClient, ns, name, func(values map[string]string) {
+		values[key] = string(data)
+	})
+}
+
+// updateConfigMapData applies the change to the data of the ConfigMap, lazily creating the ConfigMap if required
+func updateConfigMapData(ctx context.Context, kubeClient kubernetes.Interface, ns, name string, change func(values map[string]string)) error {
+	configMaps := kubeClient.CoreV1().ConfigMaps(ns)
+	cm, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to find ConfigMap %s in namespace %s", name, ns)
+		}
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Data: map[string]string{},
+		}
+		change(cm.Data)
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to create ConfigMap %s in namespace %s", name, ns)
+		}
+		return nil
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	change(cm.Data)
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update ConfigMap %s in namespace %s", name, ns)
+	}
+	return nil
+}
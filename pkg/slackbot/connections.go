@@ -0,0 +1,222 @@
+package slackbot
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/email"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/mattermost"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/teams"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/webhook"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// connectionSeparator separates the connection name from the channel in a SourceConfig slack channel
+	connectionSeparator = ":"
+
+	defaultTokenKey   = "token"
+	defaultWebhookKey = "url"
+	defaultSecretKey  = "secret"
+)
+
+// SplitChannel splits a SourceConfig slack channel of the form "connection:#channel" into the connection
+// name and the channel. Channels without a connection name use the default slack connection
+func SplitChannel(channel string) (string, string) {
+	values := strings.SplitN(channel, connectionSeparator, 2)
+	if len(values) < 2 {
+		return "", channel
+	}
+	return values[0], values[1]
+}
+
+// connectionChannel returns the channel in the "connection:#channel" form for channels of connections other than the
+// default so that channels with the same name in different workspaces are remembered separately
+func connectionChannel(connection string, channel string) string {
+	if connection == "" {
+		return channel
+	}
+	return connection + connectionSeparator + channel
+}
+
+// createConnections lazily creates the slack clients, notifiers, webhooks and emails in the configuration
+func (o *Options) createConnections(ctx context.Context) error {
+	if o.Config == nil {
+		return nil
+	}
+	if o.Connections == nil {
+		o.Connections = map[string]slacker.Interface{}
+	}
+	if o.Notifiers == nil {
+		o.Notifiers = map[string]notifiers.Notifier{}
+	}
+	for i := range o.Config.Connections {
+		c := &o.Config.Connections[i]
+		if c.Name == "" {
+			return errors.Errorf("slack connection %d has no name", i)
+		}
+		if o.Connections[c.Name] != nil || o.Notifiers[c.Name] != nil {
+			continue
+		}
+		if c.TokenSecret == "" {
+			return errors.Errorf("slack connection %s has no tokenSecret", c.Name)
+		}
+		key := c.TokenKey
+		if key == "" {
+			key = defaultTokenKey
+			if c.Kind != "" && c.Kind != notifiers.KindSlack && !isMattermostAPI(c) {
+				key = defaultWebhookKey
+			}
+		}
+		secret, err := o.KubeClient.CoreV1().Secrets(o.Namespace).Get(ctx, c.TokenSecret, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to find Secret %s in namespace %s for slack connection %s", c.TokenSecret, o.Namespace, c.Name)
+		}
+		token := string(secret.Data[key])
+		if token == "" {
+			return errors.Errorf("no %s key in Secret %s for slack connection %s", key, c.TokenSecret, c.Name)
+		}
+
+		switch c.Kind {
+		case "", notifiers.KindSlack:
+			var clientOptions []slack.Option
+			if c.URL != "" {
+				log.Logger().Infof("using slack URL %s for connection %s", c.URL, c.Name)
+				clientOptions = append(clientOptions, slack.OptionAPIURL(c.URL))
+			}
+			o.Connections[c.Name] = slack.New(token, clientOptions...)
+		case notifiers.KindMattermost:
+			if isMattermostAPI(c) {
+				log.Logger().Infof("using the mattermost API at %s for connection %s", c.URL, c.Name)
+				o.Connections[c.Name] = mattermost.NewAPIClient(c.URL, token, c.Team)
+			} else {
+				o.Notifiers[c.Name] = mattermost.NewClient(token)
+			}
+		case notifiers.KindTeams:
+			o.Notifiers[c.Name] = teams.NewClient(token)
+		default:
+			return errors.Errorf("unknown kind %s of slack connection %s", c.Kind, c.Name)
+		}
+	}
+
+	if o.Webhooks == nil {
+		o.Webhooks = map[string]notifiers.Notifier{}
+	}
+	for i := range o.Config.Webhooks {
+		w := &o.Config.Webhooks[i]
+		if w.Name == "" || w.URL == "" {
+			return errors.Errorf("webhook %d must have a name and url", i)
+		}
+		if o.Webhooks[w.Name] != nil {
+			continue
+		}
+		var secret []byte
+		if w.Secret != "" {
+			key := w.SecretKey
+			if key == "" {
+				key = defaultSecretKey
+			}
+			s, err := o.KubeClient.CoreV1().Secrets(o.Namespace).Get(ctx, w.Secret, metav1.GetOptions{})
+			if err != nil {
+				return errors.Wrapf(err, "failed to find Secret %s in namespace %s for webhook %s", w.Secret, o.Namespace, w.Name)
+			}
+			secret = s.Data[key]
+			if len(secret) == 0 {
+				return errors.Errorf("no %s key in Secret %s for webhook %s", key, w.Secret, w.Name)
+			}
+		}
+		client := webhook.NewClient(w.URL, secret)
+		if w.MaxRetries != nil {
+			client.MaxRetries = *w.MaxRetries
+		}
+		client.DeadLetterFile = w.DeadLetterFile
+		o.Webhooks[w.Name] = client
+	}
+
+	if o.Emails == nil {
+		o.Emails = map[string]notifiers.Notifier{}
+	}
+	for i := range o.Config.Emails {
+		e := &o.Config.Emails[i]
+		if e.Name == "" || e.Host == "" || e.From == "" {
+			return errors.Errorf("email %d must have a name, host and from", i)
+		}
+		if o.Emails[e.Name] != nil {
+			continue
+		}
+		client := email.NewClient(e.Host, e.Port, e.From)
+		if e.Secret != "" {
+			s, err := o.KubeClient.CoreV1().Secrets(o.Namespace).Get(ctx, e.Secret, metav1.GetOptions{})
+			if err != nil {
+				return errors.Wrapf(err, "failed to find Secret %s in namespace %s for email %s", e.Secret, o.Namespace, e.Name)
+			}
+			client.Username = string(s.Data["username"])
+			client.Password = string(s.Data["password"])
+		}
+		o.Emails[e.Name] = client
+	}
+	return nil
+}
+
+// isMattermostAPI returns true if the connection uses the Mattermost API rather than an incoming webhook so that it
+// supports the same messages as slack
+func isMattermostAPI(c *Connection) bool {
+	return c.Kind == notifiers.KindMattermost && c.URL != ""
+}
+
+// forConnection returns the options to use when sending messages via the named slack connection. The default
+// connection is used if the name is empty
+func (o *Options) forConnection(name string) (*Options, error) {
+	if name == "" {
+		return o, nil
+	}
+	client := o.Connections[name]
+	if client == nil {
+		return nil, errors.Errorf("unknown slack connection %s", name)
+	}
+	if o.Timestamps == nil {
+		// lets share the message references between connections
+		o.Timestamps = map[string]map[string]*MessageReference{}
+	}
+	if o.changelogs == nil {
+		o.changelogs = newLRUCache(maxChangelogCacheSize)
+	}
+	if o.testReports == nil {
+		o.testReports = newLRUCache(maxTestReportCacheSize)
+	}
+	if o.homePullRequests == nil {
+		o.homePullRequests = newLRUCache(maxHomePullRequestsCacheSize)
+	}
+	answer := *o
+	answer.SlackClient = client
+	answer.SlackUserResolver = o.SlackUserResolver
+	answer.SlackUserResolver.SlackClient = client
+	answer.SlackUserResolver.Connection = name
+	return &answer, nil
+}
+
+// forTeam returns the options of the slack connection of the workspace with the given team ID so that its events
+// are handled with the same connection. The default connection is used if no connection has the team ID
+func (o *Options) forTeam(teamID string) *Options {
+	if teamID == "" || o.Config == nil {
+		return o
+	}
+	for i := range o.Config.Connections {
+		c := &o.Config.Connections[i]
+		if c.TeamID != teamID {
+			continue
+		}
+		bot, err := o.forConnection(c.Name)
+		if err != nil {
+			log.Logger().Warnf("using the default slack connection for team %s: %s", teamID, err.Error())
+			return o
+		}
+		return bot
+	}
+	return o
+}
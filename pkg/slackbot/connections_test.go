@@ -0,0 +1,297 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/mattermost"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/mattermost/fakemattermost"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/teams/faketeams"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSplitChannel(t *testing.T) {
+	testCases := []struct {
+		channel            string
+		expectedConnection string
+		expectedChannel    string
+	}{
+		{channel: "#jenkins-x-pipelines", expectedConnection: "", expectedChannel: "#jenkins-x-pipelines"},
+		{channel: "builds", expectedConnection: "", expectedChannel: "builds"},
+		{channel: "acme:#builds", expectedConnection: "acme", expectedChannel: "#builds"},
+	}
+	for _, tc := range testCases {
+		connection, channel := SplitChannel(tc.channel)
+		assert.Equal(t, tc.expectedConnection, connection, "connection for %s", tc.channel)
+		assert.Equal(t, tc.expectedChannel, channel, "channel for %s", tc.channel)
+	}
+}
+
+func TestPipelineMessageViaConnection(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+
+	scmClient, _ := fakescm.NewDefault()
+	defaultSlack := fakeslack.NewFakeSlack()
+	acmeSlack := fakeslack.NewFakeSlack()
+
+	sourceConfig := &v1alpha1.SourceConfig{
+		Spec: v1alpha1.SourceConfigSpec{
+			Groups: []v1alpha1.RepositoryGroup{
+				{
+					Provider: "https://fake.git",
+					Owner:    owner,
+					Repositories: []v1alpha1.Repository{
+						{
+							Name: repo,
+							Slack: &v1alpha1.SlackNotify{
+								Channel:  "acme:#builds",
+								Kind:     v1alpha1.NotifyKindAlways,
+								Pipeline: v1alpha1.PipelineKindAll,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	pa := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", "1", jenkinsv1.ActivityStatusTypeFailed)
+
+	o := &Options{
+		KubeClient:    fake.NewSimpleClientset(),
+		JXClient:      fakejx.NewSimpleClientset(pa),
+		ScmClient:     scmClient,
+		SlackClient:   defaultSlack,
+		Connections:   map[string]slacker.Interface{"acme": acmeSlack},
+		SourceConfigs: sourceConfig,
+	}
+	o.Namespace = ns
+
+	err := o.PipelineMessage(pa)
+	require.NoError(t, err, "failed to process pipeline %s", pa.Name)
+
+	assert.Len(t, acmeSlack.Messages["#builds"], 1, "messages sent via the acme connection")
+	assert.Empty(t, defaultSlack.Messages, "messages sent via the default connection")
+
+	// the message is stored against the channel of the connection so that a #builds channel in the default
+	// workspace has its own message
+	updated, err := o.JXClient.JenkinsV1().PipelineActivities(ns).Get(context.TODO(), pa.Name, metav1.GetOptions{})
+	require.NoError(t, err, "failed to find activity %s", pa.Name)
+	assert.NotEmpty(t, updated.Annotations[connectionAnnotationKey("acme", "#builds", pipelineMessageType)], "acme annotation")
+	assert.Empty(t, updated.Annotations[annotationKey("#builds", pipelineMessageType)], "default annotation")
+	assert.NotNil(t, o.Timestamps["acme:#builds"][pa.Name], "acme message in memory")
+	assert.Nil(t, o.Timestamps["#builds"][pa.Name], "default message in memory")
+
+	o.Connections = nil
+	err = o.PipelineMessage(pa)
+	require.Error(t, err, "should fail for an unknown connection")
+}
+
+func TestConnectionAnnotationKey(t *testing.T) {
+	assert.Equal(t, "message.slack.jenkins-x.io-pipeline/builds", annotationKey("#builds", pipelineMessageType))
+	assert.Equal(t, "message.slack.jenkins-x.io-pipeline/builds", connectionAnnotationKey("", "#builds", pipelineMessageType))
+	assert.Equal(t, "message.slack.jenkins-x.io-pipeline/acme.builds", connectionAnnotationKey("acme", "#builds", pipelineMessageType))
+
+	o := &Options{}
+	o.SlackUserResolver.Connection = "acme"
+	assert.Equal(t, "message.slack.jenkins-x.io-pr/acme.builds", o.annotationKey("#builds", pullRequestReviewMessageType))
+}
+
+func TestPipelineMessageViaNotifiers(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+
+	scmClient, _ := fakescm.NewDefault()
+	slackClient := fakeslack.NewFakeSlack()
+	fakeMattermost := fakemattermost.NewFakeMattermost()
+	fakeTeams := faketeams.NewFakeTeams()
+
+	repository := func(name, channel string) v1alpha1.Repository {
+		return v1alpha1.Repository{
+			Name: name,
+			Slack: &v1alpha1.SlackNotify{
+				Channel:  channel,
+				Kind:     v1alpha1.NotifyKindAlways,
+				Pipeline: v1alpha1.PipelineKindAll,
+			},
+		}
+	}
+	sourceConfig := &v1alpha1.SourceConfig{
+		Spec: v1alpha1.SourceConfigSpec{
+			Groups: []v1alpha1.RepositoryGroup{
+				{
+					Provider: "https://fake.git",
+					Owner:    owner,
+					Repositories: []v1alpha1.Repository{
+						repository("mmrepo", "mm:#builds"),
+						repository("teamsrepo", "teams:"),
+					},
+				},
+			},
+		},
+	}
+	mmActivity := testpipelines.CreateTestPipelineActivity(ns, owner, "mmrepo", "main", "release", "1", jenkinsv1.ActivityStatusTypeFailed)
+	teamsActivity := testpipelines.CreateTestPipelineActivity(ns, owner, "teamsrepo", "main", "release", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+	runningActivity := testpipelines.CreateTestPipelineActivity(ns, owner, "teamsrepo", "main", "release", "2", jenkinsv1.ActivityStatusTypeRunning)
+	jxClient := fakejx.NewSimpleClientset(mmActivity, teamsActivity, runningActivity)
+
+	o := &Options{
+		KubeClient:    fake.NewSimpleClientset(),
+		JXClient:      jxClient,
+		ScmClient:     scmClient,
+		SlackClient:   slackClient,
+		Notifiers:     map[string]notifiers.Notifier{"mm": fakeMattermost, "teams": fakeTeams},
+		SourceConfigs: sourceConfig,
+	}
+	o.Namespace = ns
+
+	for _, pa := range []*jenkinsv1.PipelineActivity{mmActivity, teamsActivity, runningActivity} {
+		err := o.PipelineMessage(pa)
+		require.NoError(t, err, "failed to process pipeline %s", pa.Name)
+	}
+
+	messages := fakeMattermost.AssertMessageCount(t, "#builds", 1, "mattermost")
+	require.Len(t, messages[0].Attachments, 1, "mattermost attachments")
+	assert.Equal(t, "#d00000", messages[0].Attachments[0].Color, "mattermost attachment color")
+	assert.Contains(t, messages[0].Attachments[0].Title, "myorg/mmrepo", "mattermost attachment title")
+
+	teamsMessages := fakeTeams.AssertMessageCount(t, 1, "teams")
+	require.Len(t, teamsMessages[0].Attachments, 1, "teams attachments")
+	card := teamsMessages[0].Attachments[0].Content
+	require.NotNil(t, card, "teams adaptive card")
+	assert.Equal(t, "Good", card.Body[0].Color, "teams title color")
+	assert.Contains(t, card.Body[0].Text, "myorg/teamsrepo", "teams title")
+	assert.Empty(t, slackClient.Messages, "slack messages")
+
+	// lets check we don't notify again once the activity is annotated
+	updated, err := jxClient.JenkinsV1().PipelineActivities(ns).Get(context.TODO(), mmActivity.Name, metav1.GetOptions{})
+	require.NoError(t, err, "failed to find activity %s", mmActivity.Name)
+	err = o.PipelineMessage(updated)
+	require.NoError(t, err, "failed to process pipeline %s", updated.Name)
+	fakeMattermost.AssertMessageCount(t, "#builds", 1, "mattermost after annotation")
+}
+
+func TestPipelineMessageViaMattermostAPI(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+
+	scmClient, _ := fakescm.NewDefault()
+	slackClient := fakeslack.NewFakeSlack()
+	server := fakemattermost.NewFakeServer("engineering")
+	defer server.Close()
+	builds := server.AddChannel("builds")
+
+	sourceConfig := &v1alpha1.SourceConfig{
+		Spec: v1alpha1.SourceConfigSpec{
+			Groups: []v1alpha1.RepositoryGroup{
+				{
+					Provider: "https://fake.git",
+					Owner:    owner,
+					Repositories: []v1alpha1.Repository{
+						{
+							Name: repo,
+							Slack: &v1alpha1.SlackNotify{
+								Channel:  "mm:#builds",
+								Kind:     v1alpha1.NotifyKindAlways,
+								Pipeline: v1alpha1.PipelineKindAll,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	pa := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", "1", jenkinsv1.ActivityStatusTypeRunning)
+	jxClient := fakejx.NewSimpleClientset(pa)
+
+	o := &Options{
+		KubeClient:    fake.NewSimpleClientset(),
+		JXClient:      jxClient,
+		ScmClient:     scmClient,
+		SlackClient:   slackClient,
+		Connections:   map[string]slacker.Interface{"mm": mattermost.NewAPIClient(server.URL(), "token", "engineering")},
+		SourceConfigs: sourceConfig,
+	}
+	o.Namespace = ns
+
+	err := o.PipelineMessage(pa)
+	require.NoError(t, err, "failed to process pipeline %s", pa.Name)
+	posts := server.AssertPostCount(t, builds.ID, 1, "running pipeline")
+	postID := posts[0].ID
+
+	updated, err := jxClient.JenkinsV1().PipelineActivities(ns).Get(context.TODO(), pa.Name, metav1.GetOptions{})
+	require.NoError(t, err, "failed to find activity %s", pa.Name)
+	updated.Spec.Status = jenkinsv1.ActivityStatusTypeSucceeded
+	err = o.PipelineMessage(updated)
+	require.NoError(t, err, "failed to process pipeline %s", updated.Name)
+
+	posts = server.AssertPostCount(t, builds.ID, 1, "succeeded pipeline")
+	assert.Equal(t, postID, posts[0].ID, "the post of the running pipeline is updated")
+	attachments, ok := posts[0].Props["attachments"].([]interface{})
+	require.True(t, ok && len(attachments) > 0, "mattermost attachments")
+	assert.Equal(t, "good", attachments[0].(map[string]interface{})["color"], "succeeded color")
+	assert.Empty(t, slackClient.Messages, "slack messages")
+}
+
+func TestCreateConnections(t *testing.T) {
+	ns := "jx"
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "acme-slack",
+			Namespace: ns,
+		},
+		Data: map[string][]byte{
+			"token": []byte("xoxb-acme"),
+		},
+	}
+	o := &Options{
+		KubeClient: fake.NewSimpleClientset(secret),
+		Config: &Config{
+			Connections: []Connection{
+				{
+					Name:        "acme",
+					URL:         "https://acme.slack.example.com/api/",
+					TokenSecret: "acme-slack",
+				},
+				{
+					Name:        "mm",
+					Kind:        notifiers.KindMattermost,
+					TokenSecret: "acme-slack",
+					TokenKey:    "token",
+				},
+				{
+					Name:        "mmapi",
+					Kind:        notifiers.KindMattermost,
+					URL:         "https://mattermost.example.com",
+					Team:        "engineering",
+					TokenSecret: "acme-slack",
+				},
+			},
+		},
+	}
+	o.Namespace = ns
+
+	err := o.createConnections(context.TODO())
+	require.NoError(t, err, "failed to create connections")
+	assert.NotNil(t, o.Connections["acme"], "acme connection")
+	assert.NotNil(t, o.Notifiers["mm"], "mattermost notifier")
+	assert.NotNil(t, o.Connections["mmapi"], "mattermost API connection")
+
+	o.Config.Connections = append(o.Config.Connections, Connection{Name: "missing", TokenSecret: "does-not-exist"})
+	err = o.createConnections(context.TODO())
+	require.Error(t, err, "should fail if the token secret does not exist")
+}
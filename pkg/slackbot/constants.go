@@ -5,8 +5,24 @@ const (
 	// messsage in a particular channel
 	SlackAnnotationPrefix = "message.slack.jenkins-x.io"
 
+	// StuckAnnotation annotation used on a PipelineActivity to record that the watchdog has warned it has been
+	// running or pending for too long
+	StuckAnnotation = "watchdog.slack.jenkins-x.io/stuck"
+
+	// TestReportAnnotation the default annotation used on a PipelineActivity to point at the URL of its JUnit report
+	TestReportAnnotation = "tests.slack.jenkins-x.io/report"
+
+	// TestThreadAnnotation annotation used on a PipelineActivity to record that its failing tests have been posted
+	// as a reply to the pipeline message
+	TestThreadAnnotation = "tests.slack.jenkins-x.io/thread"
+
 	pullRequestReviewMessageType = "pr"
 	pipelineMessageType          = "pipeline"
+	notificationMessageType      = "notification"
+	webhookMessageType           = "webhook"
+	emailMessageType             = "email"
+	releaseMessageType           = "release"
+	checklistMessageType         = "checklist"
 )
 
 var knownPipelineStageTypes = []string{"setup", "setVersion", "preBuild", "build", "postBuild", "promote", "pipeline"}
@@ -0,0 +1,77 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/dryrun"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDryRun(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+
+	sourceConfig := &v1alpha1.SourceConfig{
+		Spec: v1alpha1.SourceConfigSpec{
+			Groups: []v1alpha1.RepositoryGroup{
+				{
+					Provider: "https://fake.git",
+					Owner:    owner,
+					Repositories: []v1alpha1.Repository{
+						{
+							Name: repo,
+							Slack: &v1alpha1.SlackNotify{
+								Channel:  "#builds",
+								Kind:     v1alpha1.NotifyKindAlways,
+								Pipeline: v1alpha1.PipelineKindAll,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	pa := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", "1", jenkinsv1.ActivityStatusTypeRunning)
+	jxClient := fakejx.NewSimpleClientset(pa)
+	scmClient, _ := fakescm.NewDefault()
+
+	o := &Options{
+		KubeClient:    fake.NewSimpleClientset(),
+		JXClient:      jxClient,
+		ScmClient:     scmClient,
+		SourceConfigs: sourceConfig,
+		DryRun:        true,
+	}
+	o.Namespace = ns
+	o.enableDryRun()
+	client, ok := o.SlackClient.(*dryrun.Client)
+	require.True(t, ok, "SlackClient should be a dry run client but was %#v", o.SlackClient)
+
+	err := o.PipelineMessage(pa)
+	require.NoError(t, err, "failed to process pipeline %s", pa.Name)
+
+	// the second message should update the first as we remember the message in memory
+	pa.Spec.Status = jenkinsv1.ActivityStatusTypeSucceeded
+	err = o.PipelineMessage(pa)
+	require.NoError(t, err, "failed to process pipeline %s", pa.Name)
+
+	require.Len(t, client.Messages, 2, "dry run messages")
+	assert.Equal(t, "#builds", client.Messages[0].Channel, "channel")
+	assert.Equal(t, dryrun.MethodPostMessage, client.Messages[0].Method, "first message method")
+	assert.Equal(t, dryrun.MethodUpdate, client.Messages[1].Method, "second message method")
+	assert.Contains(t, client.Messages[0].Payload, "pipelineactivity:"+pa.Name, "payload")
+
+	updated, err := jxClient.JenkinsV1().PipelineActivities(ns).Get(context.TODO(), pa.Name, metav1.GetOptions{})
+	require.NoError(t, err, "failed to find activity %s", pa.Name)
+	assert.Empty(t, updated.Annotations, "annotations should not be added in dry run mode")
+}
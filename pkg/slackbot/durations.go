@@ -0,0 +1,153 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultDurationBuilds     = 10
+	defaultDurationRegression = 50
+
+	// minDurationBuilds the number of previous builds required before comparing with the median
+	minDurationBuilds = 3
+)
+
+// DurationComparison the duration of a pipeline compared with the median of its previous successful builds
+type DurationComparison struct {
+	Duration time.Duration
+	Median   time.Duration
+	Builds   int
+
+	// Regression true if the pipeline took longer than the configured percentage over the median
+	Regression bool
+}
+
+// Percentage returns how much longer, or shorter if negative, the pipeline took than the median
+func (c *DurationComparison) Percentage() int {
+	if c.Median <= 0 {
+		return 0
+	}
+	return int((c.Duration - c.Median) * 100 / c.Median)
+}
+
+// Describe describes the comparison such as '50% slower than the median 4m of the last 10 builds'
+func (c *DurationComparison) Describe() string {
+	percentage := c.Percentage()
+	comparison := "slower"
+	if percentage < 0 {
+		percentage = -percentage
+		comparison = "faster"
+	}
+	return fmt.Sprintf("%d%% %s than the median %s of the last %d builds", percentage, comparison, formatDuration(c.Median), c.Builds)
+}
+
+// activityDuration returns the duration of the activity if it has completed
+func activityDuration(activity *jenkinsv1.PipelineActivity) (time.Duration, bool) {
+	return elapsed(activity.Spec.StartedTimestamp, activity.Spec.CompletedTimestamp)
+}
+
+// stepDuration returns the duration of the step if it has completed
+func stepDuration(step *jenkinsv1.CoreActivityStep) (time.Duration, bool) {
+	return elapsed(step.StartedTimestamp, step.CompletedTimestamp)
+}
+
+func elapsed(started, completed *metav1.Time) (time.Duration, bool) {
+	if started == nil || completed == nil || completed.Before(started) {
+		return 0, false
+	}
+	return completed.Sub(started.Time), true
+}
+
+// compareDuration compares the duration of the completed activity with the median duration of the previous
+// successful builds of the same pipeline returning nil if the activity has not completed or there are too few
+// previous builds
+func (o *Options) compareDuration(ctx context.Context, activity *jenkinsv1.PipelineActivity) *DurationComparison {
+	duration, ok := activityDuration(activity)
+	if !ok {
+		return nil
+	}
+	buildNumber, err := strconv.Atoi(activity.Spec.Build)
+	if err != nil {
+		return nil
+	}
+	builds, regression := defaultDurationBuilds, defaultDurationRegression
+	if o.Config != nil && o.Config.Durations != nil {
+		if o.Config.Durations.Builds > 0 {
+			builds = o.Config.Durations.Builds
+		}
+		if o.Config.Durations.Regression > 0 {
+			regression = o.Config.Durations.Regression
+		}
+	}
+
+	history, err := o.pipelineHistory(ctx, activity, buildNumber)
+	if err != nil {
+		log.Logger().Warnf("failed to find the previous builds of %s: %s", activity.Name, err.Error())
+		return nil
+	}
+	var durations []time.Duration
+	for i := range history {
+		if len(durations) >= builds {
+			break
+		}
+		previous := &history[i]
+		if previous.Spec.Status != jenkinsv1.ActivityStatusTypeSucceeded {
+			continue
+		}
+		if d, ok := activityDuration(previous); ok {
+			durations = append(durations, d)
+		}
+	}
+	if len(durations) < minDurationBuilds {
+		return nil
+	}
+	c := &DurationComparison{
+		Duration: duration,
+		Median:   medianDuration(durations),
+		Builds:   len(durations),
+	}
+	c.Regression = c.Percentage() > regression
+	return c
+}
+
+// medianDuration returns the median of the durations
+func medianDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// formatDuration formats the duration to the nearest second such as 45s, 3m20s or 1h5m
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d >= time.Hour {
+		return describeDuration(d)
+	}
+	minutes := int(d.Minutes())
+	seconds := int(d.Seconds()) % 60
+	switch {
+	case minutes > 0 && seconds > 0:
+		return fmt.Sprintf("%dm%ds", minutes, seconds)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
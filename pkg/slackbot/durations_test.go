@@ -0,0 +1,109 @@
+package slackbot
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestCompareDuration(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+
+	createActivity := func(build int, status jenkinsv1.ActivityStatusType, duration time.Duration) *jenkinsv1.PipelineActivity {
+		pa := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "main", "release", strconv.Itoa(build), status)
+		started := metav1.NewTime(now.Add(-duration))
+		completed := metav1.NewTime(now)
+		pa.Spec.StartedTimestamp = &started
+		pa.Spec.CompletedTimestamp = &completed
+		return pa
+	}
+
+	var jxObjects []runtime.Object
+	for i, d := range []time.Duration{4 * time.Minute, 5 * time.Minute, 6 * time.Minute} {
+		jxObjects = append(jxObjects, createActivity(i+1, jenkinsv1.ActivityStatusTypeSucceeded, d))
+	}
+	// failed builds are ignored
+	jxObjects = append(jxObjects, createActivity(4, jenkinsv1.ActivityStatusTypeFailed, 30*time.Second))
+
+	slow := createActivity(5, jenkinsv1.ActivityStatusTypeSucceeded, 10*time.Minute)
+	fast := createActivity(6, jenkinsv1.ActivityStatusTypeSucceeded, 6*time.Minute)
+	jxObjects = append(jxObjects, slow, fast)
+
+	o := &Options{
+		JXClient: fakejx.NewSimpleClientset(jxObjects...),
+	}
+	o.Namespace = ns
+
+	c := o.compareDuration(context.TODO(), slow)
+	require.NotNil(t, c, "comparison of build 5")
+	assert.Equal(t, 5*time.Minute, c.Median, "median")
+	assert.Equal(t, 3, c.Builds, "builds")
+	assert.Equal(t, 100, c.Percentage(), "percentage")
+	assert.True(t, c.Regression, "regression")
+	assert.Equal(t, "100% slower than the median 5m of the last 3 builds", c.Describe())
+
+	options, _, err := o.createPipelineMessage(slow, nil, "")
+	require.NoError(t, err, "failed to create message")
+	_, values, err := slack.UnsafeApplyMsgOptions("fakeToken", "#builds", "fakeapiurl", options...)
+	require.NoError(t, err, "failed to render message")
+	attachments := values.Get("attachments")
+	assert.Contains(t, attachments, "in 10m (median 5m)", "attachments")
+	assert.Contains(t, attachments, ":snail: this pipeline was 100% slower", "attachments")
+
+	o.Config = &Config{
+		Durations: &Durations{
+			Builds:     2,
+			Regression: 25,
+		},
+	}
+	c = o.compareDuration(context.TODO(), fast)
+	assert.Nil(t, c, "too few builds to compare with")
+
+	o.Config.Durations.Builds = 3
+	c = o.compareDuration(context.TODO(), fast)
+	require.NotNil(t, c, "comparison of build 6")
+	assert.Equal(t, 6*time.Minute, c.Median, "median of builds 2, 3 and 5")
+	assert.False(t, c.Regression, "regression")
+}
+
+func TestStepDuration(t *testing.T) {
+	started := metav1.Now()
+	completed := metav1.NewTime(started.Add(95 * time.Second))
+	step := jenkinsv1.CoreActivityStep{
+		Name:               "build make",
+		Status:             jenkinsv1.ActivityStatusTypeSucceeded,
+		StartedTimestamp:   &started,
+		CompletedTimestamp: &completed,
+	}
+	o := &Options{}
+	attachment := o.createStepAttachment(step, "", "", "")
+	assert.Contains(t, attachment.Text, "(1m35s)", "step text")
+
+	step.CompletedTimestamp = nil
+	attachment = o.createStepAttachment(step, "", "", "")
+	assert.NotContains(t, attachment.Text, "(", "running step text")
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "45s", formatDuration(45*time.Second))
+	assert.Equal(t, "3m", formatDuration(3*time.Minute))
+	assert.Equal(t, "3m20s", formatDuration(3*time.Minute+20*time.Second))
+	assert.Equal(t, "1h5m", formatDuration(time.Hour+5*time.Minute+20*time.Second))
+}
+
+func TestMedianDuration(t *testing.T) {
+	assert.Equal(t, time.Duration(0), medianDuration(nil))
+	assert.Equal(t, 5*time.Second, medianDuration([]time.Duration{9 * time.Second, time.Second, 5 * time.Second}))
+	assert.Equal(t, 4*time.Second, medianDuration([]time.Duration{2 * time.Second, 6 * time.Second, 3 * time.Second, 5 * time.Second}))
+}
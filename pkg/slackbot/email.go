@@ -0,0 +1,103 @@
+package slackbot
+
+import (
+	"context"
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultEmailNotify by default we only email about failed release pipelines
+var defaultEmailNotify = v1alpha1.SlackNotify{
+	Kind:     v1alpha1.NotifyKindFailure,
+	Pipeline: v1alpha1.PipelineKindRelease,
+}
+
+// notifyEmails sends the emails which match the pipeline once it has terminated. Failures are logged rather than
+// returned so that they do not stop the slack notifications
+func (o *Options) notifyEmails(ctx context.Context, activity *jenkinsv1.PipelineActivity) {
+	if o.Config == nil || len(o.Emails) == 0 {
+		return
+	}
+	status := pipelineStatus(activity)
+	if !status.IsTerminated() {
+		return
+	}
+	for i := range o.Config.Emails {
+		e := &o.Config.Emails[i]
+		client := o.Emails[e.Name]
+		if client == nil {
+			continue
+		}
+		if len(e.Repositories) > 0 && !matchesRepository(e.Repositories, activity) {
+			continue
+		}
+		key := annotationKey(e.Name, emailMessageType)
+		if o.alreadyNotified(activity, key, string(status)) {
+			continue
+		}
+		cfg := e.Notify
+		if cfg == nil {
+			cfg = &defaultEmailNotify
+		}
+		enabled, pullRequest, _, err := o.NotifyPipeline(activity, cfg)
+		if err != nil {
+			log.Logger().Warnf("failed to verify if email %s should be sent for %s: %s", e.Name, activity.Name, err.Error())
+			continue
+		}
+		if !enabled {
+			continue
+		}
+		recipients, err := o.emailRecipients(ctx, e)
+		if err != nil {
+			log.Logger().Warnf("failed to find the recipients of email %s: %s", e.Name, err.Error())
+			continue
+		}
+		if len(recipients) == 0 {
+			log.Logger().Warnf("no recipients for email %s", e.Name)
+			continue
+		}
+		notification, err := o.createNotification(activity, pullRequest)
+		if err != nil {
+			log.Logger().Warnf("failed to create notification for %s: %s", activity.Name, err.Error())
+			return
+		}
+		err = client.Notify(ctx, strings.Join(recipients, ","), notification)
+		if err != nil {
+			log.Logger().Warnf("failed to send email %s for %s: %s", e.Name, activity.Name, err.Error())
+			continue
+		}
+		log.Logger().Infof("Email %s sent for %s to %s\n", e.Name, activity.Name, strings.Join(recipients, ", "))
+		err = o.annotatePipelineActivity(ctx, activity, key, string(status))
+		if err != nil {
+			log.Logger().Warnf("failed to annotate %s: %s", activity.Name, err.Error())
+		}
+	}
+}
+
+// emailRecipients returns the email addresses of the users and the additional addresses of the email
+func (o *Options) emailRecipients(ctx context.Context, e *Email) ([]string, error) {
+	recipients := append([]string{}, e.Addresses...)
+	if o.JXClient == nil {
+		log.Logger().Debugf("not looking up the Users of email %s as there is no cluster", e.Name)
+		return recipients, nil
+	}
+	for _, name := range e.Users {
+		user, err := o.JXClient.JenkinsV1().Users(o.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find User %s in namespace %s", name, o.Namespace)
+		}
+		if user.Spec.Email == "" {
+			log.Logger().Warnf("User %s has no email address", name)
+			continue
+		}
+		if !stringsContain(recipients, user.Spec.Email) {
+			recipients = append(recipients, user.Spec.Email)
+		}
+	}
+	return recipients, nil
+}
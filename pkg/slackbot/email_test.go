@@ -0,0 +1,86 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/email/fakesmtp"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNotifyEmails(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+
+	server, err := fakesmtp.NewFakeServer()
+	require.NoError(t, err, "failed to start fake SMTP server")
+	defer server.Close()
+
+	user := &jenkinsv1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jane",
+			Namespace: ns,
+		},
+		Spec: jenkinsv1.UserDetails{
+			Name:  "Jane",
+			Email: "jane@example.com",
+		},
+	}
+	failedRelease := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", "1", jenkinsv1.ActivityStatusTypeFailed)
+	succeededRelease := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", "2", jenkinsv1.ActivityStatusTypeSucceeded)
+	failedPullRequest := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "PR-1", "pr", "1", jenkinsv1.ActivityStatusTypeFailed)
+	otherRepo := testpipelines.CreateTestPipelineActivity(ns, "otherorg", "another", "main", "release", "1", jenkinsv1.ActivityStatusTypeFailed)
+
+	scmClient, _ := fakescm.NewDefault()
+	o := &Options{
+		KubeClient: fake.NewSimpleClientset(),
+		JXClient:   fakejx.NewSimpleClientset(user, failedRelease, succeededRelease, failedPullRequest, otherRepo),
+		ScmClient:  scmClient,
+		Config: &Config{
+			Emails: []Email{
+				{
+					Name:         "managers",
+					Host:         server.Host(),
+					Port:         server.Port(),
+					From:         "jenkins-x@example.com",
+					Users:        []string{"jane"},
+					Addresses:    []string{"team@example.com"},
+					Repositories: []string{"myorg/*"},
+				},
+			},
+		},
+	}
+	o.Namespace = ns
+
+	err = o.createConnections(context.TODO())
+	require.NoError(t, err, "failed to create connections")
+
+	for _, pa := range []*jenkinsv1.PipelineActivity{failedRelease, succeededRelease, failedPullRequest, otherRepo} {
+		err = o.PipelineMessage(pa)
+		require.NoError(t, err, "failed to process pipeline %s", pa.Name)
+	}
+
+	messages := server.AssertMessageCount(t, 1, "failed release")
+	msg := messages[0]
+	assert.Equal(t, "jenkins-x@example.com", msg.From, "from")
+	assert.Equal(t, []string{"team@example.com", "jane@example.com"}, msg.To, "to")
+	assert.Contains(t, msg.Data, "Subject: [failed] Pipeline myorg/myrepo (release #1)", "subject")
+	assert.Contains(t, msg.Data, "Content-Type: text/plain", "plain text part")
+	assert.Contains(t, msg.Data, "Content-Type: text/html", "HTML part")
+	t.Logf("got email:\n%s\n", msg.Data)
+
+	// lets check we don't email again once the activity is annotated
+	updated, err := o.JXClient.JenkinsV1().PipelineActivities(ns).Get(context.TODO(), failedRelease.Name, metav1.GetOptions{})
+	require.NoError(t, err, "failed to find activity %s", failedRelease.Name)
+	err = o.PipelineMessage(updated)
+	require.NoError(t, err, "failed to process pipeline %s", updated.Name)
+	server.AssertMessageCount(t, 1, "after the activity is annotated")
+}
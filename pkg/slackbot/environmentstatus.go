@@ -0,0 +1,362 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// EnvironmentStatusConfigMapName the name of the ConfigMap used to store the environment status of each channel
+	EnvironmentStatusConfigMapName = "jx-slack-environment-status"
+
+	environmentStatusTitle      = "Environment status"
+	environmentStatusTimeFormat = "2006-01-02 15:04 UTC"
+
+	// maxSectionTextLength the maximum length of the text of a slack section block
+	maxSectionTextLength = 3000
+
+	// maxEnvironmentStatusLinks the maximum number of application links shown below the table
+	maxEnvironmentStatusLinks = 30
+)
+
+// EnvironmentStatus the current version of each application in each environment along with the pinned message
+// which shows it
+type EnvironmentStatus struct {
+	// ChannelID the ID of the channel of the message
+	ChannelID string `json:"channelId,omitempty"`
+
+	// Timestamp the timestamp of the message which is edited in place
+	Timestamp string `json:"timestamp,omitempty"`
+
+	// Applications the deployments of each application, indexed by the owner/name of its repository, indexed by
+	// environment name
+	Applications map[string]map[string]*Deployment `json:"applications,omitempty"`
+}
+
+// Deployment a version of an application promoted to an environment
+type Deployment struct {
+	Version  string    `json:"version"`
+	URL      string    `json:"url,omitempty"`
+	Promoted time.Time `json:"promoted"`
+	Activity string    `json:"activity,omitempty"`
+}
+
+// EnvironmentStatusStore loads and saves the EnvironmentStatus of each channel in a ConfigMap
+type EnvironmentStatusStore struct {
+	KubeClient kubernetes.Interface
+	Namespace  string
+
+	// lock serialises the updates of the status messages from the PipelineActivity watchers
+	lock sync.Mutex
+}
+
+// NewEnvironmentStatusStore creates a new store of environment statuses in the given namespace
+func NewEnvironmentStatusStore(kubeClient kubernetes.Interface, namespace string) *EnvironmentStatusStore {
+	return &EnvironmentStatusStore{
+		KubeClient: kubeClient,
+		Namespace:  namespace,
+	}
+}
+
+// Get returns the environment status of the given channel or an empty status if there is none
+func (s *EnvironmentStatusStore) Get(ctx context.Context, channel string) (*EnvironmentStatus, error) {
+	status := &EnvironmentStatus{}
+	_, err := loadConfigMapValue(ctx, s.KubeClient, s.Namespace, EnvironmentStatusConfigMapName, configMapKey(channel), status)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load environment status of channel %s", channel)
+	}
+	return status, nil
+}
+
+// Save stores the environment status of the given channel, lazily creating the ConfigMap if required
+func (s *EnvironmentStatusStore) Save(ctx context.Context, channel string, status *EnvironmentStatus) error {
+	return saveConfigMapValue(ctx, s.KubeClient, s.Namespace, EnvironmentStatusConfigMapName, configMapKey(channel), status)
+}
+
+// Update records the deployment of the application in the environment returning true if it is newer than the
+// current deployment
+func (s *EnvironmentStatus) Update(app, env string, deployment *Deployment) bool {
+	if s.Applications == nil {
+		s.Applications = map[string]map[string]*Deployment{}
+	}
+	envs := s.Applications[app]
+	if envs == nil {
+		envs = map[string]*Deployment{}
+		s.Applications[app] = envs
+	}
+	current := envs[env]
+	if current != nil && (current.Version == deployment.Version || current.Promoted.After(deployment.Promoted)) {
+		return false
+	}
+	envs[env] = deployment
+	return true
+}
+
+// Rename moves the deployments stored against the old application name, such as the repository name used before
+// applications were indexed by owner too, to the new name returning true if anything moved
+func (s *EnvironmentStatus) Rename(oldApp, newApp string) bool {
+	envs := s.Applications[oldApp]
+	if oldApp == newApp || envs == nil || s.Applications[newApp] != nil {
+		return false
+	}
+	s.Applications[newApp] = envs
+	delete(s.Applications, oldApp)
+	return true
+}
+
+// completedPromotions returns the succeeded promotions of the activity indexed by environment name
+func completedPromotions(activity *jenkinsv1.PipelineActivity) map[string]*Deployment {
+	version := activity.Spec.Version
+	if version == "" {
+		return nil
+	}
+	answer := map[string]*Deployment{}
+	for _, step := range activity.Spec.Steps {
+		promote := step.Promote
+		if promote == nil || promote.Environment == "" || promote.Status != jenkinsv1.ActivityStatusTypeSucceeded {
+			continue
+		}
+		promoted := time.Now()
+		if promote.CompletedTimestamp != nil {
+			promoted = promote.CompletedTimestamp.Time
+		} else if activity.Spec.CompletedTimestamp != nil {
+			promoted = activity.Spec.CompletedTimestamp.Time
+		}
+		answer[promote.Environment] = &Deployment{
+			Version:  version,
+			URL:      promote.ApplicationURL,
+			Promoted: promoted.UTC(),
+			Activity: activity.Name,
+		}
+	}
+	return answer
+}
+
+// updateEnvironmentStatus updates the environment status message of each configured channel with the completed
+// promotions of the activity. Failures are logged rather than returned so that they do not stop the pipeline messages
+func (o *Options) updateEnvironmentStatus(ctx context.Context, activity *jenkinsv1.PipelineActivity) {
+	if o.Config == nil || len(o.Config.EnvironmentStatus) == 0 || o.EnvironmentStatus == nil {
+		return
+	}
+	promotions := completedPromotions(activity)
+	if len(promotions) == 0 {
+		return
+	}
+	repo := activity.Spec.GitRepository
+	if repo == "" {
+		return
+	}
+	// lets use the owner too so that repositories with the same name in different organisations do not collide
+	app := scm.Join(activity.Spec.GitOwner, repo)
+
+	store := o.EnvironmentStatus
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	for i := range o.Config.EnvironmentStatus {
+		cfg := &o.Config.EnvironmentStatus[i]
+		if cfg.Channel == "" {
+			continue
+		}
+		if len(cfg.Repositories) > 0 && !matchesRepository(cfg.Repositories, activity) {
+			continue
+		}
+		err := o.updateEnvironmentStatusChannel(ctx, cfg, app, repo, promotions)
+		if err != nil {
+			log.Logger().Warnf("failed to update the environment status in channel %s for %s: %s", cfg.Channel, activity.Name, err.Error())
+		}
+	}
+}
+
+// updateEnvironmentStatusChannel records the promotions and edits the status message of the channel, posting and
+// pinning a new message if there is not one yet
+func (o *Options) updateEnvironmentStatusChannel(ctx context.Context, cfg *EnvironmentStatusChannel, app, legacyApp string, promotions map[string]*Deployment) error {
+	store := o.EnvironmentStatus
+	status, err := store.Get(ctx, cfg.Channel)
+	if err != nil {
+		return err
+	}
+	changed := status.Rename(legacyApp, app)
+	for env, deployment := range promotions {
+		if len(cfg.Environments) > 0 && !stringsContain(cfg.Environments, env) {
+			continue
+		}
+		if status.Update(app, env, deployment) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	connection, channel := SplitChannel(cfg.Channel)
+	bot, err := o.forConnection(connection)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the slack connection")
+	}
+	options := []slack.MsgOption{
+		slack.MsgOptionText(environmentStatusTitle, false),
+		slack.MsgOptionBlocks(createEnvironmentStatusBlocks(status, cfg.Environments, time.Now())...),
+	}
+	if status.Timestamp != "" {
+		_, _, _, err = bot.SlackClient.SendMessage(status.ChannelID, append(options, slack.MsgOptionUpdate(status.Timestamp))...)
+		if err == nil {
+			log.Logger().Infof("Environment status updated in %s\n", cfg.Channel)
+			return o.saveEnvironmentStatus(ctx, cfg.Channel, status)
+		}
+		if !isMissingMessageError(err) {
+			// lets keep the deployments so that the next update of the message includes them
+			saveErr := o.saveEnvironmentStatus(ctx, cfg.Channel, status)
+			if saveErr != nil {
+				log.Logger().Warnf("failed to save the environment status of channel %s: %s", cfg.Channel, saveErr.Error())
+			}
+			return errors.Wrapf(err, "failed to update the environment status message in channel %s", cfg.Channel)
+		}
+		// the message has been deleted so lets post a new one
+		log.Logger().Warnf("failed to update the environment status message in channel %s so posting a new one: %s", cfg.Channel, err.Error())
+	}
+
+	channelID, timestamp, _, err := bot.SlackClient.SendMessage(channelName(channel), options...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to post the environment status message")
+	}
+	log.Logger().Infof("Environment status posted to %s\n", cfg.Channel)
+	status.ChannelID = channelID
+	status.Timestamp = timestamp
+
+	err = bot.SlackClient.AddPin(channelID, slack.NewRefToMessage(channelID, timestamp))
+	if err != nil {
+		log.Logger().Warnf("failed to pin the environment status message in channel %s: %s", cfg.Channel, describeSlackError(err, "pins:write", cfg.Channel))
+	}
+	return o.saveEnvironmentStatus(ctx, cfg.Channel, status)
+}
+
+// isMissingMessageError returns true if the message could not be updated because it has been deleted or can no
+// longer be edited by the bot
+func isMissingMessageError(err error) bool {
+	switch slackErrorCode(err) {
+	case "message_not_found", "cant_update_message":
+		return true
+	default:
+		return false
+	}
+}
+
+// saveEnvironmentStatus saves the status unless this is a dry run
+func (o *Options) saveEnvironmentStatus(ctx context.Context, channel string, status *EnvironmentStatus) error {
+	if o.DryRun {
+		log.Logger().Infof("dry run: would save the environment status of channel %s", channel)
+		return nil
+	}
+	return o.EnvironmentStatus.Save(ctx, channel, status)
+}
+
+// createEnvironmentStatusBlocks creates a table of the version of each application in each environment along with
+// links to the applications
+func createEnvironmentStatusBlocks(status *EnvironmentStatus, environments []string, now time.Time) []slack.Block {
+	if len(environments) == 0 {
+		environments = statusEnvironments(status)
+	}
+	var apps []string
+	for app := range status.Applications {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+
+	buf := &strings.Builder{}
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	headings := []string{"APPLICATION"}
+	for _, env := range environments {
+		headings = append(headings, strings.ToUpper(env))
+	}
+	fmt.Fprintln(w, strings.Join(headings, "\t"))
+
+	var links []string
+	for _, app := range apps {
+		row := []string{app}
+		for _, env := range environments {
+			d := status.Applications[app][env]
+			if d == nil {
+				row = append(row, "-")
+				continue
+			}
+			row = append(row, fmt.Sprintf("%s %s", d.Version, d.Promoted.UTC().Format(environmentStatusTimeFormat)))
+			if d.URL != "" {
+				links = append(links, fmt.Sprintf("%s in %s", link(app+" "+d.Version, d.URL), strings.Title(env)))
+			}
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, ":bar_chart: "+environmentStatusTitle, true, false)),
+	}
+	// the rows are aligned before being split so that the table still lines up across the sections
+	rows := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	blocks = append(blocks, markdownSections(rows, "```")...)
+	if len(links) > maxEnvironmentStatusLinks {
+		more := len(links) - maxEnvironmentStatusLinks
+		links = append(links[:maxEnvironmentStatusLinks], fmt.Sprintf("and %d more", more))
+	}
+	blocks = append(blocks, markdownSections(links, "")...)
+	blocks = append(blocks, slack.NewContextBlock("",
+		slack.NewTextBlockObject(slack.MarkdownType, "updated "+now.UTC().Format(environmentStatusTimeFormat), false, false)))
+	return blocks
+}
+
+// markdownSections splits the lines across as few mrkdwn sections as possible, each wrapped in the fence, keeping
+// the text of each section within the slack limit
+func markdownSections(lines []string, fence string) []slack.Block {
+	limit := maxSectionTextLength - 2*len(fence)
+	var blocks []slack.Block
+	var section []string
+	length := 0
+	flush := func() {
+		if len(section) == 0 {
+			return
+		}
+		text := fence + strings.Join(section, "\n") + fence
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil))
+		section = nil
+		length = 0
+	}
+	for _, line := range lines {
+		if len(line) > limit {
+			line = line[:limit-3] + "..."
+		}
+		if length+len(line)+1 > limit {
+			flush()
+		}
+		section = append(section, line)
+		length += len(line) + 1
+	}
+	flush()
+	return blocks
+}
+
+// statusEnvironments returns the names of the environments any application has been promoted to
+func statusEnvironments(status *EnvironmentStatus) []string {
+	var answer []string
+	for _, envs := range status.Applications {
+		for env := range envs {
+			if !stringsContain(answer, env) {
+				answer = append(answer, env)
+			}
+		}
+	}
+	sort.Strings(answer)
+	return answer
+}
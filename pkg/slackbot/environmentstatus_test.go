@@ -0,0 +1,226 @@
+package slackbot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEnvironmentStatus(t *testing.T) {
+	ns := "jx"
+	channel := "#deployments"
+	ctx := context.TODO()
+
+	kubeClient := fake.NewSimpleClientset()
+	fakeSlackClient := fakeslack.NewFakeSlack()
+	o := &Options{
+		KubeClient:        kubeClient,
+		SlackClient:       fakeSlackClient,
+		EnvironmentStatus: NewEnvironmentStatusStore(kubeClient, ns),
+		Config: &Config{
+			EnvironmentStatus: []EnvironmentStatusChannel{
+				{
+					Channel:      channel,
+					Environments: []string{"staging", "production"},
+				},
+			},
+		},
+	}
+	o.Namespace = ns
+
+	promoted := time.Date(2021, 3, 4, 10, 30, 0, 0, time.UTC)
+	o.updateEnvironmentStatus(ctx, createPromoteActivity("myapp-1", "1.0.0", "staging", promoted))
+
+	require.Len(t, fakeSlackClient.Messages[channel], 1, "should have posted the status message")
+	first := fakeSlackClient.Messages[channel][0]
+	require.Len(t, fakeSlackClient.Pins[channel], 1, "should have pinned the status message")
+	assert.Equal(t, first.Timestamp, fakeSlackClient.Pins[channel][0].Timestamp, "pinned message timestamp")
+
+	blocks := renderBlocks(t, channel, first.Options)
+	assert.Contains(t, blocks, "APPLICATION  STAGING", "blocks")
+	assert.Contains(t, blocks, "1.0.0 2021-03-04 10:30 UTC", "blocks")
+
+	status, err := o.EnvironmentStatus.Get(ctx, channel)
+	require.NoError(t, err, "failed to load environment status")
+	assert.Equal(t, first.Timestamp, status.Timestamp, "saved timestamp")
+	require.NotNil(t, status.Applications["myorg/myapp"]["staging"], "saved staging deployment")
+	assert.Equal(t, "1.0.0", status.Applications["myorg/myapp"]["staging"].Version, "saved staging version")
+
+	// the same promotion should not edit the message again
+	o.updateEnvironmentStatus(ctx, createPromoteActivity("myapp-1", "1.0.0", "staging", promoted))
+	require.Len(t, fakeSlackClient.Messages[channel], 1, "should not have edited the message for the same version")
+
+	o.updateEnvironmentStatus(ctx, createPromoteActivity("myapp-2", "1.0.1", "production", promoted.Add(time.Hour)))
+
+	require.Len(t, fakeSlackClient.Messages[channel], 2, "should have edited the status message")
+	require.Len(t, fakeSlackClient.Pins[channel], 1, "should not have pinned another message")
+
+	_, values, err := slack.UnsafeApplyMsgOptions("fakeToken", channel, "fakeapiurl", fakeSlackClient.Messages[channel][1].Options...)
+	require.NoError(t, err, "failed to render message")
+	assert.Equal(t, first.Timestamp, values.Get("ts"), "should have updated the first message")
+	blocks = values.Get("blocks")
+	assert.Contains(t, blocks, "1.0.0 2021-03-04 10:30 UTC", "blocks")
+	assert.Contains(t, blocks, "1.0.1 2021-03-04 11:30 UTC", "blocks")
+}
+
+// failingSlack fails the next message it is sent with the given error
+type failingSlack struct {
+	*fakeslack.FakeSlack
+	err error
+}
+
+func (f *failingSlack) SendMessage(channel string, options ...slack.MsgOption) (string, string, string, error) {
+	if f.err != nil {
+		err := f.err
+		f.err = nil
+		return "", "", "", err
+	}
+	return f.FakeSlack.SendMessage(channel, options...)
+}
+
+func TestEnvironmentStatusUpdateFailure(t *testing.T) {
+	ns := "jx"
+	channel := "#deployments"
+	ctx := context.TODO()
+
+	kubeClient := fake.NewSimpleClientset()
+	fakeSlackClient := fakeslack.NewFakeSlack()
+	slackClient := &failingSlack{FakeSlack: fakeSlackClient}
+	o := &Options{
+		KubeClient:        kubeClient,
+		SlackClient:       slackClient,
+		EnvironmentStatus: NewEnvironmentStatusStore(kubeClient, ns),
+	}
+	o.Namespace = ns
+	cfg := &EnvironmentStatusChannel{Channel: channel}
+	promoted := time.Date(2021, 3, 4, 10, 30, 0, 0, time.UTC)
+	promote := func(version string, age time.Duration) error {
+		return o.updateEnvironmentStatusChannel(ctx, cfg, "myorg/myapp", "myapp", map[string]*Deployment{
+			"staging": {Version: version, Promoted: promoted.Add(age)},
+		})
+	}
+
+	require.NoError(t, promote("1.0.0", 0), "failed to post the status")
+	require.Len(t, fakeSlackClient.Messages[channel], 1, "should post the status message")
+
+	// other errors should not post a new message
+	slackClient.err = slack.SlackErrorResponse{Err: "ratelimited"}
+	require.Error(t, promote("1.0.1", time.Hour), "should fail to update the status")
+	assert.Len(t, fakeSlackClient.Messages[channel], 1, "should not post a new message")
+	assert.Len(t, fakeSlackClient.Pins[channel], 1, "should not pin a new message")
+
+	// a deleted message should be posted and pinned again
+	slackClient.err = slack.SlackErrorResponse{Err: "message_not_found"}
+	require.NoError(t, promote("1.0.2", 2*time.Hour), "failed to post the status again")
+	require.Len(t, fakeSlackClient.Messages[channel], 2, "should post a new message")
+	assert.Len(t, fakeSlackClient.Pins[channel], 2, "should pin the new message")
+	blocks := renderBlocks(t, channel, fakeSlackClient.Messages[channel][1].Options)
+	assert.Contains(t, blocks, "1.0.2", "blocks")
+}
+
+func TestEnvironmentStatusUpdate(t *testing.T) {
+	now := time.Now()
+	status := &EnvironmentStatus{}
+
+	assert.True(t, status.Update("myapp", "staging", &Deployment{Version: "1.0.1", Promoted: now}), "first deployment")
+	assert.False(t, status.Update("myapp", "staging", &Deployment{Version: "1.0.1", Promoted: now.Add(time.Minute)}), "same version")
+	assert.False(t, status.Update("myapp", "staging", &Deployment{Version: "1.0.0", Promoted: now.Add(-time.Hour)}), "older deployment")
+	assert.True(t, status.Update("myapp", "staging", &Deployment{Version: "1.0.2", Promoted: now.Add(time.Hour)}), "newer deployment")
+	assert.Equal(t, "1.0.2", status.Applications["myapp"]["staging"].Version, "current version")
+}
+
+func TestEnvironmentStatusRename(t *testing.T) {
+	status := &EnvironmentStatus{}
+	status.Update("myapp", "staging", &Deployment{Version: "1.0.0", Promoted: time.Now()})
+
+	assert.True(t, status.Rename("myapp", "myorg/myapp"), "should move the legacy application")
+	assert.Nil(t, status.Applications["myapp"], "legacy application")
+	require.NotNil(t, status.Applications["myorg/myapp"]["staging"], "renamed application")
+	assert.False(t, status.Rename("myapp", "myorg/myapp"), "nothing left to move")
+}
+
+func TestEnvironmentStatusBlocksWithManyApplications(t *testing.T) {
+	now := time.Now()
+	status := &EnvironmentStatus{}
+	environments := []string{"staging", "production"}
+	for i := 0; i < 200; i++ {
+		app := fmt.Sprintf("myorg/application-with-a-long-name-%d", i)
+		for _, env := range environments {
+			status.Update(app, env, &Deployment{
+				Version:  "1.2.3",
+				URL:      fmt.Sprintf("https://application-with-a-long-name-%d-%s.example.com", i, env),
+				Promoted: now,
+			})
+		}
+	}
+
+	blocks := createEnvironmentStatusBlocks(status, environments, now)
+	var tableSections, links int
+	for _, b := range blocks {
+		section, ok := b.(*slack.SectionBlock)
+		if !ok {
+			continue
+		}
+		text := section.Text.Text
+		assert.LessOrEqual(t, len(text), maxSectionTextLength, "section text length")
+		if strings.HasPrefix(text, "```") {
+			tableSections++
+			assert.True(t, strings.HasSuffix(text, "```"), "each table section should be a code block")
+		} else {
+			links += strings.Count(text, "\n") + 1
+		}
+	}
+	assert.Greater(t, tableSections, 1, "the table should be split across sections")
+	assert.Equal(t, maxEnvironmentStatusLinks+1, links, "the links should be capped")
+	assert.LessOrEqual(t, len(blocks), 50, "slack messages can have at most 50 blocks")
+
+	data, err := json.Marshal(blocks)
+	require.NoError(t, err, "failed to marshal blocks")
+	assert.Contains(t, string(data), fmt.Sprintf("and %d more", 400-maxEnvironmentStatusLinks), "blocks")
+}
+
+func createPromoteActivity(name, version, env string, completed time.Time) *jenkinsv1.PipelineActivity {
+	completedTime := metav1.NewTime(completed)
+	return &jenkinsv1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "jx",
+		},
+		Spec: jenkinsv1.PipelineActivitySpec{
+			GitOwner:      "myorg",
+			GitRepository: "myapp",
+			Version:       version,
+			Steps: []jenkinsv1.PipelineActivityStep{
+				{
+					Kind: jenkinsv1.ActivityStepKindTypePromote,
+					Promote: &jenkinsv1.PromoteActivityStep{
+						CoreActivityStep: jenkinsv1.CoreActivityStep{
+							Name:               "promote " + env,
+							Status:             jenkinsv1.ActivityStatusTypeSucceeded,
+							CompletedTimestamp: &completedTime,
+						},
+						Environment:    env,
+						ApplicationURL: "https://myapp-" + env + ".example.com",
+					},
+				},
+			},
+		},
+	}
+}
+
+func renderBlocks(t *testing.T, channel string, options []slack.MsgOption) string {
+	_, values, err := slack.UnsafeApplyMsgOptions("fakeToken", channel, "fakeapiurl", options...)
+	require.NoError(t, err, "failed to render message")
+	return values.Get("blocks")
+}
@@ -0,0 +1,27 @@
+package slackbot
+
+import (
+	"context"
+
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// HandleEvent processes a callback event from the slack events API
+func (o *Options) HandleEvent(event *slackevents.EventsAPIEvent) {
+	if event.Type != slackevents.CallbackEvent {
+		return
+	}
+	switch ev := event.InnerEvent.Data.(type) {
+	case *slackevents.AppHomeOpenedEvent:
+		if ev.Tab != "" && ev.Tab != "home" {
+			return
+		}
+		err := o.forTeam(event.TeamID).PublishHomeTab(context.TODO(), ev.User)
+		if err != nil {
+			log.Logger().Warnf("failed to publish home tab for %s: %s", ev.User, err.Error())
+		}
+	default:
+		log.Logger().Debugf("ignoring slack event %s", event.InnerEvent.Type)
+	}
+}
@@ -0,0 +1,100 @@
+package slackbot
+
+import (
+	"context"
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/naming"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// maxFailureReasonLength the maximum length of the termination message shown in the header
+const maxFailureReasonLength = 150
+
+// failedStepName returns the name of the first failed step of the activity, or of the stage or promotion if none of
+// its steps failed, such as build-make-test or an empty string if nothing failed
+func failedStepName(activity *jenkinsv1.PipelineActivity) string {
+	for _, step := range activity.Spec.Steps {
+		if stage := step.Stage; stage != nil {
+			if !isFailedStatus(stage.Status) {
+				continue
+			}
+			for _, s := range stage.Steps {
+				if isFailedStatus(s.Status) {
+					return naming.ToValidName(s.Name)
+				}
+			}
+			return naming.ToValidName(stage.Name)
+		}
+		if promote := step.Promote; promote != nil && isFailedStatus(promote.Status) {
+			return naming.ToValidName("promote " + promote.Environment)
+		}
+	}
+	return ""
+}
+
+// describeFailure describes where a failed pipeline failed such as 'failed at build-make-test: OOMKilled' or
+// returns an empty string if the activity has not failed or the failed step is unknown
+func (o *Options) describeFailure(ctx context.Context, activity *jenkinsv1.PipelineActivity) string {
+	if !isFailedStatus(pipelineStatus(activity)) {
+		return ""
+	}
+	step := failedStepName(activity)
+	if step == "" {
+		return ""
+	}
+	text := "failed at " + step
+	reason, err := o.failureReason(ctx, activity, step)
+	if err != nil {
+		log.Logger().Warnf("failed to find why %s failed: %s", activity.Name, err.Error())
+	}
+	if reason != "" {
+		text += ": " + reason
+	}
+	return text
+}
+
+// failureReason returns the termination message, or the reason if there is none, of the container of the failed
+// step in the pods of the activity
+func (o *Options) failureReason(ctx context.Context, activity *jenkinsv1.PipelineActivity, step string) (string, error) {
+	pods, err := o.pipelinePods(ctx, activity)
+	if err != nil {
+		return "", err
+	}
+
+	var fallback *corev1.ContainerStateTerminated
+	for i := range pods {
+		for _, status := range pods[i].Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if terminated == nil || terminated.ExitCode == 0 {
+				continue
+			}
+			if status.Name == "step-"+step {
+				return terminationReason(terminated), nil
+			}
+			if fallback == nil {
+				fallback = terminated
+			}
+		}
+	}
+	if fallback != nil {
+		return terminationReason(fallback), nil
+	}
+	return "", nil
+}
+
+// terminationReason returns the first line of the termination message of the container ignoring the results which
+// tekton writes there
+func terminationReason(terminated *corev1.ContainerStateTerminated) string {
+	message := strings.TrimSpace(terminated.Message)
+	if message == "" || strings.HasPrefix(message, "[") {
+		return terminated.Reason
+	}
+	message = strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+	if len(message) > maxFailureReasonLength {
+		message = message[:maxFailureReasonLength] + "..."
+	}
+	return message
+}
@@ -0,0 +1,138 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/naming"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDescribeFailure(t *testing.T) {
+	ns := "jx"
+	activity := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "main", "release", "1", jenkinsv1.ActivityStatusTypeFailed)
+	activity.Spec.Steps = []jenkinsv1.PipelineActivityStep{
+		{
+			Kind: jenkinsv1.ActivityStepKindTypeStage,
+			Stage: &jenkinsv1.StageActivityStep{
+				CoreActivityStep: jenkinsv1.CoreActivityStep{
+					Name:   "Release",
+					Status: jenkinsv1.ActivityStatusTypeFailed,
+				},
+				Steps: []jenkinsv1.CoreActivityStep{
+					{
+						Name:   "Git Clone",
+						Status: jenkinsv1.ActivityStatusTypeSucceeded,
+					},
+					{
+						Name:   "Build Make Test",
+						Status: jenkinsv1.ActivityStatusTypeFailed,
+					},
+					{
+						Name:   "Promote Jx Promote",
+						Status: jenkinsv1.ActivityStatusTypePending,
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, "build-make-test", failedStepName(activity), "failed step")
+
+	pod := createStepPod(ns, "myorg", "myrepo", "main", "release", "1")
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{
+			Name: "step-git-clone",
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{
+					ExitCode: 0,
+					Message:  `[{"key":"StartedAt","value":"2021-01-01T00:00:00Z"}]`,
+				},
+			},
+		},
+		{
+			Name: "step-build-make-test",
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{
+					ExitCode: 2,
+					Reason:   "Error",
+					Message:  "make: *** [test] Error 2\nmore output",
+				},
+			},
+		},
+	}
+	// the failed pod of another build should be ignored
+	otherBuild := createStepPod(ns, "myorg", "myrepo", "main", "release", "2")
+	otherBuild.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{
+			Name: "step-build-make-test",
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{
+					ExitCode: 1,
+					Reason:   "Error",
+					Message:  "another build",
+				},
+			},
+		},
+	}
+	o := &Options{
+		KubeClient: fake.NewSimpleClientset(pod, otherBuild),
+	}
+	o.Namespace = ns
+
+	ctx := context.TODO()
+	assert.Equal(t, "failed at build-make-test: make: *** [test] Error 2", o.describeFailure(ctx, activity), "failure")
+
+	options, _, err := o.createPipelineMessage(activity, nil, "")
+	require.NoError(t, err, "failed to create message")
+	_, values, err := slack.UnsafeApplyMsgOptions("fakeToken", "#builds", "fakeapiurl", options...)
+	require.NoError(t, err, "failed to render message")
+	assert.Contains(t, values.Get("attachments"), "failed at build-make-test: make: *** [test] Error 2", "attachments")
+
+	// tekton results are not shown
+	pod.Status.ContainerStatuses[1].State.Terminated.Message = `[{"key":"StartedAt","value":"2021-01-01T00:00:00Z"}]`
+	o.KubeClient = fake.NewSimpleClientset(pod, otherBuild)
+	assert.Equal(t, "failed at build-make-test: Error", o.describeFailure(ctx, activity), "failure without a message")
+
+	// without a pod only the step is shown
+	o.KubeClient = fake.NewSimpleClientset(otherBuild)
+	assert.Equal(t, "failed at build-make-test", o.describeFailure(ctx, activity), "failure without a pod")
+
+	activity.Spec.Status = jenkinsv1.ActivityStatusTypeSucceeded
+	assert.Equal(t, "", o.describeFailure(ctx, activity), "succeeded pipeline")
+}
+
+// createStepPod creates the pod of a pipeline with the labels which lighthouse adds to the PipelineRun and tekton
+// copies onto the pods of its TaskRuns
+func createStepPod(ns, owner, repo, branch, pipelineCtx, build string) *corev1.Pod {
+	pipelineRun := naming.ToValidName(repo + "-" + branch + "-" + pipelineCtx + "-" + build + "-" + "abcde")
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pipelineRun + "-from-build-pack-pod",
+			Namespace: ns,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by":      "tekton-pipelines",
+				"created-by-lighthouse":             "true",
+				"lighthouse.jenkins-x.io/branch":    branch,
+				"lighthouse.jenkins-x.io/buildNum":  build,
+				"lighthouse.jenkins-x.io/context":   pipelineCtx,
+				"lighthouse.jenkins-x.io/id":        naming.ToValidName(owner + "-" + repo + "-" + branch + "-" + pipelineCtx),
+				"lighthouse.jenkins-x.io/job":       pipelineCtx,
+				"lighthouse.jenkins-x.io/refs.org":  owner,
+				"lighthouse.jenkins-x.io/refs.repo": repo,
+				"lighthouse.jenkins-x.io/type":      "postsubmit",
+				"tekton.dev/memberOf":               "tasks",
+				"tekton.dev/pipeline":               pipelineRun,
+				"tekton.dev/pipelineRun":            pipelineRun,
+				"tekton.dev/pipelineTask":           "from-build-pack",
+				"tekton.dev/taskRun":                pipelineRun + "-from-build-pack",
+			},
+		},
+	}
+}
@@ -0,0 +1,338 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	"github.com/jenkins-x/go-scm/scm"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// DefaultHomePipelineCount the default number of recent pipelines shown on the App Home tab
+	DefaultHomePipelineCount = 10
+
+	// maxHomePullRequests the maximum number of pull requests shown so we stay within the block limit of a view
+	maxHomePullRequests = 20
+
+	// maxHomeBlocks the maximum number of blocks slack allows in a view
+	maxHomeBlocks = 100
+
+	// homeFixedBlocks the number of headings, dividers and actions in the App Home tab
+	homeFixedBlocks = 6
+
+	// maxHomePipelineCount the most pipelines which fit in the App Home tab along with the pull requests
+	maxHomePipelineCount = maxHomeBlocks - maxHomePullRequests - homeFixedBlocks
+
+	// homePullRequestPageSize the number of pull requests listed at a time
+	homePullRequestPageSize = 100
+
+	// maxHomePullRequestPages the maximum number of pages of open pull requests listed for a repository
+	maxHomePullRequestPages = 10
+
+	// homePullRequestsTTL how long the open pull requests of a repository are remembered for
+	homePullRequestsTTL = time.Minute
+
+	// maxHomePullRequestsCacheSize the number of repositories whose open pull requests are remembered
+	maxHomePullRequestsCacheSize = 100
+
+	// homeActivitiesPageSize the number of PipelineActivities listed at a time looking for the pipelines of a user
+	homeActivitiesPageSize = 500
+
+	// maxHomeActivities the maximum number of PipelineActivities looked at for the pipelines of a user
+	maxHomeActivities = 5000
+)
+
+// homeRepository a repository shown on the App Home tab along with the namespace of its PipelineActivities
+type homeRepository struct {
+	namespace string
+	fullName  string
+}
+
+// homePullRequests the open pull requests of a repository and when they were listed
+type homePullRequests struct {
+	listed       time.Time
+	pullRequests []*scm.PullRequest
+}
+
+// PublishHomeTab renders the App Home tab for the given slack user showing their open pull requests and
+// their most recent pipelines in the repositories notified via the slack connection of the options
+func (o *Options) PublishHomeTab(ctx context.Context, slackUserID string) error {
+	user, err := o.SlackUserResolver.FindUser(ctx, slackUserID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the Jenkins X user for slack user %s", slackUserID)
+	}
+	resolver := &users.GitUserResolver{
+		GitProvider: o.ScmClient,
+	}
+	login := GitUserLogin(user, resolver.GitProviderKey())
+
+	var blocks []slack.Block
+	if login == "" {
+		blocks = append(blocks, markdownSection("I could not find the git user for your slack account. "+
+			"Please ask your administrator to add your slack account to your Jenkins X User or to the user mapping file"))
+	} else {
+		prBlocks, err := o.homePullRequestBlocks(ctx, login)
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, prBlocks...)
+		blocks = append(blocks, slack.NewDividerBlock())
+
+		pipelineBlocks, err := o.homePipelineBlocks(ctx, login)
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, pipelineBlocks...)
+	}
+	blocks = append(blocks, slack.NewDividerBlock(),
+		markdownSection("*Your notification settings*\n"+describePreferences(o.userPreferences(slackUserID))+
+			"\n_Use `/jx notify help` to change them_"),
+		slack.NewActionBlock("home-actions",
+			slack.NewButtonBlockElement(refreshHomeActionID, "refresh",
+				slack.NewTextBlockObject(slack.PlainTextType, "Refresh", false, false))))
+
+	view := slack.HomeTabViewRequest{
+		Type:   slack.VTHomeTab,
+		Blocks: slack.Blocks{BlockSet: blocks},
+	}
+	_, err = o.SlackClient.PublishView(slackUserID, view, "")
+	if err != nil {
+		return errors.Wrapf(err, "failed to publish the home tab for slack user %s", slackUserID)
+	}
+	return nil
+}
+
+// homePullRequestBlocks lists the open pull requests authored by the git user in the repositories we notify on
+func (o *Options) homePullRequestBlocks(ctx context.Context, login string) ([]slack.Block, error) {
+	blocks := []slack.Block{markdownSection("*Your open pull requests*")}
+	count := 0
+	for _, repo := range o.homeRepositories() {
+		prs, err := o.listOpenPullRequests(ctx, repo.fullName)
+		if err != nil {
+			log.Logger().Warnf("failed to list pull requests of %s: %s", repo.fullName, err.Error())
+			continue
+		}
+		for _, pr := range prs {
+			if !strings.EqualFold(pr.Author.Login, login) {
+				continue
+			}
+			text, err := o.describeHomePullRequest(ctx, repo, pr)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, markdownSection(text))
+			count++
+			if count >= maxHomePullRequests {
+				return blocks, nil
+			}
+		}
+	}
+	if count == 0 {
+		blocks = append(blocks, markdownSection("You have no open pull requests"))
+	}
+	return blocks, nil
+}
+
+// listOpenPullRequests returns the open pull requests of the repository. They are remembered for a short while so
+// that opening the App Home tab does not list the pull requests of every repository each time
+func (o *Options) listOpenPullRequests(ctx context.Context, fullName string) ([]*scm.PullRequest, error) {
+	if o.homePullRequests == nil {
+		o.homePullRequests = newLRUCache(maxHomePullRequestsCacheSize)
+	}
+	if value, ok := o.homePullRequests.get(fullName); ok {
+		cached := value.(*homePullRequests)
+		if time.Since(cached.listed) < homePullRequestsTTL {
+			return cached.pullRequests, nil
+		}
+	}
+	var answer []*scm.PullRequest
+	for page := 1; page <= maxHomePullRequestPages; page++ {
+		prs, _, err := o.ScmClient.PullRequests.List(ctx, fullName, &scm.PullRequestListOptions{
+			Open: true,
+			Page: page,
+			Size: homePullRequestPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, prs...)
+		if len(prs) < homePullRequestPageSize {
+			break
+		}
+	}
+	o.homePullRequests.put(fullName, &homePullRequests{
+		listed:       time.Now(),
+		pullRequests: answer,
+	})
+	return answer, nil
+}
+
+// describeHomePullRequest describes the pull request with its review and build status the same way as the
+// review request message
+func (o *Options) describeHomePullRequest(ctx context.Context, repo homeRepository, pr *scm.PullRequest) (string, error) {
+	owner, name := scm.Split(repo.fullName)
+	var latest *jenkinsv1.PipelineActivity
+	acts, err := o.getPipelineActivities(ctx, repo.namespace, owner, name, pr.Number)
+	if err != nil {
+		log.Logger().Warnf("failed to find pipelines of %s PR %d: %s", repo.fullName, pr.Number, err.Error())
+	} else if len(acts.Items) > 0 {
+		sort.Sort(byBuildNumber(acts.Items))
+		latest = &acts.Items[len(acts.Items)-1]
+	}
+
+	reviewStatus, err := o.reviewStatus(latest, pr)
+	if err != nil {
+		return "", err
+	}
+	buildStatus := o.buildStatus(latest, pr)
+	return fmt.Sprintf("%s on %s\n%s %s    %s %s",
+		link(fmt.Sprintf("Pull Request %s (%s)", pullRequestName(pr.Link), pr.Title), pr.Link),
+		repo.fullName,
+		reviewStatus.Emoji, reviewStatus.Text,
+		buildStatus.Emoji, buildStatus.Text), nil
+}
+
+// homePipelineBlocks lists the most recent pipelines triggered by the git user
+func (o *Options) homePipelineBlocks(ctx context.Context, login string) ([]slack.Block, error) {
+	count := o.HomePipelineCount
+	if count <= 0 {
+		count = DefaultHomePipelineCount
+	}
+	if count > maxHomePipelineCount {
+		count = maxHomePipelineCount
+	}
+	blocks := []slack.Block{markdownSection(fmt.Sprintf("*Your last %d pipelines*", count))}
+
+	selector := o.homePipelineSelector()
+	var activities []jenkinsv1.PipelineActivity
+	for _, ns := range o.watchNamespaces() {
+		listOptions := metav1.ListOptions{
+			LabelSelector: selector,
+			Limit:         homeActivitiesPageSize,
+		}
+		for listed := 0; listed < maxHomeActivities; {
+			list, err := o.JXClient.JenkinsV1().PipelineActivities(ns).List(ctx, listOptions)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to list PipelineActivities in namespace %s", ns)
+			}
+			for i := range list.Items {
+				if strings.EqualFold(list.Items[i].Spec.Author, login) && o.isHomeActivity(&list.Items[i]) {
+					activities = append(activities, list.Items[i])
+				}
+			}
+			listed += len(list.Items)
+			listOptions.Continue = list.Continue
+			if listOptions.Continue == "" {
+				break
+			}
+		}
+	}
+	sort.Slice(activities, func(i, j int) bool {
+		return activities[j].CreationTimestamp.Before(&activities[i].CreationTimestamp)
+	})
+	if len(activities) > count {
+		activities = activities[0:count]
+	}
+	for i := range activities {
+		blocks = append(blocks, markdownSection(o.describeHomePipeline(&activities[i])))
+	}
+	if len(activities) == 0 {
+		blocks = append(blocks, markdownSection("You have no recent pipelines"))
+	}
+	return blocks, nil
+}
+
+// isHomeActivity returns true if the activity is of one of the repositories shown on the App Home tab
+func (o *Options) isHomeActivity(activity *jenkinsv1.PipelineActivity) bool {
+	details := CreatePipelineDetails(activity)
+	fullName := scm.Join(details.GitOwner, details.GitRepository)
+	for _, repo := range o.homeRepositories() {
+		if strings.EqualFold(repo.fullName, fullName) && (repo.namespace == metav1.NamespaceAll || repo.namespace == activity.Namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// homePipelineSelector returns the label selector of the PipelineActivities of the repositories in the source
+// configuration or an empty selector if they cannot all be selected
+func (o *Options) homePipelineSelector() string {
+	names := map[string]bool{}
+	var repositories []string
+	for _, repo := range o.homeRepositories() {
+		_, name := scm.Split(repo.fullName)
+		if len(validation.IsValidLabelValue(name)) > 0 || name == "" {
+			return ""
+		}
+		if !names[name] {
+			names[name] = true
+			repositories = append(repositories, name)
+		}
+	}
+	if len(repositories) == 0 {
+		return ""
+	}
+	sort.Strings(repositories)
+	return "repository in (" + strings.Join(repositories, ",") + ")"
+}
+
+func (o *Options) describeHomePipeline(activity *jenkinsv1.PipelineActivity) string {
+	spec := &activity.Spec
+	details := CreatePipelineDetails(activity)
+	status := pipelineStatus(activity)
+	text := fmt.Sprintf("%s %s %s %s", o.statusString(status), repositoryName(activity), details.BranchName,
+		link("#"+spec.Build, o.pipelineBuildURL(activity)))
+	if spec.Context != "" {
+		text += " (" + spec.Context + ")"
+	}
+	if status != "" {
+		text += " " + strings.ToLower(string(status))
+	}
+	return text
+}
+
+// homeRepositories returns the repositories in the source configuration of each watched namespace which notify
+// via the slack connection of the options
+func (o *Options) homeRepositories() []homeRepository {
+	var answer []homeRepository
+	found := map[homeRepository]bool{}
+	for _, ns := range o.watchNamespaces() {
+		sourceConfigs := o.sourceConfigsFor(ns)
+		if sourceConfigs == nil {
+			continue
+		}
+		for i := range sourceConfigs.Spec.Groups {
+			group := &sourceConfigs.Spec.Groups[i]
+			for j := range group.Repositories {
+				r := &group.Repositories[j]
+				connection := ""
+				if r.Slack != nil {
+					connection, _ = SplitChannel(r.Slack.Channel)
+				}
+				if connection != o.SlackUserResolver.Connection {
+					continue
+				}
+				repo := homeRepository{namespace: ns, fullName: scm.Join(group.Owner, r.Name)}
+				if !found[repo] {
+					found[repo] = true
+					answer = append(answer, repo)
+				}
+			}
+		}
+	}
+	return answer
+}
+
+func markdownSection(text string) *slack.SectionBlock {
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+}
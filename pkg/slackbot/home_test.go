@@ -0,0 +1,184 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	"github.com/jenkins-x/go-scm/scm"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPublishHomeTab(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+	slackUserID := "U111"
+
+	user := createHomeUser(ns, "", slackUserID)
+	release := createHomeActivity(ns, owner, repo, "main", "release", "1", "myuser", 2*time.Hour)
+	pr := createHomeActivity(ns, owner, repo, "PR-1", "pr", "1", "myuser", time.Hour)
+	otherAuthor := createHomeActivity(ns, owner, repo, "main", "lint", "2", "otheruser", time.Minute)
+	otherRepo := createHomeActivity(ns, owner, "otherrepo", "main", "release", "1", "myuser", time.Minute)
+	jxClient := fakejx.NewSimpleClientset(user, release, pr, otherAuthor, otherRepo)
+
+	scmClient, _ := fakescm.NewDefault()
+	slackClient := fakeslack.NewFakeSlack()
+	o := &Options{
+		JXClient:          jxClient,
+		ScmClient:         scmClient,
+		SlackClient:       slackClient,
+		SlackUserResolver: NewSlackUserResolver(slackClient, jxClient, ns),
+		SourceConfigs:     createNamespaceSourceConfig(owner, repo, "#builds"),
+	}
+	o.Namespace = ns
+	assert.Equal(t, "repository in (myrepo)", o.homePipelineSelector(), "selector")
+
+	err := o.PublishHomeTab(context.TODO(), slackUserID)
+	require.NoError(t, err, "failed to publish the home tab")
+
+	text := homeTabText(t, slackClient, slackUserID)
+	assert.Equal(t, slack.VTHomeTab, slackClient.HomeViews[slackUserID].Type, "view type")
+	assert.Contains(t, text, "You have no open pull requests", "pull requests")
+	assert.Contains(t, text, "*Your last 10 pipelines*", "pipelines heading")
+	assert.NotContains(t, text, "otherrepo", "should only list the repositories in the source configuration")
+	assert.NotContains(t, text, "(lint)", "should only list the pipelines of the user")
+
+	prIdx := strings.Index(text, "|myrepo> PR-1")
+	releaseIdx := strings.Index(text, "|myrepo> main")
+	require.True(t, prIdx >= 0, "should list the pull request pipeline in %s", text)
+	require.True(t, releaseIdx >= 0, "should list the release pipeline in %s", text)
+	assert.True(t, prIdx < releaseIdx, "should list the newest pipeline first")
+}
+
+func TestHomeTabListsPullRequestsOnce(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+	slackUserID := "U111"
+
+	jxClient := fakejx.NewSimpleClientset(createHomeUser(ns, "", slackUserID))
+	scmClient, scmData := fakescm.NewDefault()
+	scmData.PullRequests[1] = &scm.PullRequest{
+		Number: 1,
+		Title:  "my pull request",
+		Link:   "https://fake.git/myorg/myrepo/pull/1",
+		Author: scm.User{Login: "myuser"},
+		Base: scm.PullRequestBranch{
+			Repo: scm.Repository{Namespace: owner, Name: repo, FullName: owner + "/" + repo},
+		},
+	}
+	slackClient := fakeslack.NewFakeSlack()
+	o := &Options{
+		JXClient:          jxClient,
+		ScmClient:         scmClient,
+		SlackClient:       slackClient,
+		SlackUserResolver: NewSlackUserResolver(slackClient, jxClient, ns),
+		SourceConfigs:     createNamespaceSourceConfig(owner, repo, "#builds"),
+		HomePipelineCount: 1000,
+	}
+	o.Namespace = ns
+
+	err := o.PublishHomeTab(context.TODO(), slackUserID)
+	require.NoError(t, err, "failed to publish the home tab")
+	text := homeTabText(t, slackClient, slackUserID)
+	assert.Contains(t, text, "my pull request", "pull requests")
+	assert.Contains(t, text, fmt.Sprintf("*Your last %d pipelines*", maxHomePipelineCount), "should clamp the pipeline count")
+
+	// the pull requests are remembered rather than listed again
+	delete(scmData.PullRequests, 1)
+	err = o.PublishHomeTab(context.TODO(), slackUserID)
+	require.NoError(t, err, "failed to publish the home tab")
+	assert.Contains(t, homeTabText(t, slackClient, slackUserID), "my pull request", "cached pull requests")
+}
+
+func TestHomeTabUsesTeamConnection(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	slackUserID := "U222"
+
+	sourceConfig := createNamespaceSourceConfig(owner, "myrepo", "#builds")
+	group := &sourceConfig.Spec.Groups[0]
+	group.Repositories = append(group.Repositories, v1alpha1.Repository{
+		Name:  "opsrepo",
+		Slack: &v1alpha1.SlackNotify{Channel: "ops:#builds"},
+	})
+	jxClient := fakejx.NewSimpleClientset(
+		createHomeUser(ns, "ops", slackUserID),
+		createHomeActivity(ns, owner, "myrepo", "main", "release", "1", "myuser", time.Hour),
+		createHomeActivity(ns, owner, "opsrepo", "main", "release", "1", "myuser", time.Hour),
+	)
+	scmClient, _ := fakescm.NewDefault()
+	slackClient := fakeslack.NewFakeSlack()
+	opsClient := fakeslack.NewFakeSlack()
+	o := &Options{
+		JXClient:          jxClient,
+		ScmClient:         scmClient,
+		SlackClient:       slackClient,
+		SlackUserResolver: NewSlackUserResolver(slackClient, jxClient, ns),
+		SourceConfigs:     sourceConfig,
+		Connections:       map[string]slacker.Interface{"ops": opsClient},
+		Config: &Config{
+			Connections: []Connection{{Name: "ops", TeamID: "T2"}},
+		},
+	}
+	o.Namespace = ns
+
+	o.HandleEvent(&slackevents.EventsAPIEvent{
+		Type:   slackevents.CallbackEvent,
+		TeamID: "T2",
+		InnerEvent: slackevents.EventsAPIInnerEvent{
+			Type: slackevents.AppHomeOpened,
+			Data: &slackevents.AppHomeOpenedEvent{User: slackUserID, Tab: "home"},
+		},
+	})
+	assert.Empty(t, slackClient.HomeViews, "should not publish via the default connection")
+	text := homeTabText(t, opsClient, slackUserID)
+	assert.Contains(t, text, "|opsrepo> main", "should list the pipelines of the repositories of the connection")
+	assert.NotContains(t, text, "|myrepo> main", "should not list the pipelines of the default connection")
+}
+
+func createHomeUser(ns, connection, slackUserID string) *jenkinsv1.User {
+	return &jenkinsv1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "myuser", Namespace: ns},
+		Spec: jenkinsv1.UserDetails{
+			Login: "myuser",
+			Accounts: []jenkinsv1.AccountReference{
+				{Provider: (&SlackUserResolver{Connection: connection}).SlackProviderKey(), ID: slackUserID},
+			},
+		},
+	}
+}
+
+// homeTabText returns the text of the sections of the home tab published for the user
+func homeTabText(t *testing.T, slackClient *fakeslack.FakeSlack, slackUserID string) string {
+	view, ok := slackClient.HomeViews[slackUserID]
+	require.True(t, ok, "should publish the home tab of %s", slackUserID)
+	var texts []string
+	for _, block := range view.Blocks.BlockSet {
+		if section, ok := block.(*slack.SectionBlock); ok && section.Text != nil {
+			texts = append(texts, section.Text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func createHomeActivity(ns, owner, repo, branch, pipelineCtx, build, author string, age time.Duration) *jenkinsv1.PipelineActivity {
+	activity := testpipelines.CreateTestPipelineActivity(ns, owner, repo, branch, pipelineCtx, build, jenkinsv1.ActivityStatusTypeSucceeded)
+	activity.Spec.Author = author
+	activity.CreationTimestamp = metav1.NewTime(time.Now().Add(-age))
+	return activity
+}
@@ -0,0 +1,35 @@
+package slackbot
+
+import (
+	"context"
+
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// refreshHomeActionID the action ID of the button which refreshes the App Home tab
+	refreshHomeActionID = "refresh-home"
+)
+
+// HandleInteraction processes an interaction with one of our messages or views such as a button click
+func (o *Options) HandleInteraction(callback *slack.InteractionCallback) {
+	if callback.Type != slack.InteractionTypeBlockActions {
+		log.Logger().Debugf("ignoring slack interaction %s", string(callback.Type))
+		return
+	}
+	for _, action := range callback.ActionCallback.BlockActions {
+		if action == nil {
+			continue
+		}
+		switch action.ActionID {
+		case refreshHomeActionID:
+			err := o.forTeam(callback.Team.ID).PublishHomeTab(context.TODO(), callback.User.ID)
+			if err != nil {
+				log.Logger().Warnf("failed to refresh home tab for %s: %s", callback.User.ID, err.Error())
+			}
+		default:
+			log.Logger().Debugf("ignoring slack action %s", action.ActionID)
+		}
+	}
+}
@@ -32,49 +32,106 @@ func (o *Options) PipelineMessage(activity *jenkinsv1.PipelineActivity) error {
 	if activity.Name == "" {
 		return fmt.Errorf("PipelineActivity name cannot be empty")
 	}
+	o.recordPipelineState(context.TODO(), activity)
+	o.notifyEmails(context.TODO(), activity)
+	o.notifyWebhooks(context.TODO(), activity)
+	o.updateEnvironmentStatus(context.TODO(), activity)
 
 	cfg := o.getSlackConfigForPipeline(activity)
 	if cfg == nil || cfg.Channel == "" {
 		log.Logger().Infof("no slack configuration for %s", activity.Name)
 		return nil
 	}
-	channel := channelName(cfg.Channel)
-	enabled, pullRequest, resolver, err := o.NotifyPipeline(activity, cfg)
+	connection, channel := SplitChannel(cfg.Channel)
+	if notifier := o.Notifiers[connection]; notifier != nil {
+		return o.notifyPipeline(notifier, connection, channel, activity, cfg)
+	}
+	bot, err := o.forConnection(connection)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the slack connection for %s", activity.Name)
+	}
+	return bot.pipelineMessage(activity, cfg, channelName(channel))
+}
+
+// pipelineMessage sends the pipeline message to the channel and author using the slack connection of the options
+func (o *Options) pipelineMessage(activity *jenkinsv1.PipelineActivity, cfg *v1alpha1.SlackNotify, channel string) error {
+	decision, err := o.notifyDecision(activity, cfg)
 	if err != nil {
 		return errors.Wrapf(errors.WithStack(err), "failed to verify if message should be sent")
 	}
-	if !enabled {
+	if decision == nil {
 		return nil
 	}
-
-	options, createIfMissing, err := o.createPipelineMessage(activity, pullRequest)
+	pullRequest := decision.PullRequest
+	resolver := decision.Resolver
+	messageType := pipelineMessageType
+	messageActivity := activity
+	var all []jenkinsv1.PipelineActivity
+	var options []slack.MsgOption
+	createIfMissing := true
+	if o.useChecklist(activity) {
+		messageType = checklistMessageType
+		messageActivity, all, options, createIfMissing, err = o.createChecklistMessage(context.TODO(), activity, pullRequest)
+	} else {
+		options, createIfMissing, err = o.createPipelineMessage(activity, pullRequest, decision.ChangeLabel())
+	}
 	if err != nil {
 		return err
 	}
-	if cfg.Channel != "" {
-		err = o.postMessage(channel, false, pipelineMessageType, activity, nil, options, createIfMissing)
+
+	// lets check the preferences of the author to see if they want a direct message or a mention
+	directMessageID := ""
+	mentionID := ""
+	var resolveErr error
+	channelOptions := options
+	if cfg.DirectMessage.ToBool() && pullRequest != nil {
+		id, err := o.resolveGitUserToSlackUser(&pullRequest.Author, resolver)
+		if err != nil {
+			resolveErr = errors.Wrapf(err, "Cannot resolve Slack ID for Git user %s", pullRequest.Author.Name)
+		} else if id != "" {
+			prefs := o.userPreferences(id)
+			switch {
+			case !prefs.AllowsPipeline(activity, time.Now()):
+				log.Logger().Infof("Not notifying %s of %s due to their preferences\n", pullRequest.Author.Name, activity.Name)
+			case prefs.PrefersMention():
+				mentionID = id
+				channelOptions = append([]slack.MsgOption{slack.MsgOptionText(mentionUser(id), false)}, options...)
+			default:
+				directMessageID = id
+			}
+		}
+	}
+
+	mentioned := false
+	if channel != "" {
+		mentioned, err = o.postMessage(channel, false, messageType, messageActivity, all, channelOptions, createIfMissing)
 		if err != nil {
 			return errors.Wrap(err, fmt.Sprintf("error posting cfg for %s to channel %s", activity.Name,
 				channel))
 		}
-		log.Logger().Infof("Channel message sent to %s\n", cfg.Channel)
-	}
-	if cfg.DirectMessage.ToBool() {
-		if pullRequest != nil {
-			id, err := o.resolveGitUserToSlackUser(&pullRequest.Author, resolver)
+		log.Logger().Infof("Channel message sent to %s\n", channel)
+		if messageType == pipelineMessageType {
+			err = o.postTestFailuresThread(context.TODO(), activity, channel)
 			if err != nil {
-				return errors.Wrapf(err, "Cannot resolve Slack ID for Git user %s", pullRequest.Author.Name)
-			}
-			if id != "" {
-				err = o.postMessage(id, true, pipelineMessageType, activity, nil, options, createIfMissing)
-				if err != nil {
-					return errors.Wrap(err, fmt.Sprintf("error sending direct pipeline for %s to %s", activity.Name,
-						id))
-				}
-				log.Logger().Infof("Direct message sent to %s\n", pullRequest.Author.Name)
+				log.Logger().Warnf("failed to post the failing tests of %s: %s", activity.Name, err.Error())
 			}
 		}
 	}
+	if resolveErr != nil {
+		return resolveErr
+	}
+	if mentionID != "" && !mentioned {
+		// lets fall back to a direct message as there is no channel message to mention the author in
+		directMessageID = mentionID
+	}
+	if directMessageID != "" {
+		_, err = o.postMessage(directMessageID, true, messageType, messageActivity, all, options, createIfMissing)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("error sending direct pipeline for %s to %s", activity.Name,
+				directMessageID))
+		}
+		log.Logger().Infof("Direct message sent to %s\n", pullRequest.Author.Name)
+	}
 	return nil
 }
 
@@ -84,7 +141,11 @@ func (o *Options) getSlackConfigForPipeline(activity *jenkinsv1.PipelineActivity
 	owner := ps.GitOwner
 	repoName := ps.GitRepository
 
-	repoConfig := sourceconfigs.GetOrCreateRepositoryFor(o.SourceConfigs, gitServer, owner, repoName)
+	sourceConfigs := o.sourceConfigsFor(activity.Namespace)
+	if sourceConfigs == nil {
+		return nil
+	}
+	repoConfig := sourceconfigs.GetOrCreateRepositoryFor(sourceConfigs, gitServer, owner, repoName)
 	return repoConfig.Slack
 }
 
@@ -100,12 +161,26 @@ func (o *Options) ReviewRequestMessage(activity *jenkinsv1.PipelineActivity) err
 	if prn <= 0 {
 		return nil
 	}
-	ctx := context.TODO()
 	cfg := o.getSlackConfigForPipeline(activity)
 	if cfg == nil || cfg.Channel == "" {
 		return nil
 	}
+	connection, channel := SplitChannel(cfg.Channel)
+	if o.Notifiers[connection] != nil {
+		log.Logger().Debugf("review requests are only sent to slack so ignoring connection %s for %s", connection, activity.Name)
+		return nil
+	}
+	bot, err := o.forConnection(connection)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the slack connection for %s", activity.Name)
+	}
+	return bot.reviewRequestMessage(activity, cfg, channelName(channel))
+}
 
+// reviewRequestMessage sends the review request message to the channel and reviewers using the slack connection
+// of the options
+func (o *Options) reviewRequestMessage(activity *jenkinsv1.PipelineActivity, cfg *v1alpha1.SlackNotify, channel string) error {
+	ctx := context.TODO()
 	enabled, pullRequest, resolver, err := o.NotifyPipeline(activity, cfg)
 	if err != nil {
 		return errors.WithStack(err)
@@ -119,22 +194,7 @@ func (o *Options) ReviewRequestMessage(activity *jenkinsv1.PipelineActivity) err
 	if err != nil {
 		return err
 	}
-	buildNumber, err := strconv.Atoi(CreatePipelineDetails(activity).Build)
-	if err != nil {
-		return err
-	}
-	latestBuildNumber := -1
-	if latestActivity != nil {
-		// TODO Some activities could be missing the labels that identify them properly,
-		// in that case just display what we have?
-		latestBuildNumber, err = strconv.Atoi(CreatePipelineDetails(latestActivity).Build)
-	}
-	if oldestActivity == nil {
-		// TODO Some activities could be missing the labels that identify them so what do we do?
-		// We at least try to not error
-		oldestActivity = activity
-	}
-	if buildNumber >= latestBuildNumber {
+	if latestActivity == nil || !isEarlierBuild(activity, latestActivity) {
 		attachments, reviewers, buildStatus, err := o.createReviewersMessage(activity, cfg.NotifyReviewers.ToBool(),
 			pullRequest, resolver)
 		if err != nil {
@@ -148,9 +208,9 @@ func (o *Options) ReviewRequestMessage(activity *jenkinsv1.PipelineActivity) err
 			options := []slack.MsgOption{
 				slack.MsgOptionAttachments(attachments...),
 			}
-			if cfg.Channel != "" {
-				channel := channelName(cfg.Channel)
-				err := o.postMessage(channel, false, pullRequestReviewMessageType, oldestActivity,
+			posted := false
+			if channel != "" {
+				posted, err = o.postMessage(channel, false, pullRequestReviewMessageType, oldestActivity,
 					all, options, createIfMissing)
 				if err != nil {
 					return errors.Wrap(err, fmt.Sprintf("error posting PR review request for %s to channel %s",
@@ -160,22 +220,30 @@ func (o *Options) ReviewRequestMessage(activity *jenkinsv1.PipelineActivity) err
 			}
 			if cfg.DirectMessage.ToBool() && cfg.NotifyReviewers.ToBool() {
 				for _, user := range reviewers {
-					if user != nil {
-						err = o.postMessage(user.ID, true, pullRequestReviewMessageType, oldestActivity,
-							all, options, createIfMissing)
-						if err != nil {
-							return errors.Wrap(err, fmt.Sprintf("error sending direct PR review request for %s to %s",
-								activity.Name,
-								user.ID))
-						}
+					if user == nil {
+						continue
+					}
+					prefs := o.userPreferences(user.ID)
+					if !prefs.AllowsReview(activity, time.Now()) || (posted && prefs.PrefersMention()) {
+						// the reviewer is already mentioned in the channel message
+						log.Logger().Infof("Not sending direct review request for %s to %s due to their preferences\n",
+							activity.Name, user.ID)
+						continue
+					}
+					_, err = o.postMessage(user.ID, true, pullRequestReviewMessageType, oldestActivity,
+						all, options, createIfMissing)
+					if err != nil {
+						return errors.Wrap(err, fmt.Sprintf("error sending direct PR review request for %s to %s",
+							activity.Name,
+							user.ID))
 					}
 				}
 
 			}
 		}
 	} else {
-		log.Logger().Infof("Skipping %v as it is older than latest build number %d\n", activity.Name,
-			latestBuildNumber)
+		log.Logger().Infof("Skipping %v as it is older than the latest build %s\n", activity.Name,
+			latestActivity.Name)
 	}
 	return nil
 }
@@ -202,10 +270,10 @@ func (o *Options) isLgtmRepo(_ *jenkinsv1.PipelineActivity) (bool, error) {
 	return false, nil
 }
 
+// findPipelineActivities returns the first created PipelineActivity of the pull request across all of its contexts,
+// which the review message is stored against, along with the latest build of the same context as the activity and all the
+// activities of the pull request
 func (o *Options) findPipelineActivities(ctx context.Context, activity *jenkinsv1.PipelineActivity) (oldest *jenkinsv1.PipelineActivity, latest *jenkinsv1.PipelineActivity, all []jenkinsv1.PipelineActivity, err error) {
-	// This is the trigger activity. Working out the correct slack message is a bit tricky,
-	// as we have a 1:n mapping between PRs and PipelineActivities (which store the message info).
-	// The algorithm in use just picks the earliest pipeline activity as determined by build number
 	prn, _, err := getPullRequestNumber(activity)
 	if err != nil {
 		return nil, nil, nil, err
@@ -213,18 +281,42 @@ func (o *Options) findPipelineActivities(ctx context.Context, activity *jenkinsv
 
 	pipelineDetails := CreatePipelineDetails(activity)
 
-	acts, err := o.getPipelineActivities(ctx, pipelineDetails.GitOwner, pipelineDetails.GitRepository, prn)
-
+	acts, err := o.getPipelineActivities(ctx, o.activityNamespace(activity), pipelineDetails.GitOwner, pipelineDetails.GitRepository, prn)
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	if len(acts.Items) > 0 {
-		sort.Sort(byBuildNumber(acts.Items))
-		return &acts.Items[0], &acts.Items[len(acts.Items)-1], acts.Items, nil
-	} else {
-		log.Logger().Warnf("No pipeline activities exist for %s/%s/pr-%d", pipelineDetails.GitOwner, pipelineDetails.GitRepository, prn)
+	all = acts.Items
+	found := false
+	for i := range all {
+		if all[i].Name == activity.Name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		// the activity could be missing the labels that identify it so lets include it in the group anyway
+		log.Logger().Debugf("PipelineActivity %s was not found via the labels of %s/%s/pr-%d", activity.Name, pipelineDetails.GitOwner, pipelineDetails.GitRepository, prn)
+		all = append(all, *activity)
+	}
+	sort.Sort(byBuildNumber(all))
+	return oldestActivity(all), latestContextActivity(activity, all), all, nil
+}
+
+// latestContextActivity returns the latest build of the same context as the activity. The build numbers of each
+// context of a pull request are independent so they cannot be compared with each other
+func latestContextActivity(activity *jenkinsv1.PipelineActivity, all []jenkinsv1.PipelineActivity) *jenkinsv1.PipelineActivity {
+	pipelineContext := CreatePipelineDetails(activity).Context
+	var answer *jenkinsv1.PipelineActivity
+	for i := range all {
+		a := &all[i]
+		if CreatePipelineDetails(a).Context != pipelineContext {
+			continue
+		}
+		if answer == nil || isEarlierBuild(answer, a) {
+			answer = a
+		}
 	}
-	return nil, nil, nil, nil
+	return answer
 }
 
 func getStatus(overrideStatus *Status, defaultStatus *Status) *Status {
@@ -276,53 +368,11 @@ func (o *Options) createReviewersMessage(activity *jenkinsv1.PipelineActivity, n
 		}
 	}
 
-	// The default state is not approved
-	reviewStatus := getStatus(o.Statuses.NotApproved, defaultStatuses.NotApproved)
-
-	// A bit of a hacky way to do this,
-	// but until we get a better CRD based interface to the prow this will work
-	lgtmRepo, err := o.isLgtmRepo(activity)
+	reviewStatus, err := o.reviewStatus(activity, pr)
 	if err != nil {
-		return nil, nil, nil, errors.Wrapf(err, "checking if repo for %s is configured for lgtm", activity.Name)
-	}
-	if lgtmRepo {
-		if containsOneOf(pr.Labels, "lgtm") {
-			reviewStatus = getStatus(o.Statuses.LGTM, defaultStatuses.LGTM)
-		}
-	} else {
-		if containsOneOf(pr.Labels, "approved") {
-			reviewStatus = getStatus(o.Statuses.Approved, defaultStatuses.Approved)
-		}
-	}
-	if containsOneOf(pr.Labels, "do-not-merge/hold") {
-		reviewStatus = getStatus(o.Statuses.Hold, defaultStatuses.Hold)
-	}
-	if containsOneOf(pr.Labels, "needs-ok-to-test") {
-		reviewStatus = getStatus(o.Statuses.NeedsOkToTest, defaultStatuses.NeedsOkToTest)
-	}
-
-	// The default build state is unknown
-	buildStatus := getStatus(o.Statuses.Unknown, defaultStatuses.Unknown)
-	if pr.Merged {
-		buildStatus = getStatus(o.Statuses.Merged, defaultStatuses.Merged)
-	} else if pr.Closed {
-		buildStatus = getStatus(o.Statuses.Closed, defaultStatuses.Closed)
-	} else {
-		switch activity.Spec.Status {
-		case jenkinsv1.ActivityStatusTypePending:
-			buildStatus = getStatus(o.Statuses.Pending, defaultStatuses.Pending)
-		case jenkinsv1.ActivityStatusTypeRunning:
-			buildStatus = getStatus(o.Statuses.Running, defaultStatuses.Running)
-		case jenkinsv1.ActivityStatusTypeSucceeded:
-			buildStatus = getStatus(o.Statuses.Succeeded, defaultStatuses.Succeeded)
-		case jenkinsv1.ActivityStatusTypeFailed:
-			buildStatus = getStatus(o.Statuses.Failed, defaultStatuses.Failed)
-		case jenkinsv1.ActivityStatusTypeError:
-			buildStatus = getStatus(o.Statuses.Errored, defaultStatuses.Errored)
-		case jenkinsv1.ActivityStatusTypeAborted:
-			buildStatus = getStatus(o.Statuses.Aborted, defaultStatuses.Aborted)
-		}
+		return nil, nil, nil, err
 	}
+	buildStatus := o.buildStatus(activity, pr)
 
 	mentionsString := strings.Join(mentions, " ")
 	pleaseText := "please"
@@ -358,11 +408,93 @@ func (o *Options) createReviewersMessage(activity *jenkinsv1.PipelineActivity, n
 		attachment.Ts = json.Number(strconv.FormatInt(updatedEpochTime, 10))
 	}
 
+	if approvals := o.pullRequestApprovals(context.TODO(), activity, pr); approvals != nil {
+		if text := approvals.Describe(); text != "" {
+			attachment.Fields = append(attachment.Fields, slack.AttachmentField{
+				Value: text,
+			})
+		}
+	}
+
 	attachments = append(attachments, attachment)
 
 	return attachments, reviewers, buildStatus, nil
 }
 
+// reviewStatus returns the review status of the pull request which is inferred from its labels
+func (o *Options) reviewStatus(activity *jenkinsv1.PipelineActivity, pr *scm.PullRequest) (*Status, error) {
+	// The default state is not approved
+	reviewStatus := getStatus(o.Statuses.NotApproved, defaultStatuses.NotApproved)
+
+	// A bit of a hacky way to do this,
+	// but until we get a better CRD based interface to the prow this will work
+	lgtmRepo, err := o.isLgtmRepo(activity)
+	if err != nil {
+		return nil, errors.Wrapf(err, "checking if repo for %s is configured for lgtm", pr.Link)
+	}
+	if lgtmRepo {
+		if containsOneOf(pr.Labels, "lgtm") {
+			reviewStatus = getStatus(o.Statuses.LGTM, defaultStatuses.LGTM)
+		}
+	} else {
+		if containsOneOf(pr.Labels, "approved") {
+			reviewStatus = getStatus(o.Statuses.Approved, defaultStatuses.Approved)
+		}
+	}
+	if containsOneOf(pr.Labels, "do-not-merge/hold") {
+		reviewStatus = getStatus(o.Statuses.Hold, defaultStatuses.Hold)
+	}
+	if containsOneOf(pr.Labels, "needs-ok-to-test") {
+		reviewStatus = getStatus(o.Statuses.NeedsOkToTest, defaultStatuses.NeedsOkToTest)
+	}
+	return reviewStatus, nil
+}
+
+// buildStatus returns the status of the pull request or the build of it if it is still open
+func (o *Options) buildStatus(activity *jenkinsv1.PipelineActivity, pr *scm.PullRequest) *Status {
+	// The default build state is unknown
+	buildStatus := getStatus(o.Statuses.Unknown, defaultStatuses.Unknown)
+	if pr.Merged {
+		return getStatus(o.Statuses.Merged, defaultStatuses.Merged)
+	}
+	if pr.Closed {
+		return getStatus(o.Statuses.Closed, defaultStatuses.Closed)
+	}
+	if activity == nil {
+		return buildStatus
+	}
+	switch activity.Spec.Status {
+	case jenkinsv1.ActivityStatusTypePending:
+		buildStatus = getStatus(o.Statuses.Pending, defaultStatuses.Pending)
+	case jenkinsv1.ActivityStatusTypeRunning:
+		buildStatus = getStatus(o.Statuses.Running, defaultStatuses.Running)
+	case jenkinsv1.ActivityStatusTypeSucceeded:
+		buildStatus = getStatus(o.Statuses.Succeeded, defaultStatuses.Succeeded)
+	case jenkinsv1.ActivityStatusTypeFailed:
+		buildStatus = getStatus(o.Statuses.Failed, defaultStatuses.Failed)
+	case jenkinsv1.ActivityStatusTypeError:
+		buildStatus = getStatus(o.Statuses.Errored, defaultStatuses.Errored)
+	case jenkinsv1.ActivityStatusTypeAborted:
+		buildStatus = getStatus(o.Statuses.Aborted, defaultStatuses.Aborted)
+	}
+	return buildStatus
+}
+
+// withinAgeCutoff returns true if any of the activities has been updated in the last day so that a message should be
+// created for them if there is none yet
+func (o *Options) withinAgeCutoff(activities ...*jenkinsv1.PipelineActivity) bool {
+	if o.IgnoreAgeCutoff {
+		return true
+	}
+	dayAgo := time.Now().Add((-24) * time.Hour).Unix()
+	for _, a := range activities {
+		if getLastUpdatedTime(nil, a) >= dayAgo {
+			return true
+		}
+	}
+	return false
+}
+
 func getLastUpdatedTime(pr *scm.PullRequest, activity *jenkinsv1.PipelineActivity) int64 {
 	updatedEpochTime := int64(-1)
 	if pr != nil {
@@ -396,7 +528,7 @@ func containsOneOf(a []*scm.Label, x ...string) bool {
 	return false
 }
 
-func (o *Options) createPipelineMessage(activity *jenkinsv1.PipelineActivity, pr *scm.PullRequest) ([]slack.MsgOption, bool, error) {
+func (o *Options) createPipelineMessage(activity *jenkinsv1.PipelineActivity, pr *scm.PullRequest, label string) ([]slack.MsgOption, bool, error) {
 	format := &o.MessageFormat
 	spec := &activity.Spec
 	status := pipelineStatus(activity)
@@ -412,31 +544,29 @@ func (o *Options) createPipelineMessage(activity *jenkinsv1.PipelineActivity, pr
 		messageText = fmt.Sprintf("%s : PR %s", messageText, link(pullRequestName(pr.Link), pr.Link))
 	}
 
-	buildURL := spec.BuildURL
-	if format.DashboardURL != "" {
-		owner := spec.GitOwner
-		repoName := spec.GitRepository
-		branch := spec.GitBranch
-		build := spec.Build
-		if owner != "" && repoName != "" && branch != "" && build != "" {
-			buildURL = stringhelpers.UrlJoin(format.DashboardURL, owner, repoName, branch, build)
-		}
-	}
-
+	buildURL := o.pipelineBuildURL(activity)
 	buildNumber := link("#"+spec.Build, buildURL)
 	pipelineCtx := spec.Context
 	if pipelineCtx == "" {
 		pipelineCtx = "Build"
 	}
 	messageText = fmt.Sprintf("%s (%s %s)", messageText, pipelineCtx, buildNumber)
+	comparison := o.compareDuration(context.TODO(), activity)
+	if duration, ok := activityDuration(activity); ok {
+		messageText += " in " + formatDuration(duration)
+		if comparison != nil {
+			messageText += " (median " + formatDuration(comparison.Median) + ")"
+		}
+	}
+	if failure := o.describeFailure(context.TODO(), activity); failure != "" {
+		messageText += " " + failure
+	}
+	if label != "" {
+		messageText = label + ": " + messageText
+	}
 
 	// lets ignore old pipelines
-	dayAgo := time.Now().Add((-24) * time.Hour).Unix()
-	createIfMissing := true
-	lastUpdatedTime := getLastUpdatedTime(nil, activity)
-	if lastUpdatedTime < dayAgo {
-		createIfMissing = false
-	}
+	createIfMissing := o.withinAgeCutoff(activity)
 
 	var attachments []slack.Attachment
 	var actions []slack.AttachmentAction
@@ -485,12 +615,34 @@ func (o *Options) createPipelineMessage(activity *jenkinsv1.PipelineActivity, pr
 		Actions:    actions,
 	}
 
-	if lastUpdatedTime > 0 {
+	if lastUpdatedTime := getLastUpdatedTime(nil, activity); lastUpdatedTime > 0 {
 		attachment.Ts = json.Number(strconv.FormatInt(lastUpdatedTime, 10))
 	}
 
 	attachments = append(attachments, attachment)
 
+	if warning := stuckWarning(activity, time.Now()); warning != "" {
+		attachments = append(attachments, slack.Attachment{
+			Color: "warning",
+			Text:  warning,
+		})
+	}
+
+	if comparison != nil && comparison.Regression {
+		attachments = append(attachments, slack.Attachment{
+			Color: "warning",
+			Text:  ":snail: this pipeline was " + comparison.Describe(),
+		})
+	}
+
+	if changelog := o.createChangelogAttachment(context.TODO(), activity); changelog != nil {
+		attachments = append(attachments, *changelog)
+	}
+
+	if summary := o.testSummary(context.TODO(), activity); summary != nil {
+		attachments = append(attachments, o.createTestsAttachment(summary))
+	}
+
 	if format.ShowSteps {
 		for _, step := range spec.Steps {
 			stepAttachments := o.createAttachments(activity, &step)
@@ -505,6 +657,22 @@ func (o *Options) createPipelineMessage(activity *jenkinsv1.PipelineActivity, pr
 	return options, createIfMissing, nil
 }
 
+// pipelineBuildURL returns the URL of the pipeline in the dashboard if there is one or the build URL
+func (o *Options) pipelineBuildURL(activity *jenkinsv1.PipelineActivity) string {
+	spec := &activity.Spec
+	buildURL := spec.BuildURL
+	if o.MessageFormat.DashboardURL != "" {
+		owner := spec.GitOwner
+		repoName := spec.GitRepository
+		branch := spec.GitBranch
+		build := spec.Build
+		if owner != "" && repoName != "" && branch != "" && build != "" {
+			buildURL = stringhelpers.UrlJoin(o.MessageFormat.DashboardURL, owner, repoName, branch, build)
+		}
+	}
+	return buildURL
+}
+
 func (o *Options) getSlackUserID(gitUser *scm.User, resolver *users.GitUserResolver) (string, error) {
 	if gitUser == nil {
 		return "", fmt.Errorf("user cannot be nil")
@@ -527,16 +695,23 @@ func getPullRequestNumber(activity *jenkinsv1.PipelineActivity) (int, *PipelineD
 	return 0, pipelineDetails, nil
 }
 
+// postMessage creates or updates the message of the activity returning true if the message was sent. No message is
+// sent if there is no existing message to update and createIfMissing is false
 func (o *Options) postMessage(channel string, directMessage bool, messageType string,
 	activity *jenkinsv1.PipelineActivity, all []jenkinsv1.PipelineActivity, options []slack.MsgOption,
-	createIfMissing bool) error {
+	createIfMissing bool) (bool, error) {
 	timestamp := o.FakeTimestamp
-	messageRef := o.findMessageRefViaAnnotations(activity, channel, messageType)
+	var messageRef *MessageReference
 	channelId := channel
+	timestampChannel := connectionChannel(o.SlackUserResolver.Connection, channel)
 
+	if !o.ForceNewMessages {
+		messageRef = o.findMessageRefViaAnnotations(activity, channel, messageType)
+	}
 	if messageRef == nil {
-		// couldn't find the message ref on a Pipeline Activity so attempt to find the message ref in memory
-		messageRef = o.Timestamps[channel][activity.Name]
+		// couldn't find the message ref on a Pipeline Activity so attempt to find the message ref in memory. When
+		// forcing new messages this still finds the messages created earlier in the same run
+		messageRef = o.Timestamps[timestampChannel][timestampKey(activity, messageType)]
 	}
 	if messageRef != nil {
 		timestamp = messageRef.Timestamp
@@ -546,8 +721,8 @@ func (o *Options) postMessage(channel string, directMessage bool, messageType st
 	if o.Timestamps == nil {
 		o.Timestamps = map[string]map[string]*MessageReference{}
 	}
-	if _, ok := o.Timestamps[channel]; !ok {
-		o.Timestamps[channel] = make(map[string]*MessageReference, 0)
+	if _, ok := o.Timestamps[timestampChannel]; !ok {
+		o.Timestamps[timestampChannel] = make(map[string]*MessageReference, 0)
 	}
 
 	if directMessage {
@@ -557,7 +732,7 @@ func (o *Options) postMessage(channel string, directMessage bool, messageType st
 			},
 		})
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("(open converation channelId: %s)", channelId))
+			return false, errors.Wrap(err, fmt.Sprintf("(open converation channelId: %s)", channelId))
 		}
 		channelId = channel.ID
 	}
@@ -579,19 +754,19 @@ func (o *Options) postMessage(channel string, directMessage bool, messageType st
 
 		channelId, timestamp, _, err := o.SlackClient.SendMessage(channelId, options...)
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("(post channelId: %s, timestamp: %s)", channelId, timestamp))
+			return false, errors.Wrap(err, fmt.Sprintf("(post channelId: %s, timestamp: %s)", channelId, timestamp))
 		}
-		o.Timestamps[channel][activity.Name] = &MessageReference{
+		o.Timestamps[timestampChannel][timestampKey(activity, messageType)] = &MessageReference{
 			ChannelID: channelId,
 			Timestamp: timestamp,
 		}
-		key := annotationKey(channel, messageType)
+		key := o.annotationKey(channel, messageType)
 		value := annotationValue(channelId, timestamp)
 		if all == nil {
 			if activity.Annotations[key] != value {
 				err = o.annotatePipelineActivity(ctx, activity, key, value)
 				if err != nil {
-					return err
+					return true, err
 				}
 			}
 		} else {
@@ -599,13 +774,31 @@ func (o *Options) postMessage(channel string, directMessage bool, messageType st
 				if a.Annotations[key] != value {
 					err = o.annotatePipelineActivity(ctx, &a, key, value)
 					if err != nil {
-						return err
+						return true, err
 					}
 				}
 			}
 		}
 	}
-	return nil
+	return post, nil
+}
+
+// timestampKey returns the key of the in memory message reference of the activity. Checklist messages are stored
+// against an activity which also has its own pipeline or review message so they use a separate key
+func timestampKey(activity *jenkinsv1.PipelineActivity, messageType string) string {
+	if messageType == checklistMessageType {
+		return checklistMessageType + ":" + activity.Name
+	}
+	return activity.Name
+}
+
+// findMessageRef returns the reference of the message of the given type of the activity in the channel from memory,
+// which has the messages created in this run even when forcing new messages, or from the annotations of the activity
+func (o *Options) findMessageRef(activity *jenkinsv1.PipelineActivity, channel string, messageType string) *MessageReference {
+	if messageRef := o.Timestamps[connectionChannel(o.SlackUserResolver.Connection, channel)][timestampKey(activity, messageType)]; messageRef != nil {
+		return messageRef
+	}
+	return o.findMessageRefViaAnnotations(activity, channel, messageType)
 }
 
 //getPullRequest will return the PullRequestInfo for the activity, or nil if it's not a pull request
@@ -633,7 +826,7 @@ func (o *Options) findMessageRefViaAnnotations(activity *jenkinsv1.PipelineActiv
 	channel string, messageType string) *MessageReference {
 	annotations := activity.Annotations
 	if annotations != nil {
-		key := annotationKey(channel, messageType)
+		key := o.annotationKey(channel, messageType)
 		value := annotations[key]
 		if value != "" {
 			values := strings.SplitN(value, "/", 2)
@@ -647,8 +840,25 @@ func (o *Options) findMessageRefViaAnnotations(activity *jenkinsv1.PipelineActiv
 	return nil
 }
 
+// annotationKey returns the key of the annotation of the message of the given type in the channel of the slack
+// connection of the options
+func (o *Options) annotationKey(channel string, messageType string) string {
+	return connectionAnnotationKey(o.SlackUserResolver.Connection, channel, messageType)
+}
+
 func annotationKey(channel string, messageType string) string {
-	return fmt.Sprintf("%s-%s/%s", SlackAnnotationPrefix, messageType, strings.TrimPrefix(channel, "#"))
+	return connectionAnnotationKey("", channel, messageType)
+}
+
+// connectionAnnotationKey returns the key of the annotation of the message of the given type in the channel. Channels
+// of connections other than the default are prefixed with the connection name so that channels with the same name in
+// different workspaces have their own messages
+func connectionAnnotationKey(connection string, channel string, messageType string) string {
+	name := strings.TrimPrefix(channel, "#")
+	if connection != "" {
+		name = connection + "." + name
+	}
+	return fmt.Sprintf("%s-%s/%s", SlackAnnotationPrefix, messageType, name)
 }
 
 func annotationValue(channelId string, timestamp string) string {
@@ -657,43 +867,37 @@ func annotationValue(channelId string, timestamp string) string {
 
 func (o *Options) createAttachments(activity *jenkinsv1.PipelineActivity,
 	step *jenkinsv1.PipelineActivityStep) []slack.Attachment {
-	stage := step.Stage
-	promote := step.Promote
-	if stage != nil {
-		return o.createStageAttachments(activity, stage)
-	} else if promote != nil {
-		return o.createPromoteAttachments(activity, promote)
+	attachments := []slack.Attachment{}
+	steps := pipelineSteps(step)
+	for i := range steps {
+		attachments = append(attachments, o.createPipelineStepAttachment(activity, &steps[i]))
 	}
-	return []slack.Attachment{}
-
+	return attachments
 }
 
-func (o *Options) createStageAttachments(activity *jenkinsv1.PipelineActivity,
-	stage *jenkinsv1.StageActivityStep) []slack.Attachment {
-	name := stage.Name
-	if name == "" {
-		name = "Stage"
-	}
-	version := activity.Spec.Version
-	if name == "Release" {
-		if version != "" {
+// createPipelineStepAttachment renders a stage, step or part of a promotion as an attachment
+func (o *Options) createPipelineStepAttachment(activity *jenkinsv1.PipelineActivity, s *pipelineStep) slack.Attachment {
+	switch s.kind {
+	case stageStep:
+		name := s.stageName()
+		version := activity.Spec.Version
+		if name == "Release" && version != "" {
 			name = "release " + link(version, activity.Spec.ReleaseNotesURL)
 		}
+		return o.createStepAttachment(s.step, name, "", "")
+	case promoteStep:
+		return o.createStepAttachment(s.step, "promote to *"+strings.Title(s.promote.Environment)+"*", "", "")
+	case promotePullRequestStep:
+		pullRequest := s.promote.PullRequest
+		return o.createStepAttachment(s.step, "PR", describePromotePullRequest(activity, pullRequest), pullRequestIcon(pullRequest))
+	case promoteUpdateStep:
+		return o.createStepAttachment(s.step, "update", describePromoteUpdate(s.promote.Update), "")
+	case promoteApplicationStep:
+		envName := strings.Title(s.promote.Environment)
+		return o.createStepAttachment(s.step, ":star: application now in "+link(envName, s.promote.ApplicationURL), "", "")
+	default:
+		return o.createStepAttachment(s.step, "", "", "")
 	}
-
-	attachments := []slack.Attachment{
-		o.createStepAttachment(stage.CoreActivityStep, name, "", ""),
-	}
-	if stage.CoreActivityStep.Name != "meta pipeline" {
-		for _, step := range stage.Steps {
-			// filter out tekton generated steps
-			if isUserPipelineStep(step.Name) {
-				attachments = append(attachments, o.createStepAttachment(step, "", "", ""))
-			}
-		}
-	}
-
-	return attachments
 }
 
 func isUserPipelineStep(name string) bool {
@@ -731,6 +935,9 @@ func (o *Options) createStepAttachment(step jenkinsv1.CoreActivityStep, name str
 	if text != "" {
 		textMessage += " " + text
 	}
+	if duration, ok := stepDuration(&step); ok {
+		textMessage += " (" + formatDuration(duration) + ")"
+	}
 
 	return slack.Attachment{
 		Text:       textMessage,
@@ -740,43 +947,25 @@ func (o *Options) createStepAttachment(step jenkinsv1.CoreActivityStep, name str
 	}
 }
 
-func (o *Options) createPromoteAttachments(activity *jenkinsv1.PipelineActivity, parent *jenkinsv1.PromoteActivityStep) []slack.Attachment {
-	envName := strings.Title(parent.Environment)
-	attachments := []slack.Attachment{
-		o.createStepAttachment(parent.CoreActivityStep, "promote to *"+envName+"*", "", ""),
-	}
-
-	pullRequest := parent.PullRequest
-	update := parent.Update
-	if pullRequest != nil {
-		iconUrl := pullRequestIcon(pullRequest)
-		attachments = append(attachments, o.createStepAttachment(pullRequest.CoreActivityStep, "PR", describePromotePullRequest(activity, pullRequest), iconUrl))
-	}
-	if update != nil {
-		attachments = append(attachments, o.createStepAttachment(update.CoreActivityStep, "update", describePromoteUpdate(update), ""))
-	}
-	appURL := parent.ApplicationURL
-	if appURL != "" {
-		attachments = append(attachments, o.createStepAttachment(update.CoreActivityStep, ":star: application now in "+link(envName, appURL), "", ""))
-	}
-	return attachments
-}
-
+// annotatePipelineActivity adds the annotation using a merge patch so that the annotations added by other patches
+// since the activity was read are kept
 func (o *Options) annotatePipelineActivity(ctx context.Context, activity *jenkinsv1.PipelineActivity, key string, value string) error {
-	newActivity := activity.DeepCopy()
-	if newActivity.Annotations == nil {
-		newActivity.Annotations = make(map[string]string)
+	if o.DryRun {
+		log.Logger().Infof("dry run: would annotate %s with %s=%s", activity.Name, key, value)
+		return nil
 	}
-	newActivity.Annotations[key] = value
-	patch, err := CreatePatch(activity, newActivity)
-	if err != nil {
-		return errors.Wrapf(err, "creating patch to add annotation %s=%s to %s", key, value, activity.Name)
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				key: value,
+			},
+		},
 	}
 	jsonPatch, err := json.Marshal(patch)
 	if err != nil {
 		return errors.Wrapf(err, "marshaling patch to add annotation %s=%s to %s", key, value, activity.Name)
 	}
-	_, err = o.JXClient.JenkinsV1().PipelineActivities(o.Namespace).Patch(ctx, activity.Name, types.JSONPatchType,
+	_, err = o.JXClient.JenkinsV1().PipelineActivities(o.activityNamespace(activity)).Patch(ctx, activity.Name, types.MergePatchType,
 		jsonPatch, metav1.PatchOptions{})
 	return err
 }
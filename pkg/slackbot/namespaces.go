@@ -0,0 +1,131 @@
+package slackbot
+
+import (
+	"sync"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/jenkins-x/jx-gitops/pkg/sourceconfigs"
+	"github.com/jenkins-x/jx-gitops/pkg/variablefinders"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/requirements"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// namespaceSourceConfigs caches the SourceConfig of the development environment of each watched namespace along
+// with the watchdog thresholds of its repositories
+type namespaceSourceConfigs struct {
+	lock      sync.Mutex
+	configs   map[string]*v1alpha1.SourceConfig
+	watchdogs map[string]map[string]*WatchdogThresholds
+}
+
+// watchNamespaces returns the namespaces to watch for PipelineActivities
+func (o *Options) watchNamespaces() []string {
+	if o.WatchAllNamespaces {
+		return []string{metav1.NamespaceAll}
+	}
+	if len(o.WatchNamespaces) == 0 {
+		return []string{o.Namespace}
+	}
+	return o.WatchNamespaces
+}
+
+// activityNamespace returns the namespace of the activity defaulting to the current namespace
+func (o *Options) activityNamespace(activity *jenkinsv1.PipelineActivity) string {
+	if activity.Namespace != "" {
+		return activity.Namespace
+	}
+	return o.Namespace
+}
+
+// sourceConfigsFor returns the SourceConfig for the given namespace. Other namespaces than the current namespace
+// lazily load the SourceConfig from the git repository of their development environment
+func (o *Options) sourceConfigsFor(ns string) *v1alpha1.SourceConfig {
+	if ns == "" || ns == o.Namespace || o.namespaceSources == nil {
+		return o.SourceConfigs
+	}
+	s := o.namespaceSources
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.configs == nil {
+		s.configs = map[string]*v1alpha1.SourceConfig{}
+	}
+	answer, ok := s.configs[ns]
+	if ok {
+		return answer
+	}
+	answer, watchdogs, err := o.loadNamespaceSourceConfig(ns)
+	if err != nil {
+		log.Logger().Warnf("ignoring PipelineActivities in namespace %s as failed to load its SourceConfig: %s", ns, err.Error())
+	}
+	// lets cache failures too so that we don't clone on every event
+	s.configs[ns] = answer
+	if s.watchdogs == nil {
+		s.watchdogs = map[string]map[string]*WatchdogThresholds{}
+	}
+	s.watchdogs[ns] = watchdogs
+	return answer
+}
+
+// repositoryWatchdogsFor returns the watchdog thresholds of the repositories in the SourceConfig of the namespace
+func (o *Options) repositoryWatchdogsFor(ns string) map[string]*WatchdogThresholds {
+	if ns == "" || ns == o.Namespace || o.namespaceSources == nil {
+		return o.repositoryWatchdogs
+	}
+	// lets make sure the SourceConfig of the namespace has been loaded
+	o.sourceConfigsFor(ns)
+
+	s := o.namespaceSources
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.watchdogs[ns]
+}
+
+// loadNamespaceSourceConfig clones the development environment of the namespace and loads its SourceConfig and the
+// watchdog thresholds of its repositories
+func (o *Options) loadNamespaceSourceConfig(ns string) (*v1alpha1.SourceConfig, map[string]*WatchdogThresholds, error) {
+	if o.GitClient == nil {
+		o.GitClient = cli.NewCLIClient("", o.CommandRunner)
+	}
+	gitURL, err := o.devEnvironmentGitURL(ns)
+	if err != nil {
+		return nil, nil, err
+	}
+	dir, err := gitclient.CloneToDir(o.GitClient, gitURL, "")
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to clone git URL %s", gitURL)
+	}
+	log.Logger().Infof("loading the SourceConfig of namespace %s from %s", ns, gitURL)
+	answer, err := sourceconfigs.LoadSourceConfig(dir, true)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to load source configs from dir %s", dir)
+	}
+	watchdogs, err := LoadRepositoryWatchdogs(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	return answer, watchdogs, nil
+}
+
+// devEnvironmentGitURL returns the git URL of the development environment in the given namespace
+func (o *Options) devEnvironmentGitURL(ns string) (string, error) {
+	req, err := variablefinders.FindRequirements(o.GitClient, o.JXClient, ns, "", "", "")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load requirements from dev environment in namespace %s", ns)
+	}
+	if req == nil {
+		return "", errors.Errorf("no Requirements in TeamSettings of dev environment in namespace %s", ns)
+	}
+
+	// lets override the dev git URL if its changed in the requirements via the .jx/settings.yaml file
+	gitURL := requirements.EnvironmentGitURL(req, "dev")
+	if gitURL == "" {
+		return "", errors.Errorf("could not find development environment git URL from requirements in namespace %s", ns)
+	}
+	return gitURL, nil
+}
@@ -0,0 +1,133 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMultipleNamespaces(t *testing.T) {
+	owner := "myorg"
+	repo := "myrepo"
+
+	pa1 := testpipelines.CreateTestPipelineActivity("jx", owner, repo, "main", "release", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+	pa2 := testpipelines.CreateTestPipelineActivity("team2", owner, repo, "main", "release", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+	jxClient := fakejx.NewSimpleClientset(pa1, pa2)
+	scmClient, _ := fakescm.NewDefault()
+	fakeSlackClient := fakeslack.NewFakeSlack()
+
+	o := &Options{
+		KubeClient:    fake.NewSimpleClientset(),
+		JXClient:      jxClient,
+		ScmClient:     scmClient,
+		SlackClient:   fakeSlackClient,
+		SourceConfigs: createNamespaceSourceConfig(owner, repo, "#jx-builds"),
+		namespaceSources: &namespaceSourceConfigs{
+			configs: map[string]*v1alpha1.SourceConfig{
+				"team2": createNamespaceSourceConfig(owner, repo, "#team2-builds"),
+			},
+		},
+	}
+	o.Namespace = "jx"
+	o.WatchNamespaces = []string{"jx", "team2"}
+	assert.Equal(t, []string{"jx", "team2"}, o.watchNamespaces(), "watched namespaces")
+
+	for _, pa := range []*jenkinsv1.PipelineActivity{pa1, pa2} {
+		err := o.PipelineMessage(pa)
+		require.NoError(t, err, "failed to process pipeline %s in namespace %s", pa.Name, pa.Namespace)
+	}
+	assert.Len(t, fakeSlackClient.Messages["#jx-builds"], 1, "messages for namespace jx")
+	assert.Len(t, fakeSlackClient.Messages["#team2-builds"], 1, "messages for namespace team2")
+
+	updated, err := jxClient.JenkinsV1().PipelineActivities("team2").Get(context.TODO(), pa2.Name, metav1.GetOptions{})
+	require.NoError(t, err, "failed to find activity %s in namespace team2", pa2.Name)
+	assert.NotEmpty(t, updated.Annotations[annotationKey("#team2-builds", pipelineMessageType)], "annotation in namespace team2")
+
+	o.WatchAllNamespaces = true
+	assert.Equal(t, []string{metav1.NamespaceAll}, o.watchNamespaces(), "watched namespaces")
+}
+
+// TestWatchActivitiesInMultipleNamespaces processes events from the informers of two namespaces at the same time so
+// should be run with -race
+func TestWatchActivitiesInMultipleNamespaces(t *testing.T) {
+	owner := "myorg"
+	repo := "myrepo"
+
+	var activities []*jenkinsv1.PipelineActivity
+	for _, ns := range []string{"jx", "team2"} {
+		for _, build := range []string{"1", "2", "3"} {
+			activities = append(activities, testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", build, jenkinsv1.ActivityStatusTypeSucceeded))
+		}
+	}
+	jxClient := fakejx.NewSimpleClientset(activities[0], activities[1], activities[2], activities[3], activities[4], activities[5])
+	scmClient, _ := fakescm.NewDefault()
+	fakeSlackClient := fakeslack.NewFakeSlack()
+
+	o := &Options{
+		KubeClient:    fake.NewSimpleClientset(),
+		JXClient:      jxClient,
+		ScmClient:     scmClient,
+		SlackClient:   fakeSlackClient,
+		SourceConfigs: createNamespaceSourceConfig(owner, repo, "#jx-builds"),
+		namespaceSources: &namespaceSourceConfigs{
+			configs: map[string]*v1alpha1.SourceConfig{
+				"team2": createNamespaceSourceConfig(owner, repo, "#team2-builds"),
+			},
+		},
+	}
+	o.Namespace = "jx"
+	o.WatchNamespaces = []string{"jx", "team2"}
+
+	stopper := make(chan struct{})
+	defer close(stopper)
+	o.startHandlerQueue(stopper)
+	o.watchActivities(stopper)
+
+	// the slack client is only used on the handler queue so lets read its messages there too
+	messageCount := func(channel string) int {
+		answer := make(chan int, 1)
+		o.serialize(func() {
+			answer <- len(fakeSlackClient.Messages[channel])
+		})
+		return <-answer
+	}
+	for _, channel := range []string{"#jx-builds", "#team2-builds"} {
+		assert.Eventually(t, func() bool {
+			return messageCount(channel) > 0
+		}, 10*time.Second, 10*time.Millisecond, "messages for channel %s", channel)
+	}
+}
+
+func createNamespaceSourceConfig(owner, repo, channel string) *v1alpha1.SourceConfig {
+	return &v1alpha1.SourceConfig{
+		Spec: v1alpha1.SourceConfigSpec{
+			Groups: []v1alpha1.RepositoryGroup{
+				{
+					Provider: "https://fake.git",
+					Owner:    owner,
+					Repositories: []v1alpha1.Repository{
+						{
+							Name: repo,
+							Slack: &v1alpha1.SlackNotify{
+								Channel:  channel,
+								Kind:     v1alpha1.NotifyKindAlways,
+								Pipeline: v1alpha1.PipelineKindAll,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,214 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x/go-scm/scm"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// defaultWebhookNotify by default webhooks are notified of every status of every pipeline
+var defaultWebhookNotify = v1alpha1.SlackNotify{
+	Kind:     v1alpha1.NotifyKindAlways,
+	Pipeline: v1alpha1.PipelineKindAll,
+}
+
+// notifyPipeline sends the pipeline notification via a backend other than slack. Incoming webhooks cannot update
+// a previous message so we only notify once the pipeline has terminated and annotate the activity to avoid
+// sending the same notification again
+func (o *Options) notifyPipeline(notifier notifiers.Notifier, connection, channel string,
+	activity *jenkinsv1.PipelineActivity, cfg *v1alpha1.SlackNotify) error {
+	status := pipelineStatus(activity)
+	if !status.IsTerminated() {
+		return nil
+	}
+	key := annotationKey(connection, notificationMessageType)
+	if o.alreadyNotified(activity, key, string(status)) {
+		log.Logger().Debugf("already notified %s of %s with status %s", connection, activity.Name, string(status))
+		return nil
+	}
+
+	decision, err := o.notifyDecision(activity, cfg)
+	if err != nil {
+		return errors.Wrapf(errors.WithStack(err), "failed to verify if notification should be sent")
+	}
+	if decision == nil {
+		return nil
+	}
+	notification, err := o.createNotification(activity, decision.PullRequest)
+	if err != nil {
+		return err
+	}
+	if label := decision.ChangeLabel(); label != "" {
+		notification.Title = label + ": " + notification.Title
+	}
+
+	ctx := context.TODO()
+	err = notifier.Notify(ctx, channel, notification)
+	if err != nil {
+		return errors.Wrapf(err, "failed to notify %s of %s", connection, activity.Name)
+	}
+	log.Logger().Infof("Notification of %s sent to %s\n", activity.Name, connection)
+	return o.annotatePipelineActivity(ctx, activity, key, string(status))
+}
+
+// notifyWebhooks sends a CloudEvent to each webhook which matches the pipeline the first time it has a new status.
+// The webhooks are notified whether or not the pipeline has a slack channel. The events are queued once the webhook
+// queues are started so that a webhook which is down does not hold up the chat notifications. Failures are logged
+// rather than returned
+func (o *Options) notifyWebhooks(ctx context.Context, activity *jenkinsv1.PipelineActivity) {
+	if len(o.Webhooks) == 0 {
+		return
+	}
+	status := string(pipelineStatus(activity))
+	var notification *notifiers.Notification
+	for name, w := range o.Webhooks {
+		key := annotationKey(name, webhookMessageType)
+		if o.alreadyNotified(activity, key, status) {
+			continue
+		}
+		cfg := o.webhookConfig(name)
+		if len(cfg.Repositories) > 0 && !matchesRepository(cfg.Repositories, activity) {
+			continue
+		}
+		notify := cfg.Notify
+		if notify == nil {
+			notify = &defaultWebhookNotify
+		}
+		enabled, pullRequest, _, err := o.NotifyPipeline(activity, notify)
+		if err != nil {
+			log.Logger().Warnf("failed to verify if webhook %s should be notified of %s: %s", name, activity.Name, err.Error())
+			continue
+		}
+		if !enabled {
+			continue
+		}
+		if notification == nil {
+			notification, err = o.createNotification(activity, pullRequest)
+			if err != nil {
+				log.Logger().Warnf("failed to create notification for %s: %s", activity.Name, err.Error())
+				return
+			}
+		}
+		d := &webhookDelivery{
+			activity:     activity,
+			key:          key,
+			status:       status,
+			notification: notification,
+		}
+		if q := o.webhookQueues[name]; q != nil {
+			q.enqueue(d)
+			continue
+		}
+		o.deliverWebhook(ctx, &webhookQueue{name: name, webhook: w}, d)
+	}
+}
+
+// webhookConfig returns the configuration of the named webhook or an empty configuration if there is none
+func (o *Options) webhookConfig(name string) *Webhook {
+	if o.Config != nil {
+		for i := range o.Config.Webhooks {
+			if o.Config.Webhooks[i].Name == name {
+				return &o.Config.Webhooks[i]
+			}
+		}
+	}
+	return &Webhook{Name: name}
+}
+
+// alreadyNotified returns true if the activity is annotated as notified of the status unless we are forcing
+// new messages
+func (o *Options) alreadyNotified(activity *jenkinsv1.PipelineActivity, key, status string) bool {
+	return !o.ForceNewMessages && activity.Annotations[key] == status
+}
+
+// createNotification creates the backend independent notification for the pipeline
+func (o *Options) createNotification(activity *jenkinsv1.PipelineActivity, pr *scm.PullRequest) (*notifiers.Notification, error) {
+	spec := &activity.Spec
+	details := CreatePipelineDetails(activity)
+	name, err := pipelineName(activity)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting pipeline name for %s", activity.Name)
+	}
+	pipelineCtx := details.Context
+	if pipelineCtx == "" {
+		pipelineCtx = "Build"
+	}
+	notification := &notifiers.Notification{
+		Name:            activity.Name,
+		Title:           fmt.Sprintf("%s %s (%s #%s)", name, scm.Join(details.GitOwner, details.GitRepository), pipelineCtx, details.Build),
+		Status:          string(pipelineStatus(activity)),
+		Owner:           details.GitOwner,
+		Repository:      details.GitRepository,
+		Branch:          details.BranchName,
+		Build:           details.Build,
+		Pipeline:        details.Pipeline,
+		Context:         details.Context,
+		Version:         spec.Version,
+		GitURL:          spec.GitURL,
+		BuildURL:        o.pipelineBuildURL(activity),
+		BuildLogsURL:    strings.Replace(spec.BuildLogsURL, "gs://", "https://storage.cloud.google.com/", -1),
+		ReleaseNotesURL: spec.ReleaseNotesURL,
+		Steps:           notificationSteps(activity),
+	}
+	if pr != nil && pr.Number > 0 {
+		notification.PullRequest = &notifiers.PullRequest{
+			Number: pr.Number,
+			Title:  pr.Title,
+			URL:    pr.Link,
+			Author: pr.Author.Login,
+		}
+	}
+	return notification, nil
+}
+
+// notificationSteps returns the same stages, steps and promotions of the pipeline as the slack attachments
+func notificationSteps(activity *jenkinsv1.PipelineActivity) []notifiers.Step {
+	var answer []notifiers.Step
+	for i := range activity.Spec.Steps {
+		steps := pipelineSteps(&activity.Spec.Steps[i])
+		for j := range steps {
+			answer = append(answer, notificationStep(&steps[j]))
+		}
+	}
+	return answer
+}
+
+func notificationStep(s *pipelineStep) notifiers.Step {
+	name := ""
+	description := ""
+	switch s.kind {
+	case stageStep:
+		name = s.stageName()
+	case promoteStep:
+		name = "promote to " + strings.Title(s.promote.Environment)
+	case promotePullRequestStep:
+		name = "PR"
+		description = s.promote.PullRequest.PullRequestURL
+	case promoteUpdateStep:
+		name = "update"
+	case promoteApplicationStep:
+		name = "application now in " + strings.Title(s.promote.Environment)
+		description = s.promote.ApplicationURL
+	}
+	step := s.step
+	text := step.Description
+	if description != "" {
+		text = strings.TrimSpace(text + " " + description)
+	}
+	textName := strings.Title(name)
+	if textName == "" {
+		textName = step.Name
+	}
+	return notifiers.Step{
+		Name:        getUserFriendlyMapping(textName),
+		Status:      string(step.Status),
+		Description: text,
+	}
+}
@@ -0,0 +1,236 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers/webhook/fakewebhook"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPipelineMessageWebhooks(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+
+	scmClient, _ := fakescm.NewDefault()
+	slackClient := fakeslack.NewFakeSlack()
+	fakeWebhook := fakewebhook.NewFakeWebhook()
+
+	sourceConfig := &v1alpha1.SourceConfig{
+		Spec: v1alpha1.SourceConfigSpec{
+			Groups: []v1alpha1.RepositoryGroup{
+				{
+					Provider: "https://fake.git",
+					Owner:    owner,
+					Repositories: []v1alpha1.Repository{
+						{
+							Name: repo,
+							Slack: &v1alpha1.SlackNotify{
+								Channel:  v1alpha1.DefaultSlackChannel,
+								Kind:     v1alpha1.NotifyKindAlways,
+								Pipeline: v1alpha1.PipelineKindAll,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	pa := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", "1", jenkinsv1.ActivityStatusTypeFailed)
+	jxClient := fakejx.NewSimpleClientset(pa)
+
+	o := &Options{
+		KubeClient:    fake.NewSimpleClientset(),
+		JXClient:      jxClient,
+		ScmClient:     scmClient,
+		SlackClient:   slackClient,
+		Webhooks:      map[string]notifiers.Notifier{"events": fakeWebhook},
+		SourceConfigs: sourceConfig,
+	}
+	o.Namespace = ns
+
+	err := o.PipelineMessage(pa)
+	require.NoError(t, err, "failed to process pipeline %s", pa.Name)
+
+	events := fakeWebhook.AssertEventCount(t, 1, "first notification")
+	require.NotNil(t, events[0].Data, "event data")
+	assert.Equal(t, pa.Name, events[0].Subject, "event subject")
+	assert.Equal(t, "Failed", events[0].Data.Status, "event status")
+	assert.Equal(t, owner, events[0].Data.Owner, "event owner")
+	assert.Equal(t, repo, events[0].Data.Repository, "event repository")
+
+	// lets check both the webhook and slack message annotations were added
+	updated, err := jxClient.JenkinsV1().PipelineActivities(ns).Get(context.TODO(), pa.Name, metav1.GetOptions{})
+	require.NoError(t, err, "failed to find activity %s", pa.Name)
+	assert.Equal(t, "Failed", updated.Annotations[annotationKey("events", webhookMessageType)], "webhook annotation")
+	assert.NotEmpty(t, updated.Annotations[annotationKey(v1alpha1.DefaultSlackChannel, pipelineMessageType)], "slack annotation")
+
+	err = o.PipelineMessage(updated)
+	require.NoError(t, err, "failed to process pipeline %s", updated.Name)
+	fakeWebhook.AssertEventCount(t, 1, "after the activity is annotated")
+}
+
+func TestNotifyWebhooksWithoutSlackChannel(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	pa := testpipelines.CreateTestPipelineActivity(ns, owner, "myrepo", "main", "release", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+	other := testpipelines.CreateTestPipelineActivity(ns, owner, "otherrepo", "main", "release", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+	scmClient, _ := fakescm.NewDefault()
+	fakeWebhook := fakewebhook.NewFakeWebhook()
+
+	o := &Options{
+		KubeClient:  fake.NewSimpleClientset(),
+		JXClient:    fakejx.NewSimpleClientset(pa, other),
+		ScmClient:   scmClient,
+		SlackClient: fakeslack.NewFakeSlack(),
+		Webhooks:    map[string]notifiers.Notifier{"events": fakeWebhook},
+		Config: &Config{
+			Webhooks: []Webhook{
+				{
+					Name:         "events",
+					Repositories: []string{owner + "/myrepo"},
+				},
+			},
+		},
+		SourceConfigs: &v1alpha1.SourceConfig{},
+	}
+	o.Namespace = ns
+
+	for _, activity := range []*jenkinsv1.PipelineActivity{pa, other} {
+		err := o.PipelineMessage(activity)
+		require.NoError(t, err, "failed to process pipeline %s", activity.Name)
+	}
+	events := fakeWebhook.AssertEventCount(t, 1, "should notify the webhook of the matching repository without a slack channel")
+	assert.Equal(t, pa.Name, events[0].Subject, "event subject")
+}
+
+// deadLetterWebhook records the notifications written to its dead letter file
+type deadLetterWebhook struct {
+	blockingWebhook
+	deadLetters []*notifiers.Notification
+}
+
+func (d *deadLetterWebhook) DeadLetter(notification *notifiers.Notification) error {
+	d.deadLetters = append(d.deadLetters, notification)
+	return nil
+}
+
+func TestWebhookQueueFullDeadLetters(t *testing.T) {
+	w := &deadLetterWebhook{}
+	q := &webhookQueue{
+		name:       "events",
+		webhook:    w,
+		deliveries: make(chan *webhookDelivery, 1),
+		pending:    map[string]bool{},
+	}
+	for _, build := range []string{"1", "2"} {
+		pa := testpipelines.CreateTestPipelineActivity("jx", "myorg", "myrepo", "main", "release", build, jenkinsv1.ActivityStatusTypeFailed)
+		q.enqueue(&webhookDelivery{
+			activity:     pa,
+			status:       string(pa.Spec.Status),
+			notification: &notifiers.Notification{Name: pa.Name},
+		})
+	}
+	assert.Len(t, q.deliveries, 1, "queued deliveries")
+	require.Len(t, w.deadLetters, 1, "dead letters")
+	assert.Equal(t, "myorg-myrepo-main-release-2", w.deadLetters[0].Name, "dead letter")
+}
+
+// blockingWebhook blocks each notification until it is released
+type blockingWebhook struct {
+	release       chan struct{}
+	notifications chan *notifiers.Notification
+}
+
+func (b *blockingWebhook) Notify(_ context.Context, _ string, notification *notifiers.Notification) error {
+	<-b.release
+	b.notifications <- notification
+	return nil
+}
+
+func TestNotifyWebhooksAsynchronously(t *testing.T) {
+	ns := "jx"
+	pa := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "main", "release", "1", jenkinsv1.ActivityStatusTypeFailed)
+	jxClient := fakejx.NewSimpleClientset(pa)
+	webhook := &blockingWebhook{
+		release:       make(chan struct{}),
+		notifications: make(chan *notifiers.Notification, 10),
+	}
+	o := &Options{
+		JXClient: jxClient,
+		Webhooks: map[string]notifiers.Notifier{"events": webhook},
+	}
+	o.Namespace = ns
+	o.startWebhookQueues()
+
+	// the webhook is down so the notification should be queued rather than waited for
+	ctx := context.TODO()
+	o.notifyWebhooks(ctx, pa)
+	o.notifyWebhooks(ctx, pa)
+	assert.Len(t, webhook.notifications, 0, "should not have delivered the notification yet")
+
+	close(webhook.release)
+	select {
+	case n := <-webhook.notifications:
+		assert.Equal(t, pa.Name, n.Name, "notification name")
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "timed out waiting for the webhook notification")
+	}
+	assert.Eventually(t, func() bool {
+		updated, err := jxClient.JenkinsV1().PipelineActivities(ns).Get(ctx, pa.Name, metav1.GetOptions{})
+		return err == nil && updated.Annotations[annotationKey("events", webhookMessageType)] == "Failed"
+	}, 10*time.Second, 10*time.Millisecond, "should annotate the activity once delivered")
+
+	// the same notification queued again while it was pending should not be sent twice
+	select {
+	case <-webhook.notifications:
+		assert.Fail(t, "should only deliver the notification once")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNotificationStepsMatchAttachments(t *testing.T) {
+	o := &Options{}
+	act, err := getPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+
+	// a promotion with an application URL but no update step
+	act.Spec.Steps = append(act.Spec.Steps, jenkinsv1.PipelineActivityStep{
+		Kind: jenkinsv1.ActivityStepKindTypePromote,
+		Promote: &jenkinsv1.PromoteActivityStep{
+			CoreActivityStep: jenkinsv1.CoreActivityStep{Status: jenkinsv1.ActivityStatusTypeSucceeded},
+			Environment:      "staging",
+			PullRequest: &jenkinsv1.PromotePullRequestStep{
+				CoreActivityStep: jenkinsv1.CoreActivityStep{Status: jenkinsv1.ActivityStatusTypeSucceeded},
+				PullRequestURL:   "https://github.com/myorg/environment-staging/pull/1",
+			},
+			ApplicationURL: "https://myapp.staging",
+		},
+	})
+
+	var attachments []slack.Attachment
+	for i := range act.Spec.Steps {
+		attachments = append(attachments, o.createAttachments(act, &act.Spec.Steps[i])...)
+	}
+	steps := notificationSteps(act)
+	require.Len(t, steps, len(attachments), "notification steps should match the slack attachments")
+	require.Len(t, steps, 9, "notification steps")
+
+	assert.Equal(t, "Promote to Staging", steps[6].Name, "promote step")
+	assert.Equal(t, "PR", steps[7].Name, "pull request step")
+	assert.Equal(t, "https://github.com/myorg/environment-staging/pull/1", steps[7].Description, "pull request step")
+	assert.Equal(t, "https://myapp.staging", steps[8].Description, "application step")
+}
@@ -2,6 +2,7 @@ package slackbot
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
@@ -14,11 +15,59 @@ import (
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 )
 
+// NotifyDecision the result of checking if a pipeline activity matches the slack configuration
+type NotifyDecision struct {
+	// Notify true if the pipeline should be notified
+	Notify bool
+
+	// Reason describes which filter rejected the pipeline
+	Reason string
+
+	PullRequest *scm.PullRequest
+	Resolver    *users.GitUserResolver
+
+	// Change how the pipeline compares with its previous builds if only changes are notified
+	Change *PipelineChange
+}
+
+// ChangeLabel returns the label of the change of the pipeline if only changes are notified
+func (d *NotifyDecision) ChangeLabel() string {
+	if d.Change == nil {
+		return ""
+	}
+	return d.Change.Label()
+}
+
 // NotifyPipeline returns true if the given pipeline activity matches the configuration
 func (o *Options) NotifyPipeline(activity *jenkinsv1.PipelineActivity, cfg *v1alpha1.SlackNotify) (bool, *scm.PullRequest, *users.GitUserResolver, error) {
-	enabled := o.shouldSendPipelineMessage(activity, cfg)
-	if !enabled {
-		return false, nil, nil, nil
+	decision, err := o.notifyDecision(activity, cfg)
+	if err != nil || decision == nil {
+		return false, nil, nil, err
+	}
+	return true, decision.PullRequest, decision.Resolver, nil
+}
+
+// notifyDecision returns the decision for a pipeline activity which matches the configuration or nil if it does not
+// match so that the change of the pipeline is only computed once
+func (o *Options) notifyDecision(activity *jenkinsv1.PipelineActivity, cfg *v1alpha1.SlackNotify) (*NotifyDecision, error) {
+	decision, err := o.DecideNotifyPipeline(activity, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !decision.Notify {
+		log.Logger().Infof("Ignoring %s because %s\n", activity.Name, decision.Reason)
+		return nil, nil
+	}
+	return decision, nil
+}
+
+// DecideNotifyPipeline checks if the given pipeline activity matches the configuration returning the reason if not
+func (o *Options) DecideNotifyPipeline(activity *jenkinsv1.PipelineActivity, cfg *v1alpha1.SlackNotify) (*NotifyDecision, error) {
+	send, change := o.matchesNotifyKind(activity, cfg)
+	if !send {
+		return &NotifyDecision{
+			Reason: fmt.Sprintf("the notify kind '%s' does not match the status %s", string(cfg.Kind), string(activity.Spec.Status)),
+		}, nil
 	}
 
 	prn, details, err := getPullRequestNumber(activity)
@@ -27,25 +76,31 @@ func (o *Options) NotifyPipeline(activity *jenkinsv1.PipelineActivity, cfg *v1al
 	}
 
 	if !o.matchesPipeline(activity, cfg, prn) {
-		return false, nil, nil, nil
+		kind := "release"
+		if prn > 0 {
+			kind = "pull request"
+		}
+		return &NotifyDecision{
+			Reason: fmt.Sprintf("the pipeline kind '%s' does not match a %s pipeline", string(cfg.Pipeline), kind),
+		}, nil
 	}
 	if !cfg.Branch.Matches(details.BranchName) {
-		log.Logger().Infof("Ignoring %s because it has a different branch: %s\n", activity.Name, details.BranchName)
-		return false, nil, nil, nil
+		return &NotifyDecision{
+			Reason: fmt.Sprintf("it has a different branch: %s", details.BranchName),
+		}, nil
 	}
 	if !cfg.Context.Matches(details.Context) {
-		log.Logger().Infof("Ignoring %s because it has a different context: %s\n", activity.Name, details.Context)
-		return false, nil, nil, nil
+		return &NotifyDecision{
+			Reason: fmt.Sprintf("it has a different context: %s", details.Context),
+		}, nil
 	}
 
 	if prn <= 0 {
-		return true, nil, nil, nil
+		return &NotifyDecision{Notify: true, Change: change}, nil
 	}
-	var pr *scm.PullRequest
-	var resolver *users.GitUserResolver
-	pr, resolver, err = o.getPullRequest(context.TODO(), activity, prn)
+	pr, resolver, err := o.getPullRequest(context.TODO(), activity, prn)
 	if err != nil {
-		return false, nil, nil, errors.WithStack(err)
+		return nil, errors.WithStack(err)
 	}
 
 	if pr == nil {
@@ -57,10 +112,16 @@ func (o *Options) NotifyPipeline(activity *jenkinsv1.PipelineActivity, cfg *v1al
 		labels = append(labels, v.Name)
 	}
 	if !cfg.PullRequestLabel.MatchesLabels(labels) {
-		log.Logger().Infof("Ignoring %s because it has labels %s\n", activity.Name, strings.Join(labels, ", "))
-		return false, nil, nil, nil
+		return &NotifyDecision{
+			Reason: fmt.Sprintf("it has labels %s", strings.Join(labels, ", ")),
+		}, nil
 	}
-	return true, pr, resolver, nil
+	return &NotifyDecision{
+		Notify:      true,
+		PullRequest: pr,
+		Resolver:    resolver,
+		Change:      change,
+	}, nil
 }
 
 func (o *Options) matchesPipeline(activity *jenkinsv1.PipelineActivity, cfg *v1alpha1.SlackNotify, prn int) bool {
@@ -78,30 +139,46 @@ func (o *Options) matchesPipeline(activity *jenkinsv1.PipelineActivity, cfg *v1a
 }
 
 func (o *Options) shouldSendPipelineMessage(activity *jenkinsv1.PipelineActivity, cfg *v1alpha1.SlackNotify) bool {
+	send, _ := o.matchesNotifyKind(activity, cfg)
+	return send
+}
+
+// matchesNotifyKind returns true if the status of the activity matches the notify kind along with the change of the
+// pipeline compared with its previous builds if only changes are notified
+func (o *Options) matchesNotifyKind(activity *jenkinsv1.PipelineActivity, cfg *v1alpha1.SlackNotify) (bool, *PipelineChange) {
 	failed := activity.Spec.Status == jenkinsv1.ActivityStatusTypeError || activity.Spec.Status == jenkinsv1.ActivityStatusTypeFailed
 	succeeded := activity.Spec.Status == jenkinsv1.ActivityStatusTypeSucceeded
 	switch cfg.Kind {
 	case v1alpha1.NotifyKindNone, v1alpha1.NotifyKindNever:
-		return false
+		return false, nil
 	case v1alpha1.NotifyKindAlways:
-		return true
+		return true, nil
 	case v1alpha1.NotifyKindFailure:
-		return failed
+		return failed, nil
 	case v1alpha1.NotifyKindFailureOrFirstSuccess:
 		if succeeded {
-			// TODO lets find if the last status we logged was a fail...
 			flag, err := o.previousPipelineFailed(activity)
 			if err != nil {
 				log.Logger().Warnf("failed to find if previous pipeline of %s was failed: %s", activity.Name, err.Error())
-				return false
+				return false, nil
 			}
-			return flag
+			return flag, nil
 		}
-		return failed
+		return failed, nil
 	case v1alpha1.NotifyKindSuccess:
-		return succeeded
+		return succeeded, nil
+	case NotifyKindChanges:
+		if !failed && !succeeded {
+			return false, nil
+		}
+		change, err := o.pipelineChange(context.TODO(), activity)
+		if err != nil {
+			log.Logger().Warnf("failed to compare %s with the previous builds: %s", activity.Name, err.Error())
+			return false, nil
+		}
+		return change.Changed(), change
 	default:
 		log.Logger().Warnf("invalid notify kind %s", string(cfg.Kind))
-		return false
+		return false, nil
 	}
 }
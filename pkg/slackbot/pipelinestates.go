@@ -0,0 +1,219 @@
+package slackbot
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// PipelineStatesConfigMapName the name of the ConfigMap used to store the last notified state of each pipeline
+	PipelineStatesConfigMapName = "jx-slack-pipeline-states"
+
+	// pipelineStateMaxAge the states of pipelines which have not been built for this long are removed
+	pipelineStateMaxAge = 90 * 24 * time.Hour
+)
+
+// PipelineState the status of the last terminated build of a pipeline along with the build before it so that we
+// know if a success is the first one after a failure even once the PipelineActivities are garbage collected
+type PipelineState struct {
+	Build          int                          `json:"build"`
+	Status         jenkinsv1.ActivityStatusType `json:"status"`
+	PreviousBuild  int                          `json:"previousBuild,omitempty"`
+	PreviousStatus jenkinsv1.ActivityStatusType `json:"previousStatus,omitempty"`
+
+	// Failures the number of consecutive failed builds up to and including Build
+	Failures int `json:"failures,omitempty"`
+
+	// PreviousFailures the number of consecutive failed builds up to and including PreviousBuild
+	PreviousFailures int `json:"previousFailures,omitempty"`
+
+	// Updated when the state was last recorded
+	Updated *metav1.Time `json:"updated,omitempty"`
+}
+
+// PipelineStateStore loads and saves the PipelineState of each pipeline in a ConfigMap
+type PipelineStateStore struct {
+	KubeClient kubernetes.Interface
+	Namespace  string
+
+	// lock serialises the updates of the states from the PipelineActivity watchers
+	lock sync.Mutex
+}
+
+// NewPipelineStateStore creates a new store of pipeline states in the given namespace
+func NewPipelineStateStore(kubeClient kubernetes.Interface, namespace string) *PipelineStateStore {
+	return &PipelineStateStore{
+		KubeClient: kubeClient,
+		Namespace:  namespace,
+	}
+}
+
+// Get returns the state of the given pipeline or nil if there is none
+func (s *PipelineStateStore) Get(ctx context.Context, pipeline string) (*PipelineState, error) {
+	state := &PipelineState{}
+	found, err := loadConfigMapValue(ctx, s.KubeClient, s.Namespace, PipelineStatesConfigMapName, configMapKey(pipeline), state)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the state of pipeline %s", pipeline)
+	}
+	if !found {
+		return nil, nil
+	}
+	return state, nil
+}
+
+// Save stores the state of the given pipeline, lazily creating the ConfigMap if required, and removes the states
+// which have not been updated for pipelineStateMaxAge
+func (s *PipelineStateStore) Save(ctx context.Context, pipeline string, state *PipelineState) error {
+	key := configMapKey(pipeline)
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the state of pipeline %s", pipeline)
+	}
+	cutoff := time.Now().Add(-pipelineStateMaxAge)
+	return updateConfigMapData(ctx, s.KubeClient, s.Namespace, PipelineStatesConfigMapName, func(values map[string]string) {
+		values[key] = string(data)
+		for k, v := range values {
+			old := &PipelineState{}
+			err := yaml.Unmarshal([]byte(v), old)
+			if err != nil || old.Updated == nil || old.Updated.Time.Before(cutoff) {
+				delete(values, k)
+			}
+		}
+	})
+}
+
+// Record records the status of the build returning true if the state changed. Builds older than the last one are
+// ignored
+func (s *PipelineState) Record(build int, status jenkinsv1.ActivityStatusType) bool {
+	switch {
+	case build == s.Build:
+		if status == s.Status {
+			return false
+		}
+		s.Status = status
+	case build > s.Build:
+		s.PreviousBuild = s.Build
+		s.PreviousStatus = s.Status
+		s.PreviousFailures = s.Failures
+		s.Build = build
+		s.Status = status
+	default:
+		return false
+	}
+	s.Failures = 0
+	if isFailedStatus(status) {
+		s.Failures = s.PreviousFailures + 1
+	}
+	return true
+}
+
+// StatusBefore returns the status of the last recorded build before the given build and if it is known
+func (s *PipelineState) StatusBefore(build int) (jenkinsv1.ActivityStatusType, bool) {
+	switch {
+	case s.Build < build && s.Build > 0:
+		return s.Status, true
+	case s.Build == build && s.PreviousBuild > 0:
+		return s.PreviousStatus, true
+	default:
+		return "", false
+	}
+}
+
+// FailuresBefore returns the number of consecutive failed builds up to the last recorded build before the given
+// build and if it is known
+func (s *PipelineState) FailuresBefore(build int) (int, bool) {
+	switch {
+	case s.Build < build && s.Build > 0:
+		return s.Failures, true
+	case s.Build == build && s.PreviousBuild > 0:
+		return s.PreviousFailures, true
+	default:
+		return 0, false
+	}
+}
+
+// pipelineStateKey returns the key of the namespace, pipeline and context of the activity in the state store
+func pipelineStateKey(activity *jenkinsv1.PipelineActivity) string {
+	details := CreatePipelineDetails(activity)
+	key := activity.Namespace + "/" + details.GitOwner + "/" + details.GitRepository + "/" + details.BranchName
+	if details.Context != "" {
+		key += "/" + details.Context
+	}
+	return key
+}
+
+// recordsPipelineState returns true if the state of the pipeline of the activity is recorded. Only the states of
+// branches are recorded as the PipelineActivities of pull requests are kept while they are open
+func recordsPipelineState(activity *jenkinsv1.PipelineActivity) bool {
+	prn, _, err := getPullRequestNumber(activity)
+	return err == nil && prn <= 0
+}
+
+// recordPipelineState remembers the status of the activity once it has terminated. Failures are logged rather
+// than returned so that they do not stop the pipeline messages
+func (o *Options) recordPipelineState(ctx context.Context, activity *jenkinsv1.PipelineActivity) {
+	if o.PipelineStates == nil || o.DryRun || !recordsPipelineState(activity) {
+		return
+	}
+	status := activity.Spec.Status
+	if !status.IsTerminated() {
+		return
+	}
+	build, err := strconv.Atoi(activity.Spec.Build)
+	if err != nil {
+		return
+	}
+	store := o.PipelineStates
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	key := pipelineStateKey(activity)
+	state, err := store.Get(ctx, key)
+	if err != nil {
+		log.Logger().Warnf("failed to record the status of %s: %s", activity.Name, err.Error())
+		return
+	}
+	if state == nil {
+		state = &PipelineState{}
+	}
+	if !state.Record(build, status) {
+		return
+	}
+	now := metav1.Now()
+	state.Updated = &now
+	err = store.Save(ctx, key, state)
+	if err != nil {
+		log.Logger().Warnf("failed to record the status of %s: %s", activity.Name, err.Error())
+	}
+}
+
+// previousPipelineState returns the recorded status of the build before the activity and if it is known
+func (o *Options) previousPipelineState(ctx context.Context, activity *jenkinsv1.PipelineActivity, build int) (jenkinsv1.ActivityStatusType, bool) {
+	state := o.pipelineState(ctx, activity)
+	if state == nil {
+		return "", false
+	}
+	return state.StatusBefore(build)
+}
+
+// pipelineState returns the recorded state of the pipeline of the activity or nil if there is none
+func (o *Options) pipelineState(ctx context.Context, activity *jenkinsv1.PipelineActivity) *PipelineState {
+	if o.PipelineStates == nil || !recordsPipelineState(activity) {
+		return nil
+	}
+	state, err := o.PipelineStates.Get(ctx, pipelineStateKey(activity))
+	if err != nil {
+		log.Logger().Warnf("failed to find the previous status of %s: %s", activity.Name, err.Error())
+		return nil
+	}
+	return state
+}
@@ -0,0 +1,144 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPreviousPipelineFailedUsesLabels(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+
+	failed := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", "1", jenkinsv1.ActivityStatusTypeFailed)
+	// lets use a name which does not follow the usual naming convention
+	failed.Name = "renamed-activity"
+	running := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", "2", jenkinsv1.ActivityStatusTypeRunning)
+	otherContext := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "lint", "2", jenkinsv1.ActivityStatusTypeSucceeded)
+	succeeded := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", "3", jenkinsv1.ActivityStatusTypeSucceeded)
+	lintFixed := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "lint", "3", jenkinsv1.ActivityStatusTypeSucceeded)
+
+	o := &Options{
+		JXClient: fakejx.NewSimpleClientset(failed, running, otherContext, succeeded, lintFixed),
+	}
+	o.Namespace = ns
+
+	flag, err := o.previousPipelineFailed(succeeded)
+	require.NoError(t, err, "failed to find previous pipeline of %s", succeeded.Name)
+	assert.True(t, flag, "should skip the running build and find the failed build of %s", succeeded.Name)
+
+	flag, err = o.previousPipelineFailed(lintFixed)
+	require.NoError(t, err, "failed to find previous pipeline of %s", lintFixed.Name)
+	assert.False(t, flag, "should only look at builds of the same context for %s", lintFixed.Name)
+
+	flag, err = o.previousPipelineFailed(failed)
+	require.NoError(t, err, "failed to find previous pipeline of %s", failed.Name)
+	assert.False(t, flag, "the first build has no previous build")
+}
+
+func TestPreviousPipelineFailedUsesStateAfterGarbageCollection(t *testing.T) {
+	ns := "jx"
+	ctx := context.TODO()
+	kubeClient := fake.NewSimpleClientset()
+
+	failed := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "main", "release", "4", jenkinsv1.ActivityStatusTypeFailed)
+	succeeded := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "main", "release", "5", jenkinsv1.ActivityStatusTypeSucceeded)
+
+	// the failed activity has been garbage collected
+	o := &Options{
+		KubeClient:     kubeClient,
+		JXClient:       fakejx.NewSimpleClientset(succeeded),
+		PipelineStates: NewPipelineStateStore(kubeClient, ns),
+	}
+	o.Namespace = ns
+
+	o.recordPipelineState(ctx, failed)
+	o.recordPipelineState(ctx, succeeded)
+
+	flag, err := o.previousPipelineFailed(succeeded)
+	require.NoError(t, err, "failed to find previous pipeline of %s", succeeded.Name)
+	assert.True(t, flag, "should use the recorded state of the pipeline")
+
+	state, err := o.PipelineStates.Get(ctx, pipelineStateKey(succeeded))
+	require.NoError(t, err, "failed to load pipeline state")
+	require.NotNil(t, state, "should have saved the pipeline state")
+	assert.Equal(t, 5, state.Build, "state build")
+	assert.Equal(t, jenkinsv1.ActivityStatusTypeSucceeded, state.Status, "state status")
+	assert.Equal(t, 4, state.PreviousBuild, "state previous build")
+	assert.Equal(t, jenkinsv1.ActivityStatusTypeFailed, state.PreviousStatus, "state previous status")
+}
+
+func TestPipelineStatesArePruned(t *testing.T) {
+	ns := "jx"
+	ctx := context.TODO()
+	old := metav1.NewTime(time.Now().Add(-2 * pipelineStateMaxAge))
+	recent := metav1.NewTime(time.Now().Add(-time.Hour))
+	kubeClient := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PipelineStatesConfigMapName,
+			Namespace: ns,
+		},
+		Data: map[string]string{
+			"myorg-oldrepo-main-release":      "build: 3\nstatus: Failed\nupdated: " + old.UTC().Format(time.RFC3339) + "\n",
+			"jx-myorg-otherrepo-main-release": "build: 7\nstatus: Succeeded\nupdated: " + recent.UTC().Format(time.RFC3339) + "\n",
+			"myorg-myrepo-main-release":       "build: 5\nstatus: Failed\n",
+		},
+	})
+	o := &Options{
+		KubeClient:     kubeClient,
+		PipelineStates: NewPipelineStateStore(kubeClient, ns),
+	}
+	o.Namespace = ns
+
+	succeeded := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "main", "release", "6", jenkinsv1.ActivityStatusTypeSucceeded)
+	assert.Equal(t, "jx/myorg/myrepo/main/release", pipelineStateKey(succeeded), "key")
+	pr := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "PR-12", "pr", "1", jenkinsv1.ActivityStatusTypeFailed)
+	o.recordPipelineState(ctx, pr)
+	o.recordPipelineState(ctx, succeeded)
+
+	cm, err := kubeClient.CoreV1().ConfigMaps(ns).Get(ctx, PipelineStatesConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err, "failed to find ConfigMap %s", PipelineStatesConfigMapName)
+	var keys []string
+	for k := range cm.Data {
+		keys = append(keys, k)
+	}
+	assert.ElementsMatch(t, []string{"jx-myorg-otherrepo-main-release", "jx-myorg-myrepo-main-release"}, keys,
+		"should remove the old states and not record pull requests")
+
+	state := o.pipelineState(ctx, succeeded)
+	require.NotNil(t, state, "should have saved the pipeline state")
+	require.NotNil(t, state.Updated, "should record when the state was updated")
+	assert.Equal(t, 6, state.Build, "state build")
+}
+
+func TestPipelineStateRecord(t *testing.T) {
+	state := &PipelineState{}
+	_, known := state.StatusBefore(1)
+	assert.False(t, known, "nothing recorded")
+
+	assert.True(t, state.Record(1, jenkinsv1.ActivityStatusTypeFailed), "first build")
+	assert.False(t, state.Record(1, jenkinsv1.ActivityStatusTypeFailed), "same status")
+
+	status, known := state.StatusBefore(2)
+	assert.True(t, known, "previous build recorded")
+	assert.Equal(t, jenkinsv1.ActivityStatusTypeFailed, status, "status before build 2")
+
+	assert.True(t, state.Record(2, jenkinsv1.ActivityStatusTypeSucceeded), "next build")
+	status, known = state.StatusBefore(2)
+	assert.True(t, known, "previous build recorded")
+	assert.Equal(t, jenkinsv1.ActivityStatusTypeFailed, status, "status before build 2 once it is recorded")
+
+	assert.False(t, state.Record(1, jenkinsv1.ActivityStatusTypeSucceeded), "older build")
+	_, known = state.StatusBefore(1)
+	assert.False(t, known, "older builds are unknown")
+}
@@ -0,0 +1,54 @@
+package slackbot
+
+import (
+	"context"
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// the labels lighthouse adds to the PipelineRuns it triggers which tekton copies onto their TaskRuns and pods
+const (
+	lighthouseOrgLabel     = "lighthouse.jenkins-x.io/refs.org"
+	lighthouseRepoLabel    = "lighthouse.jenkins-x.io/refs.repo"
+	lighthouseBranchLabel  = "lighthouse.jenkins-x.io/branch"
+	lighthouseContextLabel = "lighthouse.jenkins-x.io/context"
+	lighthouseBuildLabel   = "lighthouse.jenkins-x.io/buildNum"
+)
+
+// pipelinePodSelector returns the label selector of the pods of the build of the activity. The pods only have the
+// labels of the PipelineRun, not the owner, repository, branch and build labels of the activity, so we match the
+// lighthouse labels using the values of the activity
+func pipelinePodSelector(activity *jenkinsv1.PipelineActivity) string {
+	details := CreatePipelineDetails(activity)
+	labels := activity.Labels
+	selector := []string{
+		lighthouseOrgLabel + "=" + labelOrDefault(labels, lighthouseOrgLabel, labelOrDefault(labels, "owner", details.GitOwner)),
+		lighthouseRepoLabel + "=" + labelOrDefault(labels, lighthouseRepoLabel, labelOrDefault(labels, "repository", details.GitRepository)),
+		lighthouseBranchLabel + "=" + labelOrDefault(labels, lighthouseBranchLabel, labelOrDefault(labels, "branch", details.BranchName)),
+		lighthouseBuildLabel + "=" + labelOrDefault(labels, lighthouseBuildLabel, labelOrDefault(labels, "build", activity.Spec.Build)),
+	}
+	if pipelineCtx := labelOrDefault(labels, lighthouseContextLabel, labelOrDefault(labels, "context", details.Context)); pipelineCtx != "" {
+		selector = append(selector, lighthouseContextLabel+"="+pipelineCtx)
+	}
+	return strings.Join(selector, ",")
+}
+
+// pipelinePods returns the pods of the build of the activity
+func (o *Options) pipelinePods(ctx context.Context, activity *jenkinsv1.PipelineActivity) ([]corev1.Pod, error) {
+	if o.KubeClient == nil {
+		return nil, nil
+	}
+	ns := o.activityNamespace(activity)
+	selector := pipelinePodSelector(activity)
+	pods, err := o.KubeClient.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list pods in namespace %s with selector %s", ns, selector)
+	}
+	return pods.Items, nil
+}
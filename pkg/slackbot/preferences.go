@@ -0,0 +1,212 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// PreferencesConfigMapName the name of the ConfigMap used to store the notification preferences of each slack user
+	PreferencesConfigMapName = "jx-slack-preferences"
+
+	// DeliveryDirectMessage notifications are sent to the user as a direct message
+	DeliveryDirectMessage = "dm"
+
+	// DeliveryMention the user is mentioned in the channel message instead of being sent a direct message
+	DeliveryMention = "mention"
+)
+
+// UserPreferences the notification preferences a user manages from slack
+type UserPreferences struct {
+	// OnlyFailures only direct message the user about failed pipelines
+	OnlyFailures bool `json:"onlyFailures,omitempty"`
+
+	// MutedRepositories the repositories (in owner/name form, wildcards allowed) the user does not want to hear about
+	MutedRepositories []string `json:"mutedRepositories,omitempty"`
+
+	// QuietHours the time of day the user does not want to be sent direct messages
+	QuietHours *QuietHours `json:"quietHours,omitempty"`
+
+	// Delivery whether the user wants a direct message or a mention in the channel message
+	Delivery string `json:"delivery,omitempty"`
+}
+
+// QuietHours a daily time range in HH:MM format. If Start is after End the range spans midnight
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// PreferenceStore loads and saves UserPreferences in a ConfigMap
+type PreferenceStore struct {
+	KubeClient kubernetes.Interface
+	Namespace  string
+}
+
+// NewPreferenceStore creates a new store of user preferences in the given namespace
+func NewPreferenceStore(kubeClient kubernetes.Interface, namespace string) *PreferenceStore {
+	return &PreferenceStore{
+		KubeClient: kubeClient,
+		Namespace:  namespace,
+	}
+}
+
+// Get returns the preferences for the given slack user ID or the default preferences if there are none
+func (s *PreferenceStore) Get(ctx context.Context, userID string) (*UserPreferences, error) {
+	prefs := &UserPreferences{}
+	_, err := loadConfigMapValue(ctx, s.KubeClient, s.Namespace, PreferencesConfigMapName, userID, prefs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load preferences of user %s", userID)
+	}
+	return prefs, nil
+}
+
+// Save stores the preferences for the given slack user ID, lazily creating the ConfigMap if required
+func (s *PreferenceStore) Save(ctx context.Context, userID string, prefs *UserPreferences) error {
+	return saveConfigMapValue(ctx, s.KubeClient, s.Namespace, PreferencesConfigMapName, userID, prefs)
+}
+
+// PrefersMention returns true if the user would rather be mentioned in the channel than sent a direct message
+func (p *UserPreferences) PrefersMention() bool {
+	return p.Delivery == DeliveryMention
+}
+
+// IsMuted returns true if the user has muted the repository of the given activity
+func (p *UserPreferences) IsMuted(activity *jenkinsv1.PipelineActivity) bool {
+	return matchesRepository(p.MutedRepositories, activity)
+}
+
+// matchesRepository returns true if the owner/name of the repository of the activity matches one of the patterns
+func matchesRepository(patterns []string, activity *jenkinsv1.PipelineActivity) bool {
+	details := CreatePipelineDetails(activity)
+	fullName := details.GitOwner + "/" + details.GitRepository
+	for _, pattern := range patterns {
+		if pattern == fullName {
+			return true
+		}
+		if matched, err := path.Match(pattern, fullName); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsPipeline returns true if the user wants to be notified about the given pipeline at the given time
+func (p *UserPreferences) AllowsPipeline(activity *jenkinsv1.PipelineActivity, now time.Time) bool {
+	if p.OnlyFailures && !isFailedStatus(pipelineStatus(activity)) {
+		return false
+	}
+	return p.AllowsReview(activity, now)
+}
+
+// AllowsReview returns true if the user wants to be notified about the review request of the given pipeline
+// at the given time
+func (p *UserPreferences) AllowsReview(activity *jenkinsv1.PipelineActivity, now time.Time) bool {
+	if p.IsMuted(activity) {
+		return false
+	}
+	if p.QuietHours != nil {
+		quiet, err := p.QuietHours.Contains(now)
+		if err != nil {
+			log.Logger().Warnf("ignoring invalid quiet hours: %s", err.Error())
+			return true
+		}
+		return !quiet
+	}
+	return true
+}
+
+// Contains returns true if the given time is within the quiet hours
+func (q *QuietHours) Contains(t time.Time) (bool, error) {
+	if q.TimeZone != "" {
+		loc, err := time.LoadLocation(q.TimeZone)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to load time zone %s", q.TimeZone)
+		}
+		t = t.In(loc)
+	}
+	start, err := parseTimeOfDay(q.Start)
+	if err != nil {
+		return false, err
+	}
+	end, err := parseTimeOfDay(q.End)
+	if err != nil {
+		return false, err
+	}
+	now := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return now >= start && now < end, nil
+	}
+	// the quiet hours span midnight
+	return now >= start || now < end, nil
+}
+
+// String returns the quiet hours as they are entered in a slack command
+func (q *QuietHours) String() string {
+	text := q.Start + "-" + q.End
+	if q.TimeZone != "" {
+		text += " " + q.TimeZone
+	}
+	return text
+}
+
+// parseTimeOfDay parses a HH:MM string into the number of minutes since midnight
+func parseTimeOfDay(text string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(text))
+	if err != nil {
+		return 0, errors.Errorf("invalid time %s, expected the format HH:MM", text)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// userPreferences returns the preferences of the given slack user, falling back to the defaults if they
+// cannot be loaded
+func (o *Options) userPreferences(userID string) *UserPreferences {
+	if o.Preferences == nil || userID == "" {
+		return &UserPreferences{}
+	}
+	prefs, err := o.Preferences.Get(context.TODO(), userID)
+	if err != nil {
+		log.Logger().Warnf("failed to load preferences of slack user %s: %s", userID, err.Error())
+		return &UserPreferences{}
+	}
+	return prefs
+}
+
+// describePreferences returns a slack friendly description of the preferences
+func describePreferences(p *UserPreferences) string {
+	delivery := "direct message"
+	if p.PrefersMention() {
+		delivery = "mention in the channel"
+	}
+	muted := "none"
+	if len(p.MutedRepositories) > 0 {
+		muted = strings.Join(p.MutedRepositories, ", ")
+	}
+	quiet := "off"
+	if p.QuietHours != nil {
+		quiet = p.QuietHours.String()
+	}
+	return fmt.Sprintf("*Only failures:* %s\n*Muted repositories:* %s\n*Quiet hours:* %s\n*Delivery:* %s",
+		onOff(p.OnlyFailures), muted, quiet, delivery)
+}
+
+func onOff(flag bool) string {
+	if flag {
+		return "on"
+	}
+	return "off"
+}
+
+func isFailedStatus(status jenkinsv1.ActivityStatusType) bool {
+	return status == jenkinsv1.ActivityStatusTypeFailed || status == jenkinsv1.ActivityStatusTypeError
+}
@@ -0,0 +1,131 @@
+package slackbot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slackbot"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestQuietHours(t *testing.T) {
+	testCases := []struct {
+		name     string
+		quiet    slackbot.QuietHours
+		time     string
+		expected bool
+	}{
+		{
+			name:     "inside-same-day",
+			quiet:    slackbot.QuietHours{Start: "12:00", End: "14:00"},
+			time:     "13:30",
+			expected: true,
+		},
+		{
+			name:     "outside-same-day",
+			quiet:    slackbot.QuietHours{Start: "12:00", End: "14:00"},
+			time:     "14:00",
+			expected: false,
+		},
+		{
+			name:     "inside-before-midnight",
+			quiet:    slackbot.QuietHours{Start: "22:00", End: "07:00"},
+			time:     "23:15",
+			expected: true,
+		},
+		{
+			name:     "inside-after-midnight",
+			quiet:    slackbot.QuietHours{Start: "22:00", End: "07:00"},
+			time:     "06:59",
+			expected: true,
+		},
+		{
+			name:     "outside-spanning-midnight",
+			quiet:    slackbot.QuietHours{Start: "22:00", End: "07:00"},
+			time:     "12:00",
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		now, err := time.Parse("15:04", tc.time)
+		require.NoError(t, err, "failed to parse time %s for test %s", tc.time, tc.name)
+
+		got, err := tc.quiet.Contains(now)
+		require.NoError(t, err, "failed to check quiet hours for test %s", tc.name)
+		assert.Equal(t, tc.expected, got, "quiet hours for test %s", tc.name)
+	}
+
+	_, err := (&slackbot.QuietHours{Start: "25:00", End: "07:00"}).Contains(time.Now())
+	assert.Error(t, err, "should fail for an invalid start time")
+}
+
+func TestUserPreferencesAllowsPipeline(t *testing.T) {
+	failed := testpipelines.CreateTestPipelineActivity("jx", "myorg", "myrepo", "main", "", "1", jenkinsv1.ActivityStatusTypeFailed)
+	succeeded := testpipelines.CreateTestPipelineActivity("jx", "myorg", "myrepo", "main", "", "2", jenkinsv1.ActivityStatusTypeSucceeded)
+	other := testpipelines.CreateTestPipelineActivity("jx", "otherorg", "another", "main", "", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+
+	now := time.Now()
+	prefs := &slackbot.UserPreferences{}
+	assert.True(t, prefs.AllowsPipeline(succeeded, now), "default preferences should allow all pipelines")
+
+	prefs.OnlyFailures = true
+	assert.True(t, prefs.AllowsPipeline(failed, now), "only failures should allow a failed pipeline")
+	assert.False(t, prefs.AllowsPipeline(succeeded, now), "only failures should not allow a succeeded pipeline")
+	assert.True(t, prefs.AllowsReview(succeeded, now), "only failures should not affect review requests")
+
+	prefs = &slackbot.UserPreferences{
+		MutedRepositories: []string{"myorg/*"},
+	}
+	assert.False(t, prefs.AllowsPipeline(failed, now), "muted repository should not be allowed")
+	assert.True(t, prefs.AllowsPipeline(other, now), "other repository should be allowed")
+}
+
+func TestNotifyCommand(t *testing.T) {
+	ns := "jx"
+	userID := "U1234"
+	o := &slackbot.Options{
+		Preferences: slackbot.NewPreferenceStore(fake.NewSimpleClientset(), ns),
+	}
+
+	commands := []string{
+		"notify only-failures on",
+		"notify mute myorg/myrepo",
+		"notify mute myorg/another",
+		"notify unmute myorg/another",
+		"notify quiet 22:00-07:00 Europe/London",
+		"notify mention",
+	}
+	for _, text := range commands {
+		msg, err := o.HandleCommand(&slack.SlashCommand{
+			Command: "/jx",
+			Text:    text,
+			UserID:  userID,
+		})
+		require.NoError(t, err, "failed to handle command %s", text)
+		require.NotNil(t, msg, "no response for command %s", text)
+		assert.Equal(t, slack.ResponseTypeEphemeral, msg.ResponseType, "response type for command %s", text)
+		t.Logf("%s => %s\n", text, msg.Text)
+	}
+
+	prefs, err := o.Preferences.Get(context.TODO(), userID)
+	require.NoError(t, err, "failed to load preferences")
+
+	assert.True(t, prefs.OnlyFailures, "OnlyFailures")
+	assert.Equal(t, []string{"myorg/myrepo"}, prefs.MutedRepositories, "MutedRepositories")
+	require.NotNil(t, prefs.QuietHours, "QuietHours")
+	assert.Equal(t, "22:00", prefs.QuietHours.Start, "QuietHours.Start")
+	assert.Equal(t, "07:00", prefs.QuietHours.End, "QuietHours.End")
+	assert.Equal(t, "Europe/London", prefs.QuietHours.TimeZone, "QuietHours.TimeZone")
+	assert.True(t, prefs.PrefersMention(), "PrefersMention")
+
+	msg, err := o.HandleCommand(&slack.SlashCommand{Command: "/jx", Text: "notify quiet 7pm", UserID: userID})
+	require.NoError(t, err, "failed to handle invalid quiet command")
+	assert.Contains(t, msg.Text, "Usage", "response to invalid quiet command")
+}
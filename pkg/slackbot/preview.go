@@ -0,0 +1,310 @@
+package slackbot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/dryrun"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-gitops/pkg/sourceconfigs"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/jxclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PreviewOutputJSON outputs the payloads of the messages as JSON
+	PreviewOutputJSON = "json"
+
+	// PreviewOutputURL outputs a Block Kit Builder link for each slack message
+	PreviewOutputURL = "url"
+
+	blockKitBuilderURL = "https://app.slack.com/block-kit-builder#"
+)
+
+// PreviewOptions options for previewing the messages which would be sent for a PipelineActivity without
+// sending them
+type PreviewOptions struct {
+	Options
+	ActivityName string
+	File         string
+	Output       string
+	Activity     *jenkinsv1.PipelineActivity
+	Out          io.Writer
+}
+
+// previewRecorder the recorded messages of a slack connection or notifier
+type previewRecorder struct {
+	name     string
+	messages func() []dryrun.Message
+}
+
+// Validate loads the PipelineActivity and configuration and replaces the clients which send messages with dry run
+// clients which record them
+func (o *PreviewOptions) Validate() error {
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	switch o.Output {
+	case "":
+		o.Output = PreviewOutputJSON
+	case PreviewOutputJSON, PreviewOutputURL:
+	default:
+		return errors.Errorf("unknown output %s, supported values are %s and %s", o.Output, PreviewOutputJSON, PreviewOutputURL)
+	}
+	if o.Dir == "" {
+		o.Dir = "."
+	}
+	o.DryRun = true
+
+	err := o.loadActivity(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	if o.ScmClient == nil {
+		o.ScmClient, err = factory.NewClientFromEnvironment()
+		if err != nil {
+			return errors.Wrapf(err, "failed to create SCM client")
+		}
+	}
+	if o.SourceConfigs == nil {
+		o.SourceConfigs, err = sourceconfigs.LoadSourceConfig(o.Dir, true)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load source configs from dir %s", o.Dir)
+		}
+	}
+	if o.Config == nil {
+		o.Config, err = LoadConfig(o.Dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load jx-slack config from dir %s", o.Dir)
+		}
+	}
+
+	// lets use the real slack client to lookup users if we have a token
+	delegate := o.SlackClient
+	if delegate == nil && o.SlackToken != "" {
+		if o.SlackURL != "" {
+			delegate = slack.New(o.SlackToken, slack.OptionAPIURL(o.SlackURL))
+		} else {
+			delegate = slack.New(o.SlackToken)
+		}
+	}
+	o.SlackClient = dryrun.NewClient(delegate)
+	o.createPreviewConnections()
+
+	o.SlackUserResolver = NewSlackUserResolver(o.SlackClient, o.JXClient, o.Namespace)
+	if o.Preferences == nil && o.KubeClient != nil {
+		o.Preferences = NewPreferenceStore(o.KubeClient, o.Namespace)
+	}
+	return nil
+}
+
+// loadActivity loads the PipelineActivity from the file or the cluster
+func (o *PreviewOptions) loadActivity(ctx context.Context) error {
+	var err error
+	if o.File != "" {
+		o.Activity = &jenkinsv1.PipelineActivity{}
+		err = yamls.LoadFile(o.File, o.Activity)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load PipelineActivity from file %s", o.File)
+		}
+		if o.Activity.Namespace == "" {
+			o.Activity.Namespace = o.Namespace
+		}
+		if o.Namespace == "" {
+			o.Namespace = o.Activity.Namespace
+		}
+		// without a JXClient the history of the pipeline is skipped so that we can preview without a cluster
+		return nil
+	}
+	if o.ActivityName == "" {
+		return errors.Errorf("missing PipelineActivity name or file")
+	}
+	o.KubeClient, o.Namespace, err = kube.LazyCreateKubeClientAndNamespace(o.KubeClient, o.Namespace)
+	if err != nil {
+		return err
+	}
+	o.JXClient, err = jxclient.LazyCreateJXClient(o.JXClient)
+	if err != nil {
+		return err
+	}
+	o.Activity, err = o.JXClient.JenkinsV1().PipelineActivities(o.Namespace).Get(ctx, o.ActivityName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find PipelineActivity %s in namespace %s", o.ActivityName, o.Namespace)
+	}
+	return nil
+}
+
+// createPreviewConnections creates dry run clients for the connections, webhooks and emails in the configuration
+// so that no secrets are required
+func (o *PreviewOptions) createPreviewConnections() {
+	o.Connections = map[string]slacker.Interface{}
+	o.Notifiers = map[string]notifiers.Notifier{}
+	o.Webhooks = map[string]notifiers.Notifier{}
+	o.Emails = map[string]notifiers.Notifier{}
+	if o.Config == nil {
+		return
+	}
+	for i := range o.Config.Connections {
+		c := &o.Config.Connections[i]
+		switch {
+		case c.Kind == "" || c.Kind == notifiers.KindSlack || isMattermostAPI(c):
+			o.Connections[c.Name] = dryrun.NewClient(nil)
+		default:
+			o.Notifiers[c.Name] = dryrun.NewNotifier(c.Kind + " " + c.Name)
+		}
+	}
+	for _, w := range o.Config.Webhooks {
+		o.Webhooks[w.Name] = dryrun.NewNotifier("webhook " + w.Name)
+	}
+	for _, e := range o.Config.Emails {
+		o.Emails[e.Name] = dryrun.NewNotifier("email " + e.Name)
+	}
+}
+
+// Run prints the decision of whether the PipelineActivity is notified and the messages which would be sent
+func (o *PreviewOptions) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+	activity := o.Activity
+
+	cfg := o.getSlackConfigForPipeline(activity)
+	if cfg == nil || cfg.Channel == "" {
+		fmt.Fprintf(o.Out, "no slack configuration for repository %s\n", scm.Join(activity.Spec.GitOwner, activity.Spec.GitRepository))
+	} else {
+		decision, err := o.DecideNotifyPipeline(activity, cfg)
+		if err != nil {
+			return errors.Wrapf(err, "failed to verify if %s should be notified", activity.Name)
+		}
+		if decision.Notify {
+			fmt.Fprintf(o.Out, "%s matches the slack configuration for channel %s\n", activity.Name, cfg.Channel)
+		} else {
+			fmt.Fprintf(o.Out, "%s will not be notified in channel %s because %s\n", activity.Name, cfg.Channel, decision.Reason)
+		}
+	}
+
+	err = o.PipelineMessage(activity)
+	if err != nil {
+		return errors.Wrapf(err, "failed to preview pipeline message for %s", activity.Name)
+	}
+	err = o.ReviewRequestMessage(activity)
+	if err != nil {
+		return errors.Wrapf(err, "failed to preview review request message for %s", activity.Name)
+	}
+	return o.printMessages()
+}
+
+// printMessages prints the messages recorded by the dry run clients
+func (o *PreviewOptions) printMessages() error {
+	count := 0
+	for _, r := range o.previewRecorders() {
+		for _, m := range r.messages() {
+			count++
+			fmt.Fprintf(o.Out, "\nwould %s via %s:\n", describePreviewMessage(m), r.name)
+
+			text := m.Payload
+			if o.Output == PreviewOutputURL && m.Method != dryrun.MethodNotify {
+				var err error
+				text, err = blockKitBuilderLink(m.Payload)
+				if err != nil {
+					return errors.Wrapf(err, "failed to create Block Kit Builder link for %s", m.Channel)
+				}
+			}
+			fmt.Fprintln(o.Out, text)
+		}
+	}
+	if count == 0 {
+		fmt.Fprintln(o.Out, "no messages would be sent")
+	}
+	return nil
+}
+
+// previewRecorders returns the dry run clients in a stable order
+func (o *PreviewOptions) previewRecorders() []previewRecorder {
+	var answer []previewRecorder
+	if c, ok := o.SlackClient.(*dryrun.Client); ok {
+		answer = append(answer, previewRecorder{name: "slack", messages: func() []dryrun.Message { return c.Messages }})
+	}
+	var names []string
+	for name := range o.Connections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if c, ok := o.Connections[name].(*dryrun.Client); ok {
+			answer = append(answer, previewRecorder{name: "slack connection " + name, messages: func() []dryrun.Message { return c.Messages }})
+		}
+	}
+	for _, m := range []map[string]notifiers.Notifier{o.Notifiers, o.Webhooks, o.Emails} {
+		for _, name := range sortedNotifierNames(m) {
+			if n, ok := m[name].(*dryrun.Notifier); ok {
+				answer = append(answer, previewRecorder{name: n.Name, messages: func() []dryrun.Message { return n.Messages }})
+			}
+		}
+	}
+	return answer
+}
+
+func sortedNotifierNames(m map[string]notifiers.Notifier) []string {
+	var answer []string
+	for k := range m {
+		answer = append(answer, k)
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// describePreviewMessage describes the action and destination of a recorded message
+func describePreviewMessage(m dryrun.Message) string {
+	destination := "channel " + m.Channel
+	if strings.HasPrefix(m.Channel, dryrun.DirectMessagePrefix) {
+		destination = "direct message to " + strings.TrimPrefix(m.Channel, dryrun.DirectMessagePrefix)
+	}
+	switch m.Method {
+	case dryrun.MethodUpdate:
+		return "update message in " + destination
+	case dryrun.MethodNotify:
+		if m.Channel == "" {
+			return "notify"
+		}
+		return "notify " + m.Channel
+	default:
+		return "create message in " + destination
+	}
+}
+
+// blockKitBuilderLink returns a link which opens the message in the Block Kit Builder
+func blockKitBuilderLink(payload string) (string, error) {
+	values := map[string]json.RawMessage{}
+	err := json.Unmarshal([]byte(payload), &values)
+	if err != nil {
+		return "", err
+	}
+	message := map[string]json.RawMessage{}
+	for _, k := range []string{"text", "blocks", "attachments"} {
+		if v, ok := values[k]; ok {
+			message[k] = v
+		}
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return "", err
+	}
+	return blockKitBuilderURL + url.PathEscape(string(data)), nil
+}
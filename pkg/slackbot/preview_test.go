@@ -0,0 +1,150 @@
+package slackbot_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slackbot"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPreview(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+
+	testCases := []struct {
+		name     string
+		kind     v1alpha1.NotifyKind
+		output   string
+		expected []string
+	}{
+		{
+			name:   "notified",
+			kind:   v1alpha1.NotifyKindAlways,
+			output: slackbot.PreviewOutputJSON,
+			expected: []string{
+				"matches the slack configuration for channel #builds",
+				"would create message in channel #builds via slack",
+				`"attachments"`,
+			},
+		},
+		{
+			name:   "url",
+			kind:   v1alpha1.NotifyKindAlways,
+			output: slackbot.PreviewOutputURL,
+			expected: []string{
+				"https://app.slack.com/block-kit-builder#",
+			},
+		},
+		{
+			name:   "rejected",
+			kind:   v1alpha1.NotifyKindFailure,
+			output: slackbot.PreviewOutputJSON,
+			expected: []string{
+				"will not be notified in channel #builds because the notify kind",
+				"no messages would be sent",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		sourceConfig := &v1alpha1.SourceConfig{
+			Spec: v1alpha1.SourceConfigSpec{
+				Groups: []v1alpha1.RepositoryGroup{
+					{
+						Provider: "https://fake.git",
+						Owner:    owner,
+						Repositories: []v1alpha1.Repository{
+							{
+								Name: repo,
+								Slack: &v1alpha1.SlackNotify{
+									Channel:  "#builds",
+									Kind:     tc.kind,
+									Pipeline: v1alpha1.PipelineKindAll,
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		pa := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", "1", jenkinsv1.ActivityStatusTypeRunning)
+		scmClient, _ := fakescm.NewDefault()
+
+		out := &bytes.Buffer{}
+		o := &slackbot.PreviewOptions{
+			ActivityName: pa.Name,
+			Output:       tc.output,
+			Out:          out,
+		}
+		o.KubeClient = fake.NewSimpleClientset()
+		o.JXClient = fakejx.NewSimpleClientset(pa)
+		o.ScmClient = scmClient
+		o.SourceConfigs = sourceConfig
+		o.Config = &slackbot.Config{}
+		o.Namespace = ns
+
+		err := o.Run()
+		require.NoError(t, err, "failed to preview %s for %s", pa.Name, tc.name)
+
+		text := out.String()
+		t.Logf("%s preview:\n%s\n", tc.name, text)
+		for _, expected := range tc.expected {
+			assert.Contains(t, text, expected, "preview output for %s", tc.name)
+		}
+	}
+}
+
+func TestPreviewFile(t *testing.T) {
+	owner := "myorg"
+	repo := "myrepo"
+	pa := testpipelines.CreateTestPipelineActivity("jx", owner, repo, "main", "release", "2", jenkinsv1.ActivityStatusTypeFailed)
+	file := filepath.Join(t.TempDir(), "activity.yaml")
+	err := yamls.SaveFile(pa, file)
+	require.NoError(t, err, "failed to save %s", file)
+	scmClient, _ := fakescm.NewDefault()
+
+	// there is no cluster so the history of the pipeline is skipped
+	out := &bytes.Buffer{}
+	o := &slackbot.PreviewOptions{
+		File: file,
+		Out:  out,
+	}
+	o.ScmClient = scmClient
+	o.SourceConfigs = &v1alpha1.SourceConfig{
+		Spec: v1alpha1.SourceConfigSpec{
+			Groups: []v1alpha1.RepositoryGroup{
+				{
+					Provider: "https://fake.git",
+					Owner:    owner,
+					Repositories: []v1alpha1.Repository{
+						{
+							Name: repo,
+							Slack: &v1alpha1.SlackNotify{
+								Channel:  "#builds",
+								Kind:     v1alpha1.NotifyKindAlways,
+								Pipeline: v1alpha1.PipelineKindAll,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	o.Config = &slackbot.Config{}
+
+	err = o.Run()
+	require.NoError(t, err, "failed to preview %s", file)
+	assert.Nil(t, o.JXClient, "should not create a JXClient")
+	assert.Contains(t, out.String(), "would create message in channel #builds via slack", "preview output")
+}
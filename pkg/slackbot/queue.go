@@ -0,0 +1,67 @@
+package slackbot
+
+const (
+	// handlerQueueSize the number of handlers which can be waiting before the informers block
+	handlerQueueSize = 100
+)
+
+// handlerQueue runs the handlers of the informers, the watchdog, SCM webhooks and slack requests one at a time on a single goroutine
+// as they share the message timestamps, caches and lazily created clients of the Options
+type handlerQueue struct {
+	handlers chan func()
+	stopper  chan struct{}
+}
+
+// startHandlerQueue starts processing the handlers until the stopper is closed. It must be called before any of the
+// goroutines which send handlers are started
+func (o *Options) startHandlerQueue(stopper chan struct{}) {
+	if o.handlers != nil {
+		return
+	}
+	q := &handlerQueue{
+		handlers: make(chan func(), handlerQueueSize),
+		stopper:  stopper,
+	}
+	o.handlers = q
+	go func() {
+		for {
+			select {
+			case <-q.stopper:
+				return
+			case handler := <-q.handlers:
+				handler()
+			}
+		}
+	}()
+}
+
+// serialize runs the handler on the handler queue or straight away if the queue has not been started
+func (o *Options) serialize(handler func()) {
+	q := o.handlers
+	if q == nil {
+		handler()
+		return
+	}
+	select {
+	case q.handlers <- handler:
+	case <-q.stopper:
+	}
+}
+
+// serializeAndWait runs the handler on the handler queue and waits for it to complete, e.g. to reply to a slash
+// command with its result
+func (o *Options) serializeAndWait(handler func()) {
+	done := make(chan struct{})
+	o.serialize(func() {
+		defer close(done)
+		handler()
+	})
+	q := o.handlers
+	if q == nil {
+		return
+	}
+	select {
+	case <-done:
+	case <-q.stopper:
+	}
+}
@@ -0,0 +1,301 @@
+package slackbot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	informers "github.com/jenkins-x/jx-api/v4/pkg/client/informers/externalversions"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+// maxReleaseChanges the maximum number of commits and issues shown in a release message
+const maxReleaseChanges = 10
+
+// environments the permanent Environments by namespace along with the namespaces whose Releases are watched
+type environments struct {
+	lock        sync.Mutex
+	byNamespace map[string]*jenkinsv1.Environment
+	watching    map[string]bool
+}
+
+// WatchEnvironments watches the Environments in the current namespace and the Releases in the namespace of each
+// permanent environment so that a message is posted when a new version of an app is promoted
+func (o *Options) WatchEnvironments(stopper chan struct{}) {
+	if o.environments == nil {
+		o.environments = &environments{}
+	}
+	log.Logger().Infof("Watching environments in namespace %s", o.Namespace)
+
+	factory := informers.NewSharedInformerFactoryWithOptions(o.JXClient, 0, informers.WithNamespace(o.Namespace))
+
+	informer := factory.Jenkins().V1().Environments().Informer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			o.onEnvironment(obj, stopper)
+		},
+		UpdateFunc: func(_ interface{}, newObj interface{}) {
+			o.onEnvironment(newObj, stopper)
+		},
+	})
+
+	go informer.Run(stopper)
+}
+
+func (o *Options) onEnvironment(obj interface{}, stopper chan struct{}) {
+	env, ok := obj.(*jenkinsv1.Environment)
+	if !ok {
+		log.Logger().Infof("Object is not an Environment %#v\n", obj)
+		return
+	}
+	ns := o.addEnvironment(env)
+	if ns == "" {
+		return
+	}
+	log.Logger().Infof("Watching releases in namespace %s of environment %s", ns, env.Name)
+
+	factory := informers.NewSharedInformerFactoryWithOptions(o.JXClient, 0, informers.WithNamespace(ns))
+
+	informer := factory.Jenkins().V1().Releases().Informer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			o.serialize(func() {
+				o.onRelease(obj)
+			})
+		},
+		UpdateFunc: func(_ interface{}, newObj interface{}) {
+			o.serialize(func() {
+				o.onRelease(newObj)
+			})
+		},
+	})
+
+	go informer.Run(stopper)
+}
+
+// addEnvironment remembers the environment returning its namespace if its releases are not yet watched
+func (o *Options) addEnvironment(env *jenkinsv1.Environment) string {
+	ns := env.Spec.Namespace
+	if ns == "" || env.Spec.RemoteCluster || env.Spec.Kind != jenkinsv1.EnvironmentKindTypePermanent {
+		return ""
+	}
+	e := o.environments
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.byNamespace == nil {
+		e.byNamespace = map[string]*jenkinsv1.Environment{}
+		e.watching = map[string]bool{}
+	}
+	e.byNamespace[ns] = env
+	if e.watching[ns] {
+		return ""
+	}
+	e.watching[ns] = true
+	return ns
+}
+
+// environmentForNamespace returns the permanent environment of the namespace if there is one
+func (o *Options) environmentForNamespace(ns string) *jenkinsv1.Environment {
+	e := o.environments
+	if e == nil {
+		return nil
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.byNamespace[ns]
+}
+
+func (o *Options) onRelease(obj interface{}) {
+	release, ok := obj.(*jenkinsv1.Release)
+	if !ok {
+		log.Logger().Infof("Object is not a Release %#v\n", obj)
+		return
+	}
+	err := o.ReleaseMessage(release)
+	if err != nil {
+		log.Logger().Warnf("%v\n", err)
+	}
+}
+
+// ReleaseMessage posts a message to the environment channels when a new version of an app is released into the
+// namespace of a permanent environment
+func (o *Options) ReleaseMessage(release *jenkinsv1.Release) error {
+	if o.Config == nil || len(o.Config.Environments) == 0 {
+		return nil
+	}
+	env := o.environmentForNamespace(release.Namespace)
+	if env == nil {
+		log.Logger().Debugf("ignoring release %s as namespace %s is not a permanent environment", release.Name, release.Namespace)
+		return nil
+	}
+
+	// lets ignore old releases such as when we first watch an environment
+	dayAgo := time.Now().Add((-24) * time.Hour)
+	if release.CreationTimestamp.Time.Before(dayAgo) && !o.IgnoreAgeCutoff {
+		return nil
+	}
+
+	options := o.createReleaseMessage(release, env)
+	for i := range o.Config.Environments {
+		e := &o.Config.Environments[i]
+		if e.Channel == "" || !e.Matches(env.Name) {
+			continue
+		}
+		connection, channel := SplitChannel(e.Channel)
+		channel = channelName(channel)
+		key := connectionAnnotationKey(connection, channel, releaseMessageType)
+		// lets remember the sent releases in memory too as the annotations are not saved in dry run mode and so
+		// that forcing new messages only posts each release once
+		timestampChannel := connectionChannel(connection, channel)
+		sentKey := releaseMessageType + ":" + release.Namespace + "/" + release.Name
+		if o.Timestamps[timestampChannel][sentKey] != nil || (release.Annotations[key] != "" && !o.ForceNewMessages) {
+			continue
+		}
+		bot, err := o.forConnection(connection)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find the slack connection for release %s", release.Name)
+		}
+		channelID, timestamp, _, err := bot.SlackClient.SendMessage(channel, options...)
+		if err != nil {
+			return errors.Wrapf(err, "failed to post release %s to channel %s", release.Name, e.Channel)
+		}
+		log.Logger().Infof("Release message for %s sent to %s\n", release.Name, e.Channel)
+		if o.Timestamps == nil {
+			o.Timestamps = map[string]map[string]*MessageReference{}
+		}
+		if o.Timestamps[timestampChannel] == nil {
+			o.Timestamps[timestampChannel] = map[string]*MessageReference{}
+		}
+		o.Timestamps[timestampChannel][sentKey] = &MessageReference{
+			ChannelID: channelID,
+			Timestamp: timestamp,
+		}
+		err = o.annotateRelease(context.TODO(), release, key, annotationValue(channelID, timestamp))
+		if err != nil {
+			return errors.Wrapf(err, "failed to annotate release %s", release.Name)
+		}
+	}
+	return nil
+}
+
+// createReleaseMessage creates the message describing the version of the app now in the environment along with
+// the commits and issues in the release
+func (o *Options) createReleaseMessage(release *jenkinsv1.Release, env *jenkinsv1.Environment) []slack.MsgOption {
+	spec := &release.Spec
+	app := spec.Name
+	if app == "" {
+		app = spec.GitRepository
+	}
+	envName := env.Spec.Label
+	if envName == "" {
+		envName = strings.Title(env.Name)
+	}
+	text := fmt.Sprintf("version %s of %s is now in %s", spec.Version, app, envName)
+	appText := app
+	if spec.GitHTTPURL != "" {
+		appText = link(app, spec.GitHTTPURL)
+	}
+	attachment := slack.Attachment{
+		CallbackID: "release:" + release.Name,
+		Color:      "good",
+		Title:      fmt.Sprintf(":rocket: version %s of %s is now in %s", spec.Version, appText, envName),
+		Fallback:   text,
+	}
+	if spec.ReleaseNotesURL != "" {
+		attachment.Actions = append(attachment.Actions, slack.AttachmentAction{
+			Type: "button",
+			Text: "Release Notes",
+			URL:  spec.ReleaseNotesURL,
+		})
+	}
+
+	var lines []string
+	for i, c := range spec.Commits {
+		if i >= maxReleaseChanges {
+			lines = append(lines, fmt.Sprintf("and %d more commits", len(spec.Commits)-maxReleaseChanges))
+			break
+		}
+		lines = append(lines, "• "+describeReleaseCommit(&c))
+	}
+	if len(lines) > 0 {
+		attachment.Fields = append(attachment.Fields, slack.AttachmentField{
+			Title: "Commits",
+			Value: strings.Join(lines, "\n"),
+		})
+	}
+
+	lines = nil
+	for i, issue := range spec.Issues {
+		if i >= maxReleaseChanges {
+			lines = append(lines, fmt.Sprintf("and %d more issues", len(spec.Issues)-maxReleaseChanges))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("• %s %s", link("#"+issue.ID, issue.URL), issue.Title))
+	}
+	if len(lines) > 0 {
+		attachment.Fields = append(attachment.Fields, slack.AttachmentField{
+			Title: "Issues",
+			Value: strings.Join(lines, "\n"),
+		})
+	}
+	return []slack.MsgOption{
+		slack.MsgOptionAttachments(attachment),
+	}
+}
+
+// describeReleaseCommit describes a commit of a release as its short SHA and first line of the message
+func describeReleaseCommit(c *jenkinsv1.CommitSummary) string {
+	sha := c.SHA
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	message := strings.TrimSpace(c.Message)
+	if idx := strings.Index(message, "\n"); idx > 0 {
+		message = message[:idx]
+	}
+	text := fmt.Sprintf("%s %s", link(sha, c.URL), message)
+	if c.Author != nil {
+		name := c.Author.Name
+		if name == "" {
+			name = c.Author.Login
+		}
+		if name != "" {
+			text += fmt.Sprintf(" (%s)", name)
+		}
+	}
+	return text
+}
+
+// annotateRelease annotates the release with the message reference so that we only post it once
+func (o *Options) annotateRelease(ctx context.Context, release *jenkinsv1.Release, key string, value string) error {
+	if o.DryRun {
+		log.Logger().Infof("dry run: would annotate release %s with %s=%s", release.Name, key, value)
+		return nil
+	}
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				key: value,
+			},
+		},
+	}
+	jsonPatch, err := json.Marshal(patch)
+	if err != nil {
+		return errors.Wrapf(err, "marshaling patch to add annotation %s=%s to %s", key, value, release.Name)
+	}
+	_, err = o.JXClient.JenkinsV1().Releases(release.Namespace).Patch(ctx, release.Name, types.MergePatchType,
+		jsonPatch, metav1.PatchOptions{})
+	return err
+}
@@ -0,0 +1,126 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReleaseMessage(t *testing.T) {
+	ns := "jx"
+	stagingNS := "jx-staging"
+
+	release := &jenkinsv1.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "myapp-1.2.3",
+			Namespace:         stagingNS,
+			CreationTimestamp: metav1.NewTime(time.Now()),
+		},
+		Spec: jenkinsv1.ReleaseSpec{
+			Name:            "myapp",
+			Version:         "1.2.3",
+			GitOwner:        "myorg",
+			GitRepository:   "myapp",
+			GitHTTPURL:      "https://github.com/myorg/myapp",
+			ReleaseNotesURL: "https://github.com/myorg/myapp/releases/tag/v1.2.3",
+			Commits: []jenkinsv1.CommitSummary{
+				{
+					Message: "fix: the thing\n\nsome details",
+					SHA:     "1234567890abcdef",
+					URL:     "https://github.com/myorg/myapp/commit/1234567890abcdef",
+					Author: &jenkinsv1.UserDetails{
+						Login: "someone",
+					},
+				},
+			},
+			Issues: []jenkinsv1.IssueSummary{
+				{
+					ID:    "42",
+					URL:   "https://github.com/myorg/myapp/issues/42",
+					Title: "the thing is broken",
+				},
+			},
+		},
+	}
+	ignored := release.DeepCopy()
+	ignored.Name = "myapp-1.2.4"
+	ignored.Namespace = "somewhere-else"
+
+	jxClient := fakejx.NewSimpleClientset(release, ignored)
+	fakeSlackClient := fakeslack.NewFakeSlack()
+	o := &Options{
+		JXClient:    jxClient,
+		SlackClient: fakeSlackClient,
+		Config: &Config{
+			Environments: []EnvironmentNotify{
+				{
+					Channel: "#deployments",
+				},
+				{
+					Channel:      "#production",
+					Environments: []string{"production"},
+				},
+			},
+		},
+		environments: &environments{},
+	}
+	o.Namespace = ns
+
+	o.addEnvironment(&jenkinsv1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "staging",
+			Namespace: ns,
+		},
+		Spec: jenkinsv1.EnvironmentSpec{
+			Label:     "Staging",
+			Namespace: stagingNS,
+			Kind:      jenkinsv1.EnvironmentKindTypePermanent,
+		},
+	})
+
+	for _, r := range []*jenkinsv1.Release{release, ignored} {
+		err := o.ReleaseMessage(r)
+		require.NoError(t, err, "failed to process release %s", r.Name)
+	}
+
+	messages := fakeSlackClient.Messages["#deployments"]
+	require.Len(t, messages, 1, "messages in #deployments")
+	assert.Empty(t, fakeSlackClient.Messages["#production"], "messages in #production")
+
+	_, values, err := slack.UnsafeApplyMsgOptions("", "#deployments", "", messages[0].Options...)
+	require.NoError(t, err, "failed to apply message options")
+	attachments := values.Get("attachments")
+	t.Logf("release message attachments: %s\n", attachments)
+	assert.Contains(t, attachments, "version 1.2.3 of myapp is now in Staging")
+	assert.Contains(t, attachments, "1234567")
+	assert.Contains(t, attachments, "fix: the thing (someone)")
+	assert.Contains(t, attachments, "the thing is broken")
+
+	// the annotation stops the message being posted again
+	updated, err := jxClient.JenkinsV1().Releases(stagingNS).Get(context.TODO(), release.Name, metav1.GetOptions{})
+	require.NoError(t, err, "failed to find release %s", release.Name)
+	err = o.ReleaseMessage(updated)
+	require.NoError(t, err, "failed to process release %s", release.Name)
+	assert.Len(t, fakeSlackClient.Messages["#deployments"], 1, "messages in #deployments")
+
+	// in dry run mode and when forcing new messages the release is remembered in memory
+	dryRun := release.DeepCopy()
+	dryRun.Name = "myapp-1.2.5"
+	_, err = jxClient.JenkinsV1().Releases(stagingNS).Create(context.TODO(), dryRun, metav1.CreateOptions{})
+	require.NoError(t, err, "failed to create release %s", dryRun.Name)
+	o.DryRun = true
+	o.ForceNewMessages = true
+	for i := 0; i < 2; i++ {
+		err = o.ReleaseMessage(dryRun)
+		require.NoError(t, err, "failed to process release %s", dryRun.Name)
+		assert.Len(t, fakeSlackClient.Messages["#deployments"], 2, "messages in #deployments")
+	}
+}
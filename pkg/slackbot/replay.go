@@ -0,0 +1,137 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReplayOptions options for resending the messages of historical PipelineActivities
+type ReplayOptions struct {
+	Options
+	Selector string
+	Since    time.Duration
+	From     string
+	To       string
+	Out      io.Writer
+
+	from time.Time
+	to   time.Time
+}
+
+// Validate parses the time window and configures the clients
+func (o *ReplayOptions) Validate() error {
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	var err error
+	if o.From != "" {
+		o.from, err = time.Parse(time.RFC3339, o.From)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse from time %s", o.From)
+		}
+	}
+	if o.To != "" {
+		o.to, err = time.Parse(time.RFC3339, o.To)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse to time %s", o.To)
+		}
+	}
+	if o.Since > 0 {
+		if !o.from.IsZero() {
+			return errors.Errorf("cannot specify both a since duration and a from time")
+		}
+		o.from = time.Now().Add(-o.Since)
+	}
+	if o.Selector == "" && o.from.IsZero() && o.to.IsZero() {
+		return errors.Errorf("please specify a label selector or a time window to replay")
+	}
+	return o.Options.Validate()
+}
+
+// Run sends the messages of the matching PipelineActivities in build number order
+func (o *ReplayOptions) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+	activities, err := o.FindActivities(context.TODO())
+	if err != nil {
+		return err
+	}
+	if len(activities) == 0 {
+		fmt.Fprintln(o.Out, "no PipelineActivities match")
+		return nil
+	}
+
+	failed := 0
+	for i := range activities {
+		activity := &activities[i]
+		fmt.Fprintf(o.Out, "replaying %s\n", activity.Name)
+		err = o.PipelineMessage(activity)
+		if err != nil {
+			log.Logger().Warnf("failed to replay pipeline message for %s: %s", activity.Name, err.Error())
+			failed++
+			continue
+		}
+		err = o.ReviewRequestMessage(activity)
+		if err != nil {
+			log.Logger().Warnf("failed to replay review request message for %s: %s", activity.Name, err.Error())
+			failed++
+		}
+	}
+	fmt.Fprintf(o.Out, "replayed %d PipelineActivities\n", len(activities)-failed)
+	if failed > 0 {
+		return errors.Errorf("failed to replay %d PipelineActivities", failed)
+	}
+	return nil
+}
+
+// FindActivities returns the PipelineActivities matching the selector and time window sorted by pipeline and
+// build number
+func (o *ReplayOptions) FindActivities(ctx context.Context) ([]jenkinsv1.PipelineActivity, error) {
+	list, err := o.JXClient.JenkinsV1().PipelineActivities(o.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: o.Selector,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list PipelineActivities in namespace %s with selector '%s'", o.Namespace, o.Selector)
+	}
+	var answer []jenkinsv1.PipelineActivity
+	for _, a := range list.Items {
+		started := activityStartTime(&a)
+		if !o.from.IsZero() && started.Before(o.from) {
+			continue
+		}
+		if !o.to.IsZero() && started.After(o.to) {
+			continue
+		}
+		answer = append(answer, a)
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		pi, pj := answer[i].Spec.Pipeline, answer[j].Spec.Pipeline
+		if pi != pj {
+			return pi < pj
+		}
+		bi, _ := strconv.Atoi(answer[i].Spec.Build)
+		bj, _ := strconv.Atoi(answer[j].Spec.Build)
+		return bi < bj
+	})
+	return answer, nil
+}
+
+// activityStartTime returns the time the pipeline started falling back to when the activity was created
+func activityStartTime(activity *jenkinsv1.PipelineActivity) time.Time {
+	if activity.Spec.StartedTimestamp != nil {
+		return activity.Spec.StartedTimestamp.Time
+	}
+	return activity.CreationTimestamp.Time
+}
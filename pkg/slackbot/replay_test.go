@@ -0,0 +1,197 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/dryrun"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	"github.com/jenkins-x/go-scm/scm"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReplayFindActivities(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+	createActivity := func(build string, age time.Duration) *jenkinsv1.PipelineActivity {
+		pa := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "main", "release", build, jenkinsv1.ActivityStatusTypeSucceeded)
+		pa.CreationTimestamp = metav1.NewTime(now.Add(-age))
+		return pa
+	}
+
+	o := &ReplayOptions{}
+	o.JXClient = fakejx.NewSimpleClientset(
+		createActivity("10", time.Hour),
+		createActivity("2", 2*time.Hour),
+		createActivity("1", 3*time.Hour),
+		createActivity("3", 48*time.Hour),
+	)
+	o.Namespace = ns
+	o.from = now.Add(-24 * time.Hour)
+
+	activities, err := o.FindActivities(context.TODO())
+	require.NoError(t, err, "failed to find activities")
+
+	var builds []string
+	for _, a := range activities {
+		builds = append(builds, a.Spec.Build)
+	}
+	assert.Equal(t, []string{"1", "2", "10"}, builds, "builds in the time window in build number order")
+}
+
+func TestReplayMessageOptions(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+	channel := "#builds"
+
+	sourceConfig := &v1alpha1.SourceConfig{
+		Spec: v1alpha1.SourceConfigSpec{
+			Groups: []v1alpha1.RepositoryGroup{
+				{
+					Provider: "https://fake.git",
+					Owner:    owner,
+					Repositories: []v1alpha1.Repository{
+						{
+							Name: repo,
+							Slack: &v1alpha1.SlackNotify{
+								Channel:  channel,
+								Kind:     v1alpha1.NotifyKindAlways,
+								Pipeline: v1alpha1.PipelineKindAll,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		annotated       bool
+		forceNew        bool
+		ignoreAgeCutoff bool
+		expectedMethod  string
+	}{
+		{
+			name: "old-activity-ignored",
+		},
+		{
+			name:            "ignore-age-cutoff",
+			ignoreAgeCutoff: true,
+			expectedMethod:  dryrun.MethodPostMessage,
+		},
+		{
+			name:           "update-existing",
+			annotated:      true,
+			expectedMethod: dryrun.MethodUpdate,
+		},
+		{
+			name:           "force-new",
+			annotated:      true,
+			forceNew:       true,
+			expectedMethod: dryrun.MethodPostMessage,
+		},
+	}
+
+	for _, tc := range testCases {
+		pa := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+		pa.CreationTimestamp = metav1.NewTime(time.Now().Add(-48 * time.Hour))
+		if tc.annotated {
+			pa.Annotations = map[string]string{
+				annotationKey(channel, pipelineMessageType): annotationValue("C1234", "1234.5678"),
+			}
+		}
+		scmClient, _ := fakescm.NewDefault()
+
+		client := dryrun.NewClient(nil)
+		o := &Options{
+			KubeClient:       fake.NewSimpleClientset(),
+			JXClient:         fakejx.NewSimpleClientset(pa),
+			ScmClient:        scmClient,
+			SlackClient:      client,
+			SourceConfigs:    sourceConfig,
+			DryRun:           true,
+			ForceNewMessages: tc.forceNew,
+			IgnoreAgeCutoff:  tc.ignoreAgeCutoff,
+		}
+		o.Namespace = ns
+
+		err := o.PipelineMessage(pa)
+		require.NoError(t, err, "failed to process pipeline %s for %s", pa.Name, tc.name)
+
+		if tc.expectedMethod == "" {
+			assert.Empty(t, client.Messages, "messages for %s", tc.name)
+			continue
+		}
+		require.Len(t, client.Messages, 1, "messages for %s", tc.name)
+		assert.Equal(t, tc.expectedMethod, client.Messages[0].Method, "message method for %s", tc.name)
+	}
+}
+
+func TestReplayForceNewReviewMessage(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+	channel := "#builds"
+
+	var activities []*jenkinsv1.PipelineActivity
+	var objects []runtime.Object
+	for _, pipelineCtx := range []string{"pr-build", "lint"} {
+		for _, build := range []string{"1", "2", "3"} {
+			pa := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "PR-1", pipelineCtx, build, jenkinsv1.ActivityStatusTypeSucceeded)
+			// the messages of the previous run should be ignored
+			pa.Annotations = map[string]string{
+				annotationKey(channel, pullRequestReviewMessageType): annotationValue("C1234", "1234.5678"),
+			}
+			activities = append(activities, pa)
+			objects = append(objects, pa)
+		}
+	}
+	jxClient := fakejx.NewSimpleClientset(objects...)
+	scmClient, scmData := fakescm.NewDefault()
+	scmData.PullRequests[1] = &scm.PullRequest{
+		Number: 1,
+		Title:  "my pull request",
+		Link:   "https://fake.git/myorg/myrepo/pull/1",
+		Author: scm.User{Login: "author", Name: "Author"},
+	}
+
+	client := dryrun.NewClient(nil)
+	o := &Options{
+		KubeClient:        fake.NewSimpleClientset(),
+		JXClient:          jxClient,
+		ScmClient:         scmClient,
+		SlackClient:       client,
+		SlackUserResolver: NewSlackUserResolver(client, jxClient, ns),
+		SourceConfigs:     createNamespaceSourceConfig(owner, repo, channel),
+		DryRun:            true,
+		ForceNewMessages:  true,
+		IgnoreAgeCutoff:   true,
+	}
+	o.Namespace = ns
+
+	for _, pa := range activities {
+		err := o.ReviewRequestMessage(pa)
+		require.NoError(t, err, "failed to replay the review message of %s", pa.Name)
+	}
+
+	var methods []string
+	for _, m := range client.Messages {
+		methods = append(methods, m.Method)
+	}
+	require.NotEmpty(t, methods, "should send the review message")
+	assert.Equal(t, dryrun.MethodPostMessage, methods[0], "should create a new review message")
+	for _, method := range methods[1:] {
+		assert.Equal(t, dryrun.MethodUpdate, method, "should update the review message created by the replay")
+	}
+}
@@ -1,15 +1,16 @@
 package slackbot
 
 import (
+	"context"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/dryrun"
 	"github.com/jenkins-x/go-scm/scm/factory"
 	"github.com/jenkins-x/jx-gitops/pkg/sourceconfigs"
-	"github.com/jenkins-x/jx-gitops/pkg/variablefinders"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/jxclient"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/services"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/requirements"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/pkg/errors"
 	"github.com/slack-go/slack"
@@ -48,6 +49,15 @@ func (o *Options) Validate() error {
 		}
 	}
 	o.SlackUserResolver = NewSlackUserResolver(o.SlackClient, o.JXClient, o.Namespace)
+	if o.Preferences == nil {
+		o.Preferences = NewPreferenceStore(o.KubeClient, o.Namespace)
+	}
+	if o.PipelineStates == nil {
+		o.PipelineStates = NewPipelineStateStore(o.KubeClient, o.Namespace)
+	}
+	if o.EnvironmentStatus == nil {
+		o.EnvironmentStatus = NewEnvironmentStatusStore(o.KubeClient, o.Namespace)
+	}
 
 	if o.Dir == "" {
 		if o.GitClient == nil {
@@ -55,19 +65,9 @@ func (o *Options) Validate() error {
 		}
 
 		if o.GitURL == "" {
-			req, err := variablefinders.FindRequirements(o.GitClient, o.JXClient, o.Namespace, o.Dir, "", "")
+			o.GitURL, err = o.devEnvironmentGitURL(o.Namespace)
 			if err != nil {
-				return errors.Wrapf(err, "failed to load requirements from dev environment")
-			}
-
-			if req == nil {
-				return errors.Errorf("no Requirements in TeamSettings of dev environment in namespace %s", o.Namespace)
-			}
-
-			// lets override the dev git URL if its changed in the requirements via the .jx/settings.yaml file
-			o.GitURL = requirements.EnvironmentGitURL(req, "dev")
-			if o.GitURL == "" {
-				return errors.Errorf("could not find development environment git URL from requirements and no $GIT_URL specified")
+				return errors.Wrapf(err, "no $GIT_URL specified")
 			}
 		}
 		o.Dir, err = gitclient.CloneToDir(o.GitClient, o.GitURL, "")
@@ -79,6 +79,23 @@ func (o *Options) Validate() error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to load source configs from dir %s", o.Dir)
 	}
+	o.repositoryWatchdogs, err = LoadRepositoryWatchdogs(o.Dir)
+	if err != nil {
+		return err
+	}
+	if o.Config == nil {
+		o.Config, err = LoadConfig(o.Dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load jx-slack config from dir %s", o.Dir)
+		}
+	}
+	err = o.createConnections(context.TODO())
+	if err != nil {
+		return errors.Wrapf(err, "failed to create slack connections")
+	}
+	if o.DryRun {
+		o.enableDryRun()
+	}
 
 	// lets find the dashboard URL
 	if o.MessageFormat.DashboardURL == "" {
@@ -99,6 +116,24 @@ func (o *Options) Validate() error {
 	return nil
 }
 
+// enableDryRun replaces the clients which send messages with ones which log the messages instead
+func (o *Options) enableDryRun() {
+	log.Logger().Infof("dry run mode enabled so messages are logged rather than sent and PipelineActivities are not annotated")
+	o.SlackClient = dryrun.NewClient(o.SlackClient)
+	for name, c := range o.Connections {
+		o.Connections[name] = dryrun.NewClient(c)
+	}
+	for name := range o.Notifiers {
+		o.Notifiers[name] = dryrun.NewNotifier(name)
+	}
+	for name := range o.Webhooks {
+		o.Webhooks[name] = dryrun.NewNotifier("webhook " + name)
+	}
+	for name := range o.Emails {
+		o.Emails[name] = dryrun.NewNotifier("email " + name)
+	}
+}
+
 func (o *Options) Run() error {
 	defer runtime.HandleCrash()
 
@@ -107,7 +142,33 @@ func (o *Options) Run() error {
 		return errors.Wrapf(err, "failed to validate options")
 	}
 
-	log.Logger().Infof("Watching slackbots in namespace %s\n", o.Namespace)
+	// the SCM webhooks are processed on the same queue as the PipelineActivity events which runs for the lifetime
+	// of the process
+	o.startHandlerQueue(make(chan struct{}))
+	o.startWebhookQueues()
+
+	serve := o.HMACToken != ""
+	switch {
+	case o.AppToken != "":
+		go func() {
+			err := o.RunSocketMode(context.Background())
+			if err != nil {
+				log.Logger().Errorf("failed to run slack socket mode: %s", err.Error())
+			}
+		}()
+	case o.SigningSecret != "":
+		serve = true
+	default:
+		log.Logger().Infof("no $SLACK_APP_TOKEN or $SLACK_SIGNING_SECRET defined so not listening for slack commands and events")
+	}
+	if serve {
+		go func() {
+			err := o.Serve()
+			if err != nil {
+				log.Logger().Errorf("failed to serve slack commands and SCM webhooks: %s", err.Error())
+			}
+		}()
+	}
 
 	o.WatchActivities()
 	return nil
@@ -0,0 +1,201 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ScmWebhookPath the HTTP path the git provider posts webhooks to
+	ScmWebhookPath = "/scm/webhook"
+
+	defaultRequiredApprovals = 1
+)
+
+func (o *Options) handleScmWebhookRequest(w http.ResponseWriter, r *http.Request) {
+	if o.HMACToken == "" || o.ScmClient == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	hook, err := o.ScmClient.Webhooks.Parse(r, func(scm.Webhook) (string, error) {
+		return o.HMACToken, nil
+	})
+	if err != nil {
+		log.Logger().Warnf("failed to parse SCM webhook: %s", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	// lets respond before queueing the webhook so that it is processed in the order the git provider sent it
+	replyOK(w)
+	o.serialize(func() {
+		err := o.HandleScmWebhook(context.TODO(), hook)
+		if err != nil {
+			log.Logger().Warnf("failed to process SCM webhook: %s", err.Error())
+		}
+	})
+}
+
+// HandleScmWebhook updates the review message of the pull request of a review, label, comment, merge or close event
+// without waiting for a PipelineActivity to change
+func (o *Options) HandleScmWebhook(ctx context.Context, hook scm.Webhook) error {
+	repo, prn, ok := webhookPullRequest(hook)
+	if !ok {
+		log.Logger().Debugf("ignoring SCM webhook %T which is not for a pull request", hook)
+		return nil
+	}
+	activity, err := o.latestPullRequestActivity(ctx, repo.Namespace, repo.Name, prn)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the PipelineActivities of %s PR %d", repo.FullName, prn)
+	}
+	if activity == nil {
+		log.Logger().Debugf("no PipelineActivity found for %s/%s PR %d", repo.Namespace, repo.Name, prn)
+		return nil
+	}
+	return o.ReviewRequestMessage(activity)
+}
+
+// webhookPullRequest returns the repository and number of the pull request the webhook is about
+func webhookPullRequest(hook scm.Webhook) (scm.Repository, int, bool) {
+	switch h := hook.(type) {
+	case *scm.ReviewHook:
+		return h.Repo, h.PullRequest.Number, true
+	case *scm.PullRequestHook:
+		return h.Repo, h.PullRequest.Number, true
+	case *scm.PullRequestCommentHook:
+		return h.Repo, h.PullRequest.Number, true
+	case *scm.IssueCommentHook:
+		return h.Repo, h.Issue.Number, h.Issue.PullRequest
+	default:
+		return scm.Repository{}, 0, false
+	}
+}
+
+// latestPullRequestActivity returns the most recently created PipelineActivity of the pull request in the watched
+// namespaces or nil if there is none
+func (o *Options) latestPullRequestActivity(ctx context.Context, owner, repo string, prn int) (*jenkinsv1.PipelineActivity, error) {
+	var answer *jenkinsv1.PipelineActivity
+	for _, ns := range o.watchNamespaces() {
+		list, err := o.getPipelineActivities(ctx, ns, owner, repo, prn)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list PipelineActivities in namespace %s", ns)
+		}
+		for i := range list.Items {
+			a := &list.Items[i]
+			if answer == nil || answer.CreationTimestamp.Before(&a.CreationTimestamp) {
+				answer = a
+			}
+		}
+	}
+	return answer, nil
+}
+
+// Approvals the reviewers who have approved a pull request and the number of approvals it needs
+type Approvals struct {
+	Approvers []string
+	Required  int
+}
+
+// Remaining returns the number of approvals still required
+func (a *Approvals) Remaining() int {
+	if len(a.Approvers) >= a.Required {
+		return 0
+	}
+	return a.Required - len(a.Approvers)
+}
+
+// Describe describes the approvals such as 'Approved by alice, 1 more approval required'
+func (a *Approvals) Describe() string {
+	var parts []string
+	if len(a.Approvers) > 0 {
+		parts = append(parts, "Approved by "+strings.Join(a.Approvers, ", "))
+	}
+	switch remaining := a.Remaining(); remaining {
+	case 0:
+	case 1:
+		parts = append(parts, fmt.Sprintf("%d%s approval required", remaining, moreText(a)))
+	default:
+		parts = append(parts, fmt.Sprintf("%d%s approvals required", remaining, moreText(a)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func moreText(a *Approvals) string {
+	if len(a.Approvers) > 0 {
+		return " more"
+	}
+	return ""
+}
+
+// pullRequestApprovals returns the approvals of the pull request or nil if the reviews cannot be listed
+func (o *Options) pullRequestApprovals(ctx context.Context, activity *jenkinsv1.PipelineActivity, pr *scm.PullRequest) *Approvals {
+	if o.ScmClient == nil || pr == nil || pr.Number <= 0 {
+		return nil
+	}
+	details := CreatePipelineDetails(activity)
+	fullName := scm.Join(details.GitOwner, details.GitRepository)
+	reviews, _, err := o.ScmClient.Reviews.List(ctx, fullName, pr.Number, scm.ListOptions{Size: 100})
+	if err != nil {
+		log.Logger().Warnf("failed to list the reviews of %s PR %d: %s", fullName, pr.Number, err.Error())
+		return nil
+	}
+	return &Approvals{
+		Approvers: approvers(reviews),
+		Required:  o.requiredApprovals(activity),
+	}
+}
+
+// approvers returns the sorted logins of the reviewers whose latest review approved the pull request
+func approvers(reviews []*scm.Review) []string {
+	latest := map[string]*scm.Review{}
+	for _, r := range reviews {
+		login := r.Author.Login
+		if login == "" {
+			continue
+		}
+		// comments do not change the state of an earlier approval or request for changes
+		if strings.EqualFold(r.State, "commented") {
+			continue
+		}
+		if current := latest[login]; current == nil || !r.Created.Before(current.Created) {
+			latest[login] = r
+		}
+	}
+	var answer []string
+	for login, r := range latest {
+		if strings.EqualFold(r.State, "approved") {
+			answer = append(answer, login)
+		}
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// requiredApprovals returns the number of approvals the pull requests of the repository of the activity need
+func (o *Options) requiredApprovals(activity *jenkinsv1.PipelineActivity) int {
+	if o.Config == nil || o.Config.Reviews == nil {
+		return defaultRequiredApprovals
+	}
+	reviews := o.Config.Reviews
+	for i := range reviews.Repositories {
+		r := &reviews.Repositories[i]
+		if matchesRepository(r.Repositories, activity) {
+			if r.RequiredApprovals > 0 {
+				return r.RequiredApprovals
+			}
+			// an override without a number uses the default of the reviews
+			break
+		}
+	}
+	if reviews.RequiredApprovals > 0 {
+		return reviews.RequiredApprovals
+	}
+	return defaultRequiredApprovals
+}
@@ -0,0 +1,159 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	"github.com/jenkins-x/go-scm/scm"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWebhookPullRequest(t *testing.T) {
+	repo := scm.Repository{Namespace: "myorg", Name: "myrepo"}
+	testCases := []struct {
+		name     string
+		hook     scm.Webhook
+		expected int
+		ok       bool
+	}{
+		{
+			name:     "review",
+			hook:     &scm.ReviewHook{Repo: repo, PullRequest: scm.PullRequest{Number: 1}},
+			expected: 1,
+			ok:       true,
+		},
+		{
+			name:     "label",
+			hook:     &scm.PullRequestHook{Action: scm.ActionLabel, Repo: repo, PullRequest: scm.PullRequest{Number: 2}},
+			expected: 2,
+			ok:       true,
+		},
+		{
+			name:     "pull request comment",
+			hook:     &scm.PullRequestCommentHook{Repo: repo, PullRequest: scm.PullRequest{Number: 3}},
+			expected: 3,
+			ok:       true,
+		},
+		{
+			name:     "issue comment on a pull request",
+			hook:     &scm.IssueCommentHook{Repo: repo, Issue: scm.Issue{Number: 4, PullRequest: true}},
+			expected: 4,
+			ok:       true,
+		},
+		{
+			name: "issue comment",
+			hook: &scm.IssueCommentHook{Repo: repo, Issue: scm.Issue{Number: 5}},
+		},
+		{
+			name: "push",
+			hook: &scm.PushHook{Repo: repo},
+		},
+	}
+	for _, tc := range testCases {
+		r, prn, ok := webhookPullRequest(tc.hook)
+		assert.Equal(t, tc.ok, ok, "%s is for a pull request", tc.name)
+		if tc.ok {
+			assert.Equal(t, tc.expected, prn, "%s pull request number", tc.name)
+			assert.Equal(t, "myrepo", r.Name, "%s repository", tc.name)
+		}
+	}
+}
+
+func TestApprovals(t *testing.T) {
+	now := time.Now()
+	review := func(login, state string, minutes int) *scm.Review {
+		return &scm.Review{
+			State:   state,
+			Author:  scm.User{Login: login},
+			Created: now.Add(time.Duration(minutes) * time.Minute),
+		}
+	}
+	reviews := []*scm.Review{
+		review("bob", "APPROVED", 1),
+		review("alice", "CHANGES_REQUESTED", 2),
+		review("alice", "APPROVED", 3),
+		review("carol", "APPROVED", 4),
+		review("carol", "CHANGES_REQUESTED", 5),
+		review("bob", "COMMENTED", 6),
+	}
+	assert.Equal(t, []string{"alice", "bob"}, approvers(reviews), "approvers")
+
+	a := &Approvals{Approvers: []string{"alice"}, Required: 2}
+	assert.Equal(t, 1, a.Remaining(), "remaining")
+	assert.Equal(t, "Approved by alice, 1 more approval required", a.Describe())
+
+	a = &Approvals{Required: 2}
+	assert.Equal(t, "2 approvals required", a.Describe())
+
+	a = &Approvals{Approvers: []string{"alice", "bob"}, Required: 1}
+	assert.Equal(t, 0, a.Remaining(), "remaining")
+	assert.Equal(t, "Approved by alice, bob", a.Describe())
+}
+
+func TestRequiredApprovals(t *testing.T) {
+	activity := testpipelines.CreateTestPipelineActivity("jx", "myorg", "myrepo", "PR-1", "pr", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+	o := &Options{}
+	assert.Equal(t, 1, o.requiredApprovals(activity), "default")
+
+	o.Config = &Config{
+		Reviews: &Reviews{
+			RequiredApprovals: 2,
+			Repositories: []RequiredApprovals{
+				{
+					Repositories:      []string{"myorg/critical-*"},
+					RequiredApprovals: 3,
+				},
+			},
+		},
+	}
+	assert.Equal(t, 2, o.requiredApprovals(activity), "configured")
+
+	critical := testpipelines.CreateTestPipelineActivity("jx", "myorg", "critical-service", "PR-1", "pr", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+	assert.Equal(t, 3, o.requiredApprovals(critical), "repository override")
+
+	o.Config.Reviews.Repositories = append([]RequiredApprovals{{Repositories: []string{"myorg/myrepo"}}}, o.Config.Reviews.Repositories...)
+	assert.Equal(t, 2, o.requiredApprovals(activity), "repository override without a number")
+
+	o.Config.Reviews.RequiredApprovals = 0
+	assert.Equal(t, 1, o.requiredApprovals(activity), "repository override without a number or configured default")
+}
+
+func TestLatestPullRequestActivity(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+	create := func(pipelineCtx, build string, age time.Duration) *jenkinsv1.PipelineActivity {
+		pa := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "PR-1", pipelineCtx, build, jenkinsv1.ActivityStatusTypeSucceeded)
+		pa.CreationTimestamp = metav1.NewTime(now.Add(-age))
+		return pa
+	}
+	lint := create("lint", "3", 2*time.Hour)
+	build := create("pr-build", "1", time.Hour)
+	otherPR := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "PR-2", "lint", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+
+	o := &Options{
+		JXClient: fakejx.NewSimpleClientset(lint, build, otherPR),
+	}
+	o.Namespace = ns
+
+	ctx := context.TODO()
+	activity, err := o.latestPullRequestActivity(ctx, "myorg", "myrepo", 1)
+	require.NoError(t, err, "failed to find activity")
+	require.NotNil(t, activity, "activity")
+	assert.Equal(t, build.Name, activity.Name, "the most recently created activity")
+
+	activity, err = o.latestPullRequestActivity(ctx, "myorg", "myrepo", 3)
+	require.NoError(t, err, "failed to find activity")
+	assert.Nil(t, activity, "unknown pull request")
+
+	err = o.HandleScmWebhook(ctx, &scm.ReviewHook{
+		Repo:        scm.Repository{Namespace: "myorg", Name: "myrepo"},
+		PullRequest: scm.PullRequest{Number: 3},
+	})
+	assert.NoError(t, err, "a review of a pull request without pipelines should be ignored")
+}
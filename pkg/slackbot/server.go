@@ -0,0 +1,181 @@
+package slackbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+const (
+	// CommandsPath the HTTP path slack posts slash commands to
+	CommandsPath = "/slack/commands"
+
+	// EventsPath the HTTP path slack posts events API callbacks to
+	EventsPath = "/slack/events"
+
+	// InteractionsPath the HTTP path slack posts interactions such as button clicks to
+	InteractionsPath = "/slack/interactions"
+
+	defaultPort = 8080
+)
+
+// Serve runs the HTTP server which receives slash commands and events from slack and webhooks from the git provider
+func (o *Options) Serve() error {
+	port := o.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	address := fmt.Sprintf(":%d", port)
+	log.Logger().Infof("listening for slack commands, events, interactions and SCM webhooks on %s", address)
+	return http.ListenAndServe(address, o.serveMux())
+}
+
+// serveMux creates the handler of the HTTP server. The slack handlers are only registered if there is a signing
+// secret to verify the requests with
+func (o *Options) serveMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	if o.SigningSecret != "" {
+		mux.HandleFunc(CommandsPath, o.handleCommandRequest)
+		mux.HandleFunc(EventsPath, o.handleEventRequest)
+		mux.HandleFunc(InteractionsPath, o.handleInteractionRequest)
+	} else {
+		log.Logger().Infof("no $SLACK_SIGNING_SECRET defined so not listening for slack commands and events over HTTP")
+	}
+	mux.HandleFunc(ScmWebhookPath, o.handleScmWebhookRequest)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+func (o *Options) handleCommandRequest(w http.ResponseWriter, r *http.Request) {
+	if _, ok := o.verifyRequest(w, r); !ok {
+		return
+	}
+	cmd, err := slack.SlashCommandParse(r)
+	if err != nil {
+		log.Logger().Warnf("failed to parse slash command: %s", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var msg *slack.Msg
+	o.serializeAndWait(func() {
+		msg, err = o.HandleCommand(&cmd)
+	})
+	if err != nil {
+		log.Logger().Warnf("failed to process command %s %s from %s: %s", cmd.Command, cmd.Text, cmd.UserID, err.Error())
+		msg = ephemeralMessage("Sorry, something went wrong processing your command")
+	}
+	err = writeJSON(w, msg)
+	if err != nil {
+		log.Logger().Warnf("failed to reply to command %s from %s: %s", cmd.Command, cmd.UserID, err.Error())
+	}
+}
+
+func (o *Options) handleEventRequest(w http.ResponseWriter, r *http.Request) {
+	body, ok := o.verifyRequest(w, r)
+	if !ok {
+		return
+	}
+	event, err := slackevents.ParseEvent(json.RawMessage(body), slackevents.OptionNoVerifyToken())
+	if err != nil {
+		log.Logger().Warnf("failed to parse slack event: %s", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if event.Type == slackevents.URLVerification {
+		challenge, ok := event.Data.(*slackevents.EventsAPIURLVerificationEvent)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, err = w.Write([]byte(challenge.Challenge))
+		if err != nil {
+			log.Logger().Warnf("failed to reply to the slack URL verification: %s", err.Error())
+		}
+		return
+	}
+
+	// slack expects a reply within 3 seconds so lets reply before the event is processed on the handler queue
+	replyOK(w)
+	o.serialize(func() {
+		o.HandleEvent(&event)
+	})
+}
+
+func (o *Options) handleInteractionRequest(w http.ResponseWriter, r *http.Request) {
+	if _, ok := o.verifyRequest(w, r); !ok {
+		return
+	}
+	callback := &slack.InteractionCallback{}
+	err := json.Unmarshal([]byte(r.FormValue("payload")), callback)
+	if err != nil {
+		log.Logger().Warnf("failed to parse slack interaction: %s", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	replyOK(w)
+	o.serialize(func() {
+		o.HandleInteraction(callback)
+	})
+}
+
+// replyOK sends the OK response straight away so that slack is not kept waiting while the request is queued
+func replyOK(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusOK)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// verifyRequest verifies the signature of the request from slack returning the body if it is valid
+func (o *Options) verifyRequest(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if o.SigningSecret == "" {
+		log.Logger().Warnf("rejecting slack request as there is no $SLACK_SIGNING_SECRET to verify it")
+		w.WriteHeader(http.StatusUnauthorized)
+		return nil, false
+	}
+	verifier, err := slack.NewSecretsVerifier(r.Header, o.SigningSecret)
+	if err != nil {
+		log.Logger().Warnf("failed to create the slack request verifier: %s", err.Error())
+		w.WriteHeader(http.StatusUnauthorized)
+		return nil, false
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Logger().Warnf("failed to read slack request: %s", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+	_, err = verifier.Write(body)
+	if err == nil {
+		err = verifier.Ensure()
+	}
+	if err != nil {
+		log.Logger().Warnf("invalid slack request signature: %s", err.Error())
+		w.WriteHeader(http.StatusUnauthorized)
+		return nil, false
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, true
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return errors.Wrapf(err, "failed to marshal response")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	return err
+}
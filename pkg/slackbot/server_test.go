@@ -0,0 +1,87 @@
+package slackbot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyRequest(t *testing.T) {
+	body := "command=%2Fjx&text=notify+myorg%2Fmyrepo"
+
+	testCases := []struct {
+		name          string
+		signingSecret string
+		key           string
+		path          string
+		expected      int
+	}{
+		{
+			name:     "signed with an empty key without a signing secret",
+			path:     CommandsPath,
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "interaction signed with an empty key without a signing secret",
+			path:     InteractionsPath,
+			expected: http.StatusNotFound,
+		},
+		{
+			name:          "signed with an empty key",
+			signingSecret: "mysecret",
+			path:          CommandsPath,
+			expected:      http.StatusUnauthorized,
+		},
+		{
+			name:          "signed with the wrong key",
+			signingSecret: "mysecret",
+			key:           "othersecret",
+			path:          CommandsPath,
+			expected:      http.StatusUnauthorized,
+		},
+	}
+	for _, tc := range testCases {
+		o := &Options{}
+		o.SigningSecret = tc.signingSecret
+		o.HMACToken = "myhmac"
+
+		w := httptest.NewRecorder()
+		o.serveMux().ServeHTTP(w, signedRequest(tc.path, body, tc.key))
+		assert.Equal(t, tc.expected, w.Code, tc.name)
+	}
+
+	// the handlers also refuse requests if they are ever reached without a signing secret
+	o := &Options{}
+	w := httptest.NewRecorder()
+	_, ok := o.verifyRequest(w, signedRequest(CommandsPath, body, ""))
+	assert.False(t, ok, "request signed with an empty key should not be verified")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "request signed with an empty key")
+
+	o.SigningSecret = "mysecret"
+	w = httptest.NewRecorder()
+	verified, ok := o.verifyRequest(w, signedRequest(CommandsPath, body, "mysecret"))
+	assert.True(t, ok, "request signed with the signing secret should be verified")
+	assert.Equal(t, body, string(verified), "body")
+}
+
+// signedRequest creates a slack request signed with the key
+func signedRequest(path, body, key string) *http.Request {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(key))
+	_, _ = fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
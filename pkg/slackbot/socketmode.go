@@ -0,0 +1,107 @@
+package slackbot
+
+import (
+	"context"
+
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// socketModeAcker acknowledges socket mode requests so we can fake it in tests
+type socketModeAcker interface {
+	Ack(req socketmode.Request, payload ...interface{})
+}
+
+// RunSocketMode receives events, slash commands and interactions over a Socket Mode websocket so that no
+// public ingress is required. It blocks until the context is cancelled or the connection fails
+func (o *Options) RunSocketMode(ctx context.Context) error {
+	if o.AppToken == "" {
+		return errors.Errorf("no $SLACK_APP_TOKEN defined")
+	}
+	clientOptions := []slack.Option{slack.OptionAppLevelToken(o.AppToken)}
+	if o.SlackURL != "" {
+		clientOptions = append(clientOptions, slack.OptionAPIURL(o.SlackURL))
+	}
+	client := socketmode.New(slack.New(o.SlackToken, clientOptions...))
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-client.Events:
+				if !ok {
+					return
+				}
+				o.handleSocketModeEvent(client, &evt)
+			}
+		}
+	}()
+
+	log.Logger().Infof("connecting to slack using socket mode")
+	return client.RunContext(ctx)
+}
+
+// handleSocketModeEvent dispatches the socket mode event to the same handlers used by the HTTP server on the
+// handler queue
+func (o *Options) handleSocketModeEvent(client socketModeAcker, evt *socketmode.Event) {
+	switch evt.Type {
+	case socketmode.EventTypeConnecting:
+		log.Logger().Debugf("connecting to slack socket mode")
+	case socketmode.EventTypeConnected:
+		log.Logger().Infof("connected to slack socket mode")
+	case socketmode.EventTypeConnectionError, socketmode.EventTypeInvalidAuth:
+		log.Logger().Warnf("failed to connect to slack socket mode: %v", evt.Data)
+	case socketmode.EventTypeEventsAPI:
+		event, ok := evt.Data.(slackevents.EventsAPIEvent)
+		if !ok {
+			log.Logger().Warnf("ignoring unexpected events API payload %#v", evt.Data)
+			ackIgnored(client, evt)
+			return
+		}
+		client.Ack(*evt.Request)
+		o.serialize(func() {
+			o.HandleEvent(&event)
+		})
+	case socketmode.EventTypeSlashCommand:
+		cmd, ok := evt.Data.(slack.SlashCommand)
+		if !ok {
+			log.Logger().Warnf("ignoring unexpected slash command payload %#v", evt.Data)
+			ackIgnored(client, evt)
+			return
+		}
+		var msg *slack.Msg
+		var err error
+		o.serializeAndWait(func() {
+			msg, err = o.HandleCommand(&cmd)
+		})
+		if err != nil {
+			log.Logger().Warnf("failed to process command %s %s from %s: %s", cmd.Command, cmd.Text, cmd.UserID, err.Error())
+			msg = ephemeralMessage("Sorry, something went wrong processing your command")
+		}
+		client.Ack(*evt.Request, msg)
+	case socketmode.EventTypeInteractive:
+		callback, ok := evt.Data.(slack.InteractionCallback)
+		if !ok {
+			log.Logger().Warnf("ignoring unexpected interaction payload %#v", evt.Data)
+			ackIgnored(client, evt)
+			return
+		}
+		client.Ack(*evt.Request)
+		o.serialize(func() {
+			o.HandleInteraction(&callback)
+		})
+	default:
+		log.Logger().Debugf("ignoring socket mode event %s", string(evt.Type))
+	}
+}
+
+// ackIgnored acknowledges a request we cannot process so that slack does not keep retrying it
+func ackIgnored(client socketModeAcker, evt *socketmode.Event) {
+	if evt.Request != nil {
+		client.Ack(*evt.Request)
+	}
+}
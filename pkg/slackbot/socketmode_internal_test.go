@@ -0,0 +1,104 @@
+package slackbot
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type recordingAcker struct {
+	lock        sync.Mutex
+	envelopeIDs []string
+}
+
+func (r *recordingAcker) Ack(req socketmode.Request, _ ...interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.envelopeIDs = append(r.envelopeIDs, req.EnvelopeID)
+}
+
+func TestSocketModeAcksUnexpectedPayloads(t *testing.T) {
+	o := &Options{}
+	for _, eventType := range []socketmode.EventType{
+		socketmode.EventTypeEventsAPI,
+		socketmode.EventTypeSlashCommand,
+		socketmode.EventTypeInteractive,
+	} {
+		acker := &recordingAcker{}
+		o.handleSocketModeEvent(acker, &socketmode.Event{
+			Type:    eventType,
+			Data:    "unexpected",
+			Request: &socketmode.Request{EnvelopeID: "envelope-1"},
+		})
+		assert.Equal(t, []string{"envelope-1"}, acker.envelopeIDs, "%s should be acknowledged", string(eventType))
+	}
+}
+
+// TestSocketModeEventsAreSerialized is run with -race by make test-race to check the events share the Options safely with the
+// other handlers
+func TestSocketModeEventsAreSerialized(t *testing.T) {
+	ns := "jx"
+	slackUserID := "U111"
+	user := &jenkinsv1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "myuser", Namespace: ns},
+		Spec: jenkinsv1.UserDetails{
+			Login: "myuser",
+			Accounts: []jenkinsv1.AccountReference{
+				{Provider: (&SlackUserResolver{}).SlackProviderKey(), ID: slackUserID},
+			},
+		},
+	}
+	jxClient := fakejx.NewSimpleClientset(user)
+	scmClient, _ := fakescm.NewDefault()
+	slackClient := fakeslack.NewFakeSlack()
+	o := &Options{
+		JXClient:          jxClient,
+		ScmClient:         scmClient,
+		SlackClient:       slackClient,
+		SlackUserResolver: NewSlackUserResolver(slackClient, jxClient, ns),
+		SourceConfigs:     createNamespaceSourceConfig("myorg", "myrepo", "#builds"),
+	}
+	o.Namespace = ns
+	stopper := make(chan struct{})
+	defer close(stopper)
+	o.startHandlerQueue(stopper)
+
+	acker := &recordingAcker{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			o.handleSocketModeEvent(acker, &socketmode.Event{
+				Type: socketmode.EventTypeEventsAPI,
+				Data: slackevents.EventsAPIEvent{
+					Type: slackevents.CallbackEvent,
+					InnerEvent: slackevents.EventsAPIInnerEvent{
+						Type: slackevents.AppHomeOpened,
+						Data: &slackevents.AppHomeOpenedEvent{User: slackUserID, Tab: "home"},
+					},
+				},
+				Request: &socketmode.Request{EnvelopeID: fmt.Sprintf("envelope-%d", i)},
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	// wait for the queued events to be processed
+	o.serializeAndWait(func() {})
+	acker.lock.Lock()
+	assert.Len(t, acker.envelopeIDs, 10, "should acknowledge every event")
+	acker.lock.Unlock()
+	_, ok := slackClient.HomeViews[slackUserID]
+	require.True(t, ok, "should publish the home tab of %s", slackUserID)
+}
@@ -0,0 +1,54 @@
+package slackbot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slackbot"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakesocketmode"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSocketModeSlashCommand(t *testing.T) {
+	server := fakesocketmode.NewFakeServer()
+	defer server.Close()
+
+	userID := "U1234"
+	o := &slackbot.Options{
+		Preferences: slackbot.NewPreferenceStore(fake.NewSimpleClientset(), "jx"),
+	}
+	o.SlackToken = "xoxb-fake"
+	o.AppToken = "xapp-fake"
+	o.SlackURL = server.APIURL()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		err := o.RunSocketMode(ctx)
+		if err != nil && ctx.Err() == nil {
+			t.Logf("socket mode failed: %s\n", err.Error())
+		}
+	}()
+
+	envelopeID := "envelope-1"
+	err := server.SendSlashCommand(envelopeID, &slack.SlashCommand{
+		Command: "/jx",
+		Text:    "notify mute myorg/myrepo",
+		UserID:  userID,
+	})
+	require.NoError(t, err, "failed to send slash command")
+
+	ack := server.AssertAck(t, envelopeID, 10*time.Second)
+	payload, ok := ack.Payload.(map[string]interface{})
+	require.True(t, ok, "acknowledgement should have a message payload but was %#v", ack.Payload)
+	assert.Equal(t, slack.ResponseTypeEphemeral, payload["response_type"], "response type")
+	assert.Contains(t, payload["text"], "myorg/myrepo", "response text")
+
+	prefs, err := o.Preferences.Get(ctx, userID)
+	require.NoError(t, err, "failed to load preferences")
+	assert.Equal(t, []string{"myorg/myrepo"}, prefs.MutedRepositories, "MutedRepositories")
+}
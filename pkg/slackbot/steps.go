@@ -0,0 +1,68 @@
+package slackbot
+
+import (
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+)
+
+// pipelineStepKind the kind of a pipelineStep
+type pipelineStepKind int
+
+const (
+	stageStep pipelineStepKind = iota
+	stageUserStep
+	promoteStep
+	promotePullRequestStep
+	promoteUpdateStep
+	promoteApplicationStep
+)
+
+// pipelineStep a stage, a user step of a stage or a part of a promotion which each backend renders as a line
+type pipelineStep struct {
+	kind    pipelineStepKind
+	step    jenkinsv1.CoreActivityStep
+	stage   *jenkinsv1.StageActivityStep
+	promote *jenkinsv1.PromoteActivityStep
+}
+
+// pipelineSteps returns the stage or promotion of the step of an activity followed by its user steps, pull request,
+// update and application in the order they are shown
+func pipelineSteps(step *jenkinsv1.PipelineActivityStep) []pipelineStep {
+	var answer []pipelineStep
+	stage := step.Stage
+	promote := step.Promote
+	if stage != nil {
+		answer = append(answer, pipelineStep{kind: stageStep, step: stage.CoreActivityStep, stage: stage})
+		if stage.CoreActivityStep.Name != "meta pipeline" {
+			for _, s := range stage.Steps {
+				// filter out tekton generated steps
+				if isUserPipelineStep(s.Name) {
+					answer = append(answer, pipelineStep{kind: stageUserStep, step: s, stage: stage})
+				}
+			}
+		}
+	} else if promote != nil {
+		answer = append(answer, pipelineStep{kind: promoteStep, step: promote.CoreActivityStep, promote: promote})
+		if promote.PullRequest != nil {
+			answer = append(answer, pipelineStep{kind: promotePullRequestStep, step: promote.PullRequest.CoreActivityStep, promote: promote})
+		}
+		if promote.Update != nil {
+			answer = append(answer, pipelineStep{kind: promoteUpdateStep, step: promote.Update.CoreActivityStep, promote: promote})
+		}
+		if promote.ApplicationURL != "" {
+			appStep := promote.CoreActivityStep
+			if promote.Update != nil {
+				appStep = promote.Update.CoreActivityStep
+			}
+			answer = append(answer, pipelineStep{kind: promoteApplicationStep, step: appStep, promote: promote})
+		}
+	}
+	return answer
+}
+
+// stageName returns the name of the stage of the step
+func (s *pipelineStep) stageName() string {
+	if s.stage == nil || s.stage.Name == "" {
+		return "Stage"
+	}
+	return s.stage.Name
+}
@@ -0,0 +1,134 @@
+package slackbot
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+const testMessageText = ":wave: This is a test message from the Jenkins X slack app"
+
+// the OAuth scopes needed by each of the slack API methods used to send a test message
+const (
+	scopeChatWrite      = "chat:write"
+	scopeIMWrite        = "im:write"
+	scopeUsersReadEmail = "users:read.email"
+)
+
+// TestMessageOptions options for sending a test message to verify the token and channel setup
+type TestMessageOptions struct {
+	Options
+	Channel string
+	User    string
+	Out     io.Writer
+}
+
+// Run validates the options and sends the test messages
+func (o *TestMessageOptions) Run() error {
+	if o.Channel == "" && o.User == "" {
+		return errors.Errorf("please specify a channel or the email address of a user to send the test message to")
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+	return o.Send()
+}
+
+// Send sends the test message to the channel and as a direct message to the user
+func (o *TestMessageOptions) Send() error {
+	connection, channel := SplitChannel(o.Channel)
+	bot, err := o.forConnection(connection)
+	if err != nil {
+		return err
+	}
+	failed := false
+	if channel != "" {
+		channel = channelName(channel)
+		_, _, _, err = bot.SlackClient.SendMessage(channel, slack.MsgOptionText(testMessageText, false))
+		if err != nil {
+			fmt.Fprintf(o.Out, "failed to post to channel %s: %s\n", channel, describeSlackError(err, scopeChatWrite, channel))
+			failed = true
+		} else {
+			fmt.Fprintf(o.Out, "posted a test message to channel %s\n", channel)
+		}
+	}
+	if o.User != "" {
+		err = bot.sendTestDirectMessage(o.User)
+		if err != nil {
+			fmt.Fprintf(o.Out, "failed to send a direct message to %s: %s\n", o.User, err.Error())
+			failed = true
+		} else {
+			fmt.Fprintf(o.Out, "sent a test direct message to %s\n", o.User)
+		}
+	}
+	if failed {
+		return errors.Errorf("failed to send the test messages")
+	}
+	return nil
+}
+
+// sendTestDirectMessage resolves the slack user with the given email and sends them the test message
+func (o *Options) sendTestDirectMessage(email string) error {
+	id, err := o.SlackUserResolver.SlackUserLogin(&jenkinsv1.UserDetails{Email: email})
+	if err != nil {
+		return errors.New(describeSlackError(err, scopeUsersReadEmail, email))
+	}
+	if id == "" {
+		return errors.Errorf("no slack user found with email %s", email)
+	}
+	ch, _, _, err := o.SlackClient.OpenConversation(&slack.OpenConversationParameters{
+		Users: []string{id},
+	})
+	if err != nil {
+		return errors.New(describeSlackError(err, scopeIMWrite, email))
+	}
+	_, _, _, err = o.SlackClient.SendMessage(ch.ID, slack.MsgOptionText(testMessageText, false))
+	if err != nil {
+		return errors.New(describeSlackError(err, scopeChatWrite, email))
+	}
+	return nil
+}
+
+// describeSlackError describes the slack API error with advice on how to fix it. The scope is the OAuth scope needed
+// by the API method and the target is the channel or user
+func describeSlackError(err error, scope, target string) string {
+	code := slackErrorCode(err)
+	switch code {
+	case "missing_scope":
+		return fmt.Sprintf("the slack app is missing the %s OAuth scope. Add it on the OAuth & Permissions page of the app and reinstall it", scope)
+	case "not_authed", "invalid_auth", "token_revoked", "token_expired", "account_inactive":
+		return fmt.Sprintf("the slack token is not valid (%s). Check $SLACK_TOKEN is the Bot User OAuth Token of the app", code)
+	case "not_in_channel":
+		return fmt.Sprintf("the bot is not a member of %s. Invite it with /invite", target)
+	case "channel_not_found":
+		return fmt.Sprintf("channel %s does not exist or is a private channel the bot is not a member of", target)
+	case "is_archived":
+		return fmt.Sprintf("channel %s is archived", target)
+	case "users_not_found", "user_not_found":
+		return fmt.Sprintf("no slack user found for %s", target)
+	case "ratelimited":
+		return "slack is rate limiting requests, please try again later"
+	default:
+		return err.Error()
+	}
+}
+
+// slackErrorCode returns the error code of a slack API error or the message of any other error
+func slackErrorCode(err error) string {
+	switch e := errors.Cause(err).(type) {
+	case slack.SlackErrorResponse:
+		return e.Err
+	case *slack.SlackErrorResponse:
+		return e.Err
+	default:
+		return errors.Cause(err).Error()
+	}
+}
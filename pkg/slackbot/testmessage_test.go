@@ -0,0 +1,71 @@
+package slackbot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendTestMessage(t *testing.T) {
+	ns := "jx"
+	fakeSlackClient := fakeslack.NewFakeSlack()
+	fakeSlackClient.UsersByEmail = map[string]*slack.User{
+		"someone@example.com": {ID: "U1234"},
+	}
+
+	out := &bytes.Buffer{}
+	o := &TestMessageOptions{
+		Channel: "builds",
+		User:    "someone@example.com",
+		Out:     out,
+	}
+	o.SlackClient = fakeSlackClient
+	o.SlackUserResolver = NewSlackUserResolver(fakeSlackClient, fakejx.NewSimpleClientset(), ns)
+
+	err := o.Send()
+	require.NoError(t, err, "failed to send test messages")
+
+	text := out.String()
+	assert.Contains(t, text, "posted a test message to channel #builds")
+	assert.Contains(t, text, "sent a test direct message to someone@example.com")
+	assert.Len(t, fakeSlackClient.Messages["#builds"], 1, "channel messages")
+	assert.Len(t, fakeSlackClient.Messages["U1234"], 1, "direct messages")
+}
+
+func TestDescribeSlackError(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected string
+	}{
+		{
+			err:      slack.SlackErrorResponse{Err: "missing_scope"},
+			expected: "the slack app is missing the chat:write OAuth scope",
+		},
+		{
+			err:      errors.Wrapf(slack.SlackErrorResponse{Err: "not_in_channel"}, "failed to post"),
+			expected: "the bot is not a member of #builds",
+		},
+		{
+			err:      slack.SlackErrorResponse{Err: "invalid_auth"},
+			expected: "the slack token is not valid (invalid_auth)",
+		},
+		{
+			err:      errors.New("channel_not_found"),
+			expected: "channel #builds does not exist",
+		},
+		{
+			err:      errors.New("something else"),
+			expected: "something else",
+		},
+	}
+	for _, tc := range testCases {
+		text := describeSlackError(tc.err, scopeChatWrite, "#builds")
+		assert.Contains(t, text, tc.expected, "description of %s", tc.err.Error())
+	}
+}
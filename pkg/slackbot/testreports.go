@@ -0,0 +1,256 @@
+package slackbot
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/buckets"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+const (
+	defaultMaxTestFailures = 5
+
+	testReportTimeout = 30 * time.Second
+
+	// maxTestReportSize the maximum size of a JUnit report which is read
+	maxTestReportSize = 10 * 1024 * 1024
+
+	// maxTestReportCacheSize the number of activities whose test summary is remembered
+	maxTestReportCacheSize = 100
+)
+
+// TestSummary the counts of the tests in a JUnit report and the names of the failing tests
+type TestSummary struct {
+	Passed   int
+	Failed   int
+	Skipped  int
+	Failures []string
+}
+
+// Describe describes the counts such as '120 passed, 2 failed, 3 skipped'
+func (s *TestSummary) Describe() string {
+	return fmt.Sprintf("%d passed, %d failed, %d skipped", s.Passed, s.Failed, s.Skipped)
+}
+
+// junitSuite matches both the testsuites and testsuite elements of a JUnit report
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string    `xml:"name,attr"`
+	ClassName string    `xml:"classname,attr"`
+	Failure   *struct{} `xml:"failure"`
+	Error     *struct{} `xml:"error"`
+	Skipped   *struct{} `xml:"skipped"`
+}
+
+// ParseJUnit parses the JUnit XML report
+func ParseJUnit(data []byte) (*TestSummary, error) {
+	root := junitSuite{}
+	err := xml.Unmarshal(data, &root)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse JUnit XML")
+	}
+	summary := &TestSummary{}
+	summary.add(&root)
+	return summary, nil
+}
+
+func (s *TestSummary) add(suite *junitSuite) {
+	for i := range suite.Suites {
+		s.add(&suite.Suites[i])
+	}
+	for _, c := range suite.Cases {
+		switch {
+		case c.Failure != nil || c.Error != nil:
+			s.Failed++
+			name := c.Name
+			if c.ClassName != "" {
+				name = c.ClassName + "." + name
+			}
+			s.Failures = append(s.Failures, name)
+		case c.Skipped != nil:
+			s.Skipped++
+		default:
+			s.Passed++
+		}
+	}
+}
+
+// testReportURL returns the URL of the JUnit report of the activity from its annotation or the build logs location
+// or an empty string if there is none
+func testReportURL(activity *jenkinsv1.PipelineActivity, cfg *TestReports) string {
+	annotation := cfg.Annotation
+	if annotation == "" {
+		annotation = TestReportAnnotation
+	}
+	if u := activity.Annotations[annotation]; u != "" {
+		return u
+	}
+	logsURL := activity.Spec.BuildLogsURL
+	if cfg.Path == "" || logsURL == "" {
+		return ""
+	}
+	idx := strings.LastIndex(logsURL, "/")
+	if idx < 0 {
+		return ""
+	}
+	return stringhelpers.UrlJoin(logsURL[:idx], cfg.Path)
+}
+
+// testSummary reads and parses the JUnit report of the completed activity returning nil if there is none. The
+// summary, or the failure to read it, is remembered so that the report is only read once
+func (o *Options) testSummary(ctx context.Context, activity *jenkinsv1.PipelineActivity) *TestSummary {
+	if o.Config == nil || o.Config.TestReports == nil || !activity.Spec.Status.IsTerminated() {
+		return nil
+	}
+	u := testReportURL(activity, o.Config.TestReports)
+	if u == "" {
+		return nil
+	}
+	if o.testReports == nil {
+		o.testReports = newLRUCache(maxTestReportCacheSize)
+	}
+	key := activity.Namespace + "/" + activity.Name + "/" + u
+	if value, ok := o.testReports.get(key); ok {
+		summary, _ := value.(*TestSummary)
+		return summary
+	}
+	summary, err := o.readTestSummary(ctx, u)
+	if err != nil {
+		log.Logger().Warnf("failed to read the test report of %s from %s: %s", activity.Name, u, err.Error())
+	}
+	o.testReports.put(key, summary)
+	return summary
+}
+
+// readTestSummary reads and parses the JUnit report at the URL
+func (o *Options) readTestSummary(ctx context.Context, u string) (*TestSummary, error) {
+	data, err := o.readTestReport(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJUnit(data)
+}
+
+// readTestReport reads the report from a HTTP URL or, using the credentials of the cluster, from a bucket URL such
+// as gs://, s3:// or azblob:// in the same way as the build logs
+func (o *Options) readTestReport(ctx context.Context, u string) ([]byte, error) {
+	if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+		return o.download(ctx, u)
+	}
+	reader, err := buckets.ReadURL(ctx, u, testReportTimeout, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", u)
+	}
+	defer reader.Close()
+	return readAllLimited(reader, maxTestReportSize)
+}
+
+func (o *Options) download(ctx context.Context, u string) ([]byte, error) {
+	client := o.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ctx, cancel := context.WithTimeout(ctx, testReportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create request for %s", u)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s", u)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to get %s: status %d", u, resp.StatusCode)
+	}
+	return readAllLimited(resp.Body, maxTestReportSize)
+}
+
+// readAllLimited reads the reader failing if there are more than limit bytes
+func readAllLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errors.Errorf("the report is larger than %d bytes", limit)
+	}
+	return data, nil
+}
+
+// createTestsAttachment returns the attachment showing the test counts along with the failing tests unless they
+// are posted in a thread
+func (o *Options) createTestsAttachment(summary *TestSummary) slack.Attachment {
+	cfg := o.Config.TestReports
+	color := "good"
+	if summary.Failed > 0 {
+		color = "danger"
+	}
+	text := ":test_tube: " + summary.Describe()
+	if !cfg.Thread && summary.Failed > 0 {
+		text += "\n" + testFailuresText(summary, cfg)
+	}
+	return slack.Attachment{
+		Color: color,
+		Text:  text,
+	}
+}
+
+// testFailuresText lists the first failing tests
+func testFailuresText(summary *TestSummary, cfg *TestReports) string {
+	max := cfg.MaxFailures
+	if max <= 0 {
+		max = defaultMaxTestFailures
+	}
+	var lines []string
+	for i, name := range summary.Failures {
+		if i >= max {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(summary.Failures)-max))
+			break
+		}
+		lines = append(lines, "• "+name)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// postTestFailuresThread replies to the pipeline message in the channel with the failing tests of the activity once
+func (o *Options) postTestFailuresThread(ctx context.Context, activity *jenkinsv1.PipelineActivity, channel string) error {
+	if o.Config == nil || o.Config.TestReports == nil || !o.Config.TestReports.Thread || activity.Annotations[TestThreadAnnotation] != "" {
+		return nil
+	}
+	messageRef := o.findMessageRef(activity, channel, pipelineMessageType)
+	if messageRef == nil {
+		return nil
+	}
+	summary := o.testSummary(ctx, activity)
+	if summary == nil || summary.Failed == 0 {
+		return nil
+	}
+	text := "Failing tests:\n" + testFailuresText(summary, o.Config.TestReports)
+	_, timestamp, _, err := o.SlackClient.SendMessage(messageRef.ChannelID, slack.MsgOptionText(text, false), slack.MsgOptionTS(messageRef.Timestamp))
+	if err != nil {
+		return errors.Wrapf(err, "failed to reply with the failing tests of %s", activity.Name)
+	}
+	if activity.Annotations == nil {
+		activity.Annotations = map[string]string{}
+	}
+	activity.Annotations[TestThreadAnnotation] = timestamp
+	return o.annotatePipelineActivity(ctx, activity, TestThreadAnnotation, timestamp)
+}
@@ -0,0 +1,151 @@
+package slackbot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testJUnitReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="pkg/a">
+    <testcase classname="pkg/a" name="TestOne"></testcase>
+    <testcase classname="pkg/a" name="TestTwo"><failure message="boom">expected 1</failure></testcase>
+    <testcase classname="pkg/a" name="TestThree"><skipped/></testcase>
+  </testsuite>
+  <testsuite name="pkg/b">
+    <testcase classname="pkg/b" name="TestFour"><error message="panic"></error></testcase>
+    <testcase classname="pkg/b" name="TestFive"></testcase>
+  </testsuite>
+</testsuites>
+`
+
+func TestParseJUnit(t *testing.T) {
+	summary, err := ParseJUnit([]byte(testJUnitReport))
+	require.NoError(t, err, "failed to parse report")
+	assert.Equal(t, 2, summary.Passed, "passed")
+	assert.Equal(t, 2, summary.Failed, "failed")
+	assert.Equal(t, 1, summary.Skipped, "skipped")
+	assert.Equal(t, []string{"pkg/a.TestTwo", "pkg/b.TestFour"}, summary.Failures, "failures")
+
+	summary, err = ParseJUnit([]byte(`<testsuite name="single"><testcase name="TestOne"/></testsuite>`))
+	require.NoError(t, err, "failed to parse a single suite")
+	assert.Equal(t, 1, summary.Passed, "passed")
+}
+
+func TestTestReportURL(t *testing.T) {
+	activity := testpipelines.CreateTestPipelineActivity("jx", "myorg", "myrepo", "main", "release", "1", jenkinsv1.ActivityStatusTypeFailed)
+	activity.Spec.BuildLogsURL = "gs://mybucket/jenkins-x/logs/myorg/myrepo/main/1.log"
+	cfg := &TestReports{}
+	assert.Equal(t, "", testReportURL(activity, cfg), "no path configured")
+
+	cfg.Path = "junit.xml"
+	assert.Equal(t, "gs://mybucket/jenkins-x/logs/myorg/myrepo/main/junit.xml", testReportURL(activity, cfg), "build logs")
+
+	activity.Annotations = map[string]string{TestReportAnnotation: "https://reports.example.com/1.xml"}
+	assert.Equal(t, "https://reports.example.com/1.xml", testReportURL(activity, cfg), "annotation")
+}
+
+func TestTestReportMessages(t *testing.T) {
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		_, _ = w.Write([]byte(testJUnitReport))
+	}))
+	defer server.Close()
+
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+	channel := "#builds"
+	activity := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", "1", jenkinsv1.ActivityStatusTypeFailed)
+	activity.Annotations = map[string]string{TestReportAnnotation: server.URL + "/junit.xml"}
+
+	scmClient, _ := fakescm.NewDefault()
+	slackClient := fakeslack.NewFakeSlack()
+	o := &Options{
+		KubeClient:  fake.NewSimpleClientset(),
+		JXClient:    fakejx.NewSimpleClientset(activity),
+		ScmClient:   scmClient,
+		SlackClient: slackClient,
+		HTTPClient:  server.Client(),
+		SourceConfigs: &v1alpha1.SourceConfig{
+			Spec: v1alpha1.SourceConfigSpec{
+				Groups: []v1alpha1.RepositoryGroup{
+					{
+						Provider: "https://fake.git",
+						Owner:    owner,
+						Repositories: []v1alpha1.Repository{
+							{
+								Name: repo,
+								Slack: &v1alpha1.SlackNotify{
+									Channel:  channel,
+									Kind:     v1alpha1.NotifyKindAlways,
+									Pipeline: v1alpha1.PipelineKindAll,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Config: &Config{
+			TestReports: &TestReports{
+				MaxFailures: 1,
+			},
+		},
+	}
+	o.Namespace = ns
+
+	options, _, err := o.createPipelineMessage(activity, nil, "")
+	require.NoError(t, err, "failed to create message")
+	_, values, err := slack.UnsafeApplyMsgOptions("fakeToken", channel, "fakeapiurl", options...)
+	require.NoError(t, err, "failed to render message")
+	attachments := values.Get("attachments")
+	assert.Contains(t, attachments, "2 passed, 2 failed, 1 skipped", "attachments")
+	assert.Contains(t, attachments, "pkg/a.TestTwo", "attachments")
+	assert.Contains(t, attachments, "... and 1 more", "attachments")
+
+	// the failing tests can be listed in a thread instead
+	o.Config.TestReports.Thread = true
+	err = o.PipelineMessage(activity)
+	require.NoError(t, err, "failed to process pipeline")
+	require.Len(t, slackClient.Messages[channel], 2, "should post the message and a reply")
+
+	_, values, err = slack.UnsafeApplyMsgOptions("fakeToken", channel, "fakeapiurl", slackClient.Messages[channel][0].Options...)
+	require.NoError(t, err, "failed to render message")
+	assert.NotContains(t, values.Get("attachments"), "pkg/a.TestTwo", "the message should not list the failures")
+
+	_, values, err = slack.UnsafeApplyMsgOptions("fakeToken", channel, "fakeapiurl", slackClient.Messages[channel][1].Options...)
+	require.NoError(t, err, "failed to render reply")
+	assert.Equal(t, slackClient.Messages[channel][0].Timestamp, values.Get("thread_ts"), "reply thread")
+	assert.Contains(t, values.Get("text"), "pkg/a.TestTwo", "reply text")
+	assert.NotEmpty(t, activity.Annotations[TestThreadAnnotation], "thread annotation")
+
+	// the reply is only posted once
+	err = o.PipelineMessage(activity)
+	require.NoError(t, err, "failed to process pipeline")
+	assert.Len(t, slackClient.Messages[channel], 3, "should only update the message")
+	assert.Equal(t, 1, downloads, "should only download the report once")
+}
+
+func TestReadAllLimited(t *testing.T) {
+	data, err := readAllLimited(strings.NewReader("<testsuite/>"), 12)
+	require.NoError(t, err, "report within the limit")
+	assert.Equal(t, "<testsuite/>", string(data), "report")
+
+	_, err = readAllLimited(strings.NewReader("<testsuites/>"), 12)
+	assert.Error(t, err, "report larger than the limit")
+}
@@ -1,6 +1,9 @@
 package slackbot
 
 import (
+	"net/http"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
 	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker"
 	"github.com/jenkins-x/go-scm/scm"
 	jenkinsv1client "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
@@ -14,10 +17,20 @@ type SlackOptions struct {
 	Dir           string `env:"GIT_DIR"`
 	SlackToken    string `env:"SLACK_TOKEN"`
 	SlackURL      string `env:"SLACK_URL"`
+	SigningSecret string `env:"SLACK_SIGNING_SECRET"`
+	HMACToken     string `env:"HMAC_TOKEN"`
+	AppToken      string `env:"SLACK_APP_TOKEN"`
+	Port          int    `env:"PORT"`
 	GitURL        string `env:"GIT_URL"`
 	Name          string
 	Namespace     string
 	FakeTimestamp string
+
+	// WatchNamespaces the namespaces to watch for PipelineActivities. Defaults to the current namespace
+	WatchNamespaces []string `env:"WATCH_NAMESPACES"`
+
+	// WatchAllNamespaces watches PipelineActivities in all namespaces
+	WatchAllNamespaces bool `env:"WATCH_ALL_NAMESPACES"`
 }
 
 type MessageFormat struct {
@@ -36,13 +49,38 @@ type Options struct {
 	KubeClient        kubernetes.Interface
 	JXClient          jenkinsv1client.Interface
 	SlackClient       slacker.Interface
+	Connections       map[string]slacker.Interface
+	Notifiers         map[string]notifiers.Notifier
+	Webhooks          map[string]notifiers.Notifier
+	Emails            map[string]notifiers.Notifier
 	ScmClient         *scm.Client
 	SourceConfigs     *v1alpha1.SourceConfig
+	Config            *Config
 	Statuses          Statuses
 	Timestamps        map[string]map[string]*MessageReference
 	SlackUserResolver SlackUserResolver
+	Preferences       *PreferenceStore
+	EnvironmentStatus *EnvironmentStatusStore
+	PipelineStates    *PipelineStateStore
+	HTTPClient        *http.Client
+	HomePipelineCount int
+	DryRun            bool
+	ForceNewMessages  bool
+	IgnoreAgeCutoff   bool
 	GitClient         gitclient.Interface
 	CommandRunner     cmdrunner.CommandRunner
+
+	namespaceSources *namespaceSourceConfigs
+	environments     *environments
+	handlers         *handlerQueue
+	webhookQueues    map[string]*webhookQueue
+	stuckWarnings    map[string]string
+	changelogs       *lruCache
+	testReports      *lruCache
+	homePullRequests *lruCache
+
+	// repositoryWatchdogs the watchdog thresholds in the SourceConfig of the namespace by repository full name
+	repositoryWatchdogs map[string]*WatchdogThresholds
 }
 
 type Statuses struct {
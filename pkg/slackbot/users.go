@@ -2,6 +2,7 @@ package slackbot
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -15,6 +16,7 @@ import (
 	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
 
 	jenkninsv1client "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -27,6 +29,9 @@ type SlackUserResolver struct {
 	JXClient     jenkninsv1client.Interface
 	Namespace    string
 	UserMappings map[string]string
+
+	// Connection the name of the slack connection if not the default workspace
+	Connection string
 }
 
 // NewSlackUserResolver creates a new struct to work with resolving slack user details
@@ -72,8 +77,12 @@ func (r *SlackUserResolver) SlackUserLogin(user *jenkinsv1.UserDetails) (string,
 	return "", nil
 }
 
-// SlackProviderKey returns the provider key for this SlackUserResolver
+// SlackProviderKey returns the provider key for this SlackUserResolver. User IDs are specific to a slack workspace
+// so named connections use their own key
 func (r *SlackUserResolver) SlackProviderKey() string {
+	if r.Connection != "" {
+		return fmt.Sprintf("slack.apps.jenkins-x.com/%s.userid", r.Connection)
+	}
 	return fmt.Sprintf("slack.apps.jenkins-x.com/userid")
 }
 
@@ -85,6 +94,37 @@ func (r *SlackUserResolver) getSlackEmailFromMapping(gitUserEmail, fileLocation
 		return "", errors.New("no user mapping file location")
 	}
 
+	err := r.loadUserMappings(fileLocation)
+	if err != nil {
+		return "", err
+	}
+	if r.UserMappings[gitUserEmail] == "" {
+		return "", fmt.Errorf("no slack email found for git user email %s", gitUserEmail)
+	}
+	return r.UserMappings[gitUserEmail], nil
+}
+
+// getGitEmailFromMapping returns the git user email which maps to the given slack email
+func (r *SlackUserResolver) getGitEmailFromMapping(slackUserEmail, fileLocation string) (string, error) {
+	if slackUserEmail == "" {
+		return "", errors.New("no slack user email")
+	}
+	if fileLocation == "" {
+		return "", errors.New("no user mapping file location")
+	}
+	err := r.loadUserMappings(fileLocation)
+	if err != nil {
+		return "", err
+	}
+	for gitEmail, slackEmail := range r.UserMappings {
+		if strings.EqualFold(slackEmail, slackUserEmail) {
+			return gitEmail, nil
+		}
+	}
+	return "", fmt.Errorf("no git email found for slack user email %s", slackUserEmail)
+}
+
+func (r *SlackUserResolver) loadUserMappings(fileLocation string) error {
 	if r.UserMappings == nil {
 		r.UserMappings = make(map[string]string)
 	}
@@ -92,7 +132,7 @@ func (r *SlackUserResolver) getSlackEmailFromMapping(gitUserEmail, fileLocation
 	if len(r.UserMappings) == 0 {
 		f, err := os.Open(fileLocation)
 		if err != nil {
-			return "", fmt.Errorf("failed to read file %s", fileLocation)
+			return fmt.Errorf("failed to read file %s", fileLocation)
 		}
 		defer func() {
 			if err = f.Close(); err != nil {
@@ -104,21 +144,70 @@ func (r *SlackUserResolver) getSlackEmailFromMapping(gitUserEmail, fileLocation
 
 			emails := strings.Split(s.Text(), ":")
 			if len(emails) != 2 {
-				return "", fmt.Errorf("line should contain two parts GIT_USER_EMAIL:SLACK_USER_EMAIL %s", s.Text())
+				return fmt.Errorf("line should contain two parts GIT_USER_EMAIL:SLACK_USER_EMAIL %s", s.Text())
 			}
 			if r.UserMappings[emails[0]] != "" {
-				return "", fmt.Errorf("duplicate mapping found for git user email %s", emails[0])
+				return fmt.Errorf("duplicate mapping found for git user email %s", emails[0])
 			}
 			r.UserMappings[emails[0]] = emails[1]
 
 		}
 		err = s.Err()
 		if err != nil {
-			return "", errors.Wrapf(err, "failed scanning lines from file %s", fileLocation)
+			return errors.Wrapf(err, "failed scanning lines from file %s", fileLocation)
 		}
 	}
-	if r.UserMappings[gitUserEmail] == "" {
-		return "", fmt.Errorf("no slack email found for git user email %s", gitUserEmail)
+	return nil
+}
+
+// FindUser returns the Jenkins X user for the given slack user ID or nil if there is no matching user.
+// Users are matched on their slack account or by the email address of the slack user
+func (r *SlackUserResolver) FindUser(ctx context.Context, slackUserID string) (*jenkinsv1.User, error) {
+	userList, err := r.JXClient.JenkinsV1().Users(r.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list Users in namespace %s", r.Namespace)
 	}
-	return r.UserMappings[gitUserEmail], nil
+	for i := range userList.Items {
+		u := &userList.Items[i]
+		for _, a := range u.Spec.Accounts {
+			if a.Provider == r.SlackProviderKey() && a.ID == slackUserID {
+				return u, nil
+			}
+		}
+	}
+
+	slackUser, err := r.SlackClient.GetUserInfo(slackUserID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not find Slack user %s", slackUserID)
+	}
+	if slackUser == nil || slackUser.Profile.Email == "" {
+		return nil, nil
+	}
+	emails := []string{slackUser.Profile.Email}
+	gitEmail, err := r.getGitEmailFromMapping(slackUser.Profile.Email, userMappingfile)
+	if err == nil {
+		emails = append(emails, gitEmail)
+	}
+	for i := range userList.Items {
+		u := &userList.Items[i]
+		for _, email := range emails {
+			if u.Spec.Email != "" && strings.EqualFold(u.Spec.Email, email) {
+				return u, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// GitUserLogin returns the login of the user for the git provider with the given key
+func GitUserLogin(user *jenkinsv1.User, gitProviderKey string) string {
+	if user == nil {
+		return ""
+	}
+	for _, a := range user.Spec.Accounts {
+		if a.Provider == gitProviderKey && a.ID != "" {
+			return a.ID
+		}
+	}
+	return user.Spec.Login
 }
@@ -1,11 +1,19 @@
 package slackbot
 
 import (
+	"context"
 	"path"
 	"strings"
 	"testing"
 
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
 	"github.com/prometheus/common/log"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestSlackUserResolver_getSlackEmailFromMapping(t *testing.T) {
@@ -83,3 +91,51 @@ func TestSlackUserResolver_getSlackEmailFromMapping(t *testing.T) {
 		})
 	}
 }
+
+func TestSlackUserResolver_FindUser(t *testing.T) {
+	ns := "jx"
+	slackProviderKey := (&SlackUserResolver{}).SlackProviderKey()
+	jxClient := fakejx.NewSimpleClientset(
+		&jenkinsv1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "linked", Namespace: ns},
+			Spec: jenkinsv1.UserDetails{
+				Login: "linked",
+				Accounts: []jenkinsv1.AccountReference{
+					{Provider: slackProviderKey, ID: "U111"},
+					{Provider: "github.com", ID: "linked-on-github"},
+				},
+			},
+		},
+		&jenkinsv1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "byemail", Namespace: ns},
+			Spec: jenkinsv1.UserDetails{
+				Login: "byemail",
+				Email: "byemail@yummy.com",
+			},
+		},
+	)
+	slackClient := fakeslack.NewFakeSlack()
+	slackClient.UsersByID = map[string]*slack.User{
+		"U222": {ID: "U222", Profile: slack.UserProfile{Email: "byemail@yummy.com"}},
+		"U333": {ID: "U333", Profile: slack.UserProfile{Email: "unknown@yummy.com"}},
+	}
+	r := NewSlackUserResolver(slackClient, jxClient, ns)
+	r.UserMappings = map[string]string{"other@yummy.com": "different@yummy.com"}
+
+	ctx := context.TODO()
+	user, err := r.FindUser(ctx, "U111")
+	require.NoError(t, err, "failed to find user U111")
+	require.NotNil(t, user, "no user found for U111")
+	assert.Equal(t, "linked", user.Name)
+	assert.Equal(t, "linked-on-github", GitUserLogin(user, "github.com"))
+
+	user, err = r.FindUser(ctx, "U222")
+	require.NoError(t, err, "failed to find user U222")
+	require.NotNil(t, user, "no user found for U222")
+	assert.Equal(t, "byemail", user.Name)
+	assert.Equal(t, "byemail", GitUserLogin(user, "github.com"))
+
+	user, err = r.FindUser(ctx, "U333")
+	require.NoError(t, err, "failed to find user U333")
+	assert.Nil(t, user, "should not find a user for U333")
+}
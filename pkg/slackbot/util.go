@@ -1,6 +1,7 @@
 package slackbot
 
 import (
+	"strconv"
 	"strings"
 
 	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
@@ -16,13 +17,47 @@ func (s byBuildNumber) Swap(i, j int) {
 	s[i], s[j] = s[j], s[i]
 }
 
-func (s byBuildNumber) getBuildNumber(activity jenkinsv1.PipelineActivity) string {
-	details := CreatePipelineDetails(&activity)
-	return details.Build
+func (s byBuildNumber) Less(i, j int) bool {
+	return isEarlierBuild(&s[i], &s[j])
 }
 
-func (s byBuildNumber) Less(i, j int) bool {
-	return s.getBuildNumber(s[i]) < s.getBuildNumber(s[j])
+// isEarlierBuild returns true if activity a is an earlier build than activity b comparing the build numbers
+// numerically and falling back to the creation timestamp and name if they are equal or not numbers
+func isEarlierBuild(a, b *jenkinsv1.PipelineActivity) bool {
+	ba, errA := strconv.Atoi(CreatePipelineDetails(a).Build)
+	bb, errB := strconv.Atoi(CreatePipelineDetails(b).Build)
+	if errA == nil && errB == nil && ba != bb {
+		return ba < bb
+	}
+	ta := a.CreationTimestamp.Time
+	tb := b.CreationTimestamp.Time
+	if !ta.Equal(tb) {
+		return ta.Before(tb)
+	}
+	return a.Name < b.Name
+}
+
+// isEarlierCreated returns true if activity a was created before activity b falling back to the build order if they
+// were created at the same time. Unlike build numbers the creation time can be compared across contexts
+func isEarlierCreated(a, b *jenkinsv1.PipelineActivity) bool {
+	ta := a.CreationTimestamp.Time
+	tb := b.CreationTimestamp.Time
+	if !ta.Equal(tb) {
+		return ta.Before(tb)
+	}
+	return isEarlierBuild(a, b)
+}
+
+// oldestActivity returns the first created activity or nil if there are none
+func oldestActivity(activities []jenkinsv1.PipelineActivity) *jenkinsv1.PipelineActivity {
+	var answer *jenkinsv1.PipelineActivity
+	for i := range activities {
+		a := &activities[i]
+		if answer == nil || isEarlierCreated(a, answer) {
+			answer = a
+		}
+	}
+	return answer
 }
 
 func containsIgnoreCase(s []string, e string) bool {
@@ -0,0 +1,92 @@
+package slackbot
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestByBuildNumber(t *testing.T) {
+	now := time.Now()
+	var activities []jenkinsv1.PipelineActivity
+	for _, build := range []string{"10", "9", "2", "100"} {
+		activities = append(activities, *testpipelines.CreateTestPipelineActivity("jx", "myorg", "myrepo", "main", "release", build, jenkinsv1.ActivityStatusTypeSucceeded))
+	}
+	// builds without a number fall back to the creation time
+	for i, name := range []string{"unknown-late", "unknown-early"} {
+		hours := 2 - i
+		pa := testpipelines.CreateTestPipelineActivity("jx", "myorg", "myrepo", "main", "release", "", jenkinsv1.ActivityStatusTypeSucceeded)
+		pa.Name = name
+		pa.CreationTimestamp = metav1.NewTime(now.Add(time.Duration(hours) * time.Hour))
+		activities = append(activities, *pa)
+	}
+	sort.Sort(byBuildNumber(activities))
+
+	var names []string
+	for _, a := range activities {
+		names = append(names, a.Name)
+	}
+	assert.Equal(t, []string{"myorg-myrepo-main-release-2", "myorg-myrepo-main-release-9", "myorg-myrepo-main-release-10", "myorg-myrepo-main-release-100"}, names[:4], "numeric build order")
+	assert.Equal(t, []string{"unknown-early", "unknown-late"}, names[4:], "creation time order")
+}
+
+func TestFindPipelineActivitiesGroupsContexts(t *testing.T) {
+	ns := "jx"
+	lint1 := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "PR-1", "lint", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+	lint10 := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "PR-1", "lint", "10", jenkinsv1.ActivityStatusTypeSucceeded)
+	lint9 := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "PR-1", "lint", "9", jenkinsv1.ActivityStatusTypeSucceeded)
+	unit2 := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "PR-1", "unit", "2", jenkinsv1.ActivityStatusTypeRunning)
+	otherPR := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "PR-2", "lint", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+
+	o := &Options{
+		JXClient: fakejx.NewSimpleClientset(lint1, lint10, lint9, unit2, otherPR),
+	}
+	o.Namespace = ns
+
+	oldest, latest, all, err := o.findPipelineActivities(context.TODO(), unit2)
+	require.NoError(t, err, "failed to find pipeline activities")
+	require.NotNil(t, oldest, "oldest")
+	require.NotNil(t, latest, "latest")
+	assert.Len(t, all, 4, "should group all the contexts of the pull request")
+	assert.Equal(t, lint1.Name, oldest.Name, "oldest activity across contexts")
+	assert.Equal(t, unit2.Name, latest.Name, "the latest build of the unit context")
+	assert.False(t, isEarlierBuild(unit2, latest), "build 2 of the unit context should not be skipped")
+
+	_, latest, _, err = o.findPipelineActivities(context.TODO(), lint9)
+	require.NoError(t, err, "failed to find pipeline activities")
+	require.NotNil(t, latest, "latest")
+	assert.Equal(t, lint10.Name, latest.Name, "the latest build of the lint context")
+	assert.True(t, isEarlierBuild(lint9, latest), "build 9 of the lint context should be skipped")
+}
+
+func TestFindPipelineActivitiesOldestByCreation(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+	create := func(pipelineCtx, build string, age time.Duration) *jenkinsv1.PipelineActivity {
+		pa := testpipelines.CreateTestPipelineActivity(ns, "myorg", "myrepo", "PR-1", pipelineCtx, build, jenkinsv1.ActivityStatusTypeSucceeded)
+		pa.CreationTimestamp = metav1.NewTime(now.Add(-age))
+		return pa
+	}
+	// the first lint builds have been garbage collected so build 1 of the new unit context is not the oldest
+	lint5 := create("lint", "5", 2*time.Hour)
+	unit1 := create("unit", "1", time.Hour)
+
+	o := &Options{
+		JXClient: fakejx.NewSimpleClientset(lint5, unit1),
+	}
+	o.Namespace = ns
+
+	oldest, latest, _, err := o.findPipelineActivities(context.TODO(), unit1)
+	require.NoError(t, err, "failed to find pipeline activities")
+	require.NotNil(t, oldest, "oldest")
+	assert.Equal(t, lint5.Name, oldest.Name, "the first created activity holds the review message")
+	assert.Equal(t, unit1.Name, latest.Name, "the latest build of the unit context")
+}
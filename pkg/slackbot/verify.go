@@ -0,0 +1,271 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/jenkins-x/jx-gitops/pkg/sourceconfigs"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+var (
+	validNotifyKinds = []v1alpha1.NotifyKind{
+		v1alpha1.NotifyKindNone,
+		v1alpha1.NotifyKindNever,
+		v1alpha1.NotifyKindAlways,
+		v1alpha1.NotifyKindFailure,
+		v1alpha1.NotifyKindFailureOrFirstSuccess,
+		v1alpha1.NotifyKindSuccess,
+		NotifyKindChanges,
+	}
+
+	validPipelineKinds = []v1alpha1.PipelineKind{
+		v1alpha1.PipelineKindAll,
+		v1alpha1.PipelineKindNone,
+		v1alpha1.PipelineKindRelease,
+		v1alpha1.PipelineKindPullRequest,
+	}
+)
+
+// VerifyOptions options for verifying the slack configuration of the repositories in the SourceConfig
+type VerifyOptions struct {
+	Options
+	Out io.Writer
+
+	channels       map[string]map[string]slack.Channel
+	connectionsErr error
+}
+
+// VerifyProblem a problem with the slack configuration of a repository
+type VerifyProblem struct {
+	Repository string
+	Message    string
+}
+
+// Validate loads the SourceConfig and creates the slack clients used to verify the channels
+func (o *VerifyOptions) Validate() error {
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	if o.Dir == "" {
+		o.Dir = "."
+	}
+	var err error
+	if o.SourceConfigs == nil {
+		o.SourceConfigs, err = sourceconfigs.LoadSourceConfig(o.Dir, true)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load source configs from dir %s", o.Dir)
+		}
+	}
+	if o.Config == nil {
+		o.Config, err = LoadConfig(o.Dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load jx-slack config from dir %s", o.Dir)
+		}
+	}
+	if o.hasSlackConnections() {
+		// lets create the clients of the named slack connections so that their channels are verified too
+		o.KubeClient, o.Namespace, err = kube.LazyCreateKubeClientAndNamespace(o.KubeClient, o.Namespace)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the kube client to load the secrets of the slack connections")
+		}
+		o.connectionsErr = o.createConnections(context.TODO())
+		if o.connectionsErr != nil {
+			log.Logger().Warnf("failed to create the slack connections: %s", o.connectionsErr.Error())
+		}
+	}
+	if o.SlackClient == nil {
+		if o.SlackToken == "" {
+			log.Logger().Warnf("no $SLACK_TOKEN defined so only verifying the kinds of the slack configuration and not the channels of the default connection")
+			return nil
+		}
+		if o.SlackURL != "" {
+			o.SlackClient = slack.New(o.SlackToken, slack.OptionAPIURL(o.SlackURL))
+		} else {
+			o.SlackClient = slack.New(o.SlackToken)
+		}
+	}
+	return nil
+}
+
+// hasSlackConnections returns true if the configuration has any named slack connections
+func (o *VerifyOptions) hasSlackConnections() bool {
+	if o.Config == nil {
+		return false
+	}
+	for _, c := range o.Config.Connections {
+		if c.Kind == "" || c.Kind == notifiers.KindSlack {
+			return true
+		}
+	}
+	return false
+}
+
+// Run verifies the slack configuration returning an error if there are any problems
+func (o *VerifyOptions) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+	problems, err := o.Verify()
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		fmt.Fprintln(o.Out, "the slack configuration is valid")
+		return nil
+	}
+	for _, p := range problems {
+		fmt.Fprintf(o.Out, "%s: %s\n", p.Repository, p.Message)
+	}
+	return errors.Errorf("found %d problems in the slack configuration", len(problems))
+}
+
+// Verify returns the problems with the slack configuration of each repository
+func (o *VerifyOptions) Verify() ([]VerifyProblem, error) {
+	var problems []VerifyProblem
+	for i := range o.SourceConfigs.Spec.Groups {
+		group := &o.SourceConfigs.Spec.Groups[i]
+		for j := range group.Repositories {
+			repo := &group.Repositories[j]
+			cfg := repo.Slack
+			if cfg == nil {
+				continue
+			}
+			name := scm.Join(group.Owner, repo.Name)
+			messages, err := o.verifySlackNotify(cfg)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to verify the slack configuration of %s", name)
+			}
+			for _, m := range messages {
+				problems = append(problems, VerifyProblem{Repository: name, Message: m})
+			}
+		}
+	}
+	return problems, nil
+}
+
+// verifySlackNotify returns a message for each problem with the slack configuration
+func (o *VerifyOptions) verifySlackNotify(cfg *v1alpha1.SlackNotify) ([]string, error) {
+	var answer []string
+	if !containsNotifyKind(validNotifyKinds, cfg.Kind) {
+		answer = append(answer, fmt.Sprintf("invalid kind '%s', valid values are: %v", string(cfg.Kind), validNotifyKinds))
+	}
+	if !containsPipelineKind(validPipelineKinds, cfg.Pipeline) {
+		answer = append(answer, fmt.Sprintf("invalid pipeline '%s', valid values are: %v", string(cfg.Pipeline), validPipelineKinds))
+	}
+	if cfg.Channel == "" {
+		return answer, nil
+	}
+
+	connection, channel := SplitChannel(cfg.Channel)
+	client := o.SlackClient
+	if connection != "" {
+		kind, ok := o.connectionKind(connection)
+		if !ok {
+			return append(answer, fmt.Sprintf("unknown connection '%s' in channel %s", connection, cfg.Channel)), nil
+		}
+		if kind != "" && kind != notifiers.KindSlack {
+			return answer, nil
+		}
+		client = o.Connections[connection]
+		if client == nil {
+			message := fmt.Sprintf("cannot verify channel %s as the client of connection '%s' could not be created", cfg.Channel, connection)
+			if o.connectionsErr != nil {
+				message += ": " + o.connectionsErr.Error()
+			}
+			return append(answer, message), nil
+		}
+	}
+	if client == nil {
+		log.Logger().Debugf("not verifying channel %s as there is no slack client", cfg.Channel)
+		return answer, nil
+	}
+
+	channels, err := o.listChannels(connection, client)
+	if err != nil {
+		return nil, err
+	}
+	ch, ok := channels[strings.TrimPrefix(channel, "#")]
+	switch {
+	case !ok:
+		answer = append(answer, fmt.Sprintf("channel %s does not exist or is a private channel the bot is not a member of", cfg.Channel))
+	case !ch.IsMember:
+		answer = append(answer, fmt.Sprintf("the bot is not a member of channel %s", cfg.Channel))
+	}
+	return answer, nil
+}
+
+// connectionKind returns the kind of the named connection and if it exists
+func (o *VerifyOptions) connectionKind(name string) (string, bool) {
+	if o.Config == nil {
+		return "", false
+	}
+	for _, c := range o.Config.Connections {
+		if c.Name == name {
+			return c.Kind, true
+		}
+	}
+	return "", false
+}
+
+// listChannels lazily loads the channels of the connection indexed by name and ID
+func (o *VerifyOptions) listChannels(connection string, client slacker.Interface) (map[string]slack.Channel, error) {
+	if o.channels == nil {
+		o.channels = map[string]map[string]slack.Channel{}
+	}
+	answer := o.channels[connection]
+	if answer != nil {
+		return answer, nil
+	}
+	answer = map[string]slack.Channel{}
+	params := &slack.GetConversationsParameters{
+		ExcludeArchived: true,
+		Limit:           1000,
+		Types:           []string{"public_channel", "private_channel"},
+	}
+	for {
+		channels, cursor, err := client.GetConversations(params)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list slack channels")
+		}
+		for _, c := range channels {
+			answer[c.Name] = c
+			answer[c.ID] = c
+		}
+		if cursor == "" {
+			break
+		}
+		params.Cursor = cursor
+	}
+	o.channels[connection] = answer
+	return answer, nil
+}
+
+func containsNotifyKind(kinds []v1alpha1.NotifyKind, kind v1alpha1.NotifyKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPipelineKind(kinds []v1alpha1.PipelineKind, kind v1alpha1.PipelineKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
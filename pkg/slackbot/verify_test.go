@@ -0,0 +1,175 @@
+package slackbot_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slackbot"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestVerify(t *testing.T) {
+	slackNotify := func(channel string, kind v1alpha1.NotifyKind, pipeline v1alpha1.PipelineKind) *v1alpha1.SlackNotify {
+		return &v1alpha1.SlackNotify{
+			Channel:  channel,
+			Kind:     kind,
+			Pipeline: pipeline,
+		}
+	}
+	sourceConfig := &v1alpha1.SourceConfig{
+		Spec: v1alpha1.SourceConfigSpec{
+			Groups: []v1alpha1.RepositoryGroup{
+				{
+					Provider: "https://fake.git",
+					Owner:    "myorg",
+					Repositories: []v1alpha1.Repository{
+						{
+							Name:  "valid",
+							Slack: slackNotify("#builds", v1alpha1.NotifyKindAlways, v1alpha1.PipelineKindAll),
+						},
+						{
+							Name:  "bad-kind",
+							Slack: slackNotify("#builds", "sometimes", v1alpha1.PipelineKindAll),
+						},
+						{
+							Name:  "bad-pipeline",
+							Slack: slackNotify("#builds", v1alpha1.NotifyKindAlways, "nightly"),
+						},
+						{
+							Name:  "missing-channel",
+							Slack: slackNotify("#does-not-exist", v1alpha1.NotifyKindAlways, v1alpha1.PipelineKindAll),
+						},
+						{
+							Name:  "not-member",
+							Slack: slackNotify("#random", v1alpha1.NotifyKindAlways, v1alpha1.PipelineKindAll),
+						},
+						{
+							Name:  "unknown-connection",
+							Slack: slackNotify("other:#builds", v1alpha1.NotifyKindAlways, v1alpha1.PipelineKindAll),
+						},
+						{
+							Name:  "teams",
+							Slack: slackNotify("myteams:#anything", v1alpha1.NotifyKindAlways, v1alpha1.PipelineKindAll),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	channel := func(id, name string, member bool) slack.Channel {
+		c := slack.Channel{IsMember: member}
+		c.ID = id
+		c.Name = name
+		return c
+	}
+	fakeSlackClient := fakeslack.NewFakeSlack()
+	fakeSlackClient.Channels = []slack.Channel{
+		channel("C1", "builds", true),
+		channel("C2", "random", false),
+	}
+
+	out := &bytes.Buffer{}
+	o := &slackbot.VerifyOptions{Out: out}
+	o.SlackClient = fakeSlackClient
+	o.SourceConfigs = sourceConfig
+	o.Config = &slackbot.Config{
+		Connections: []slackbot.Connection{
+			{
+				Name: "myteams",
+				Kind: "teams",
+			},
+		},
+	}
+
+	err := o.Run()
+	require.Error(t, err, "should have failed to verify")
+	assert.Equal(t, "found 5 problems in the slack configuration", err.Error())
+
+	text := out.String()
+	t.Logf("verify output:\n%s\n", text)
+	assert.NotContains(t, text, "myorg/valid", "output")
+	assert.NotContains(t, text, "myorg/teams", "output")
+	assert.Contains(t, text, "myorg/bad-kind: invalid kind 'sometimes'", "output")
+	assert.Contains(t, text, "myorg/bad-pipeline: invalid pipeline 'nightly'", "output")
+	assert.Contains(t, text, "myorg/missing-channel: channel #does-not-exist does not exist", "output")
+	assert.Contains(t, text, "myorg/not-member: the bot is not a member of channel #random", "output")
+	assert.Contains(t, text, "myorg/unknown-connection: unknown connection 'other'", "output")
+}
+
+func TestVerifyNamedConnections(t *testing.T) {
+	slackNotify := func(channel string) *v1alpha1.SlackNotify {
+		return &v1alpha1.SlackNotify{
+			Channel:  channel,
+			Kind:     v1alpha1.NotifyKindAlways,
+			Pipeline: v1alpha1.PipelineKindAll,
+		}
+	}
+	sourceConfig := &v1alpha1.SourceConfig{
+		Spec: v1alpha1.SourceConfigSpec{
+			Groups: []v1alpha1.RepositoryGroup{
+				{
+					Provider: "https://fake.git",
+					Owner:    "myorg",
+					Repositories: []v1alpha1.Repository{
+						{
+							Name:  "valid",
+							Slack: slackNotify("acme:#builds"),
+						},
+						{
+							Name:  "missing-channel",
+							Slack: slackNotify("acme:#does-not-exist"),
+						},
+						{
+							Name:  "broken-connection",
+							Slack: slackNotify("broken:#builds"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	builds := slack.Channel{IsMember: true}
+	builds.ID = "C1"
+	builds.Name = "builds"
+	acmeClient := fakeslack.NewFakeSlack()
+	acmeClient.Channels = []slack.Channel{builds}
+
+	out := &bytes.Buffer{}
+	o := &slackbot.VerifyOptions{Out: out}
+	o.KubeClient = fake.NewSimpleClientset()
+	o.Namespace = "jx"
+	o.SourceConfigs = sourceConfig
+	o.Connections = map[string]slacker.Interface{
+		"acme": acmeClient,
+	}
+	o.Config = &slackbot.Config{
+		Connections: []slackbot.Connection{
+			{
+				Name:        "acme",
+				TokenSecret: "jx-slack-acme",
+			},
+			{
+				Name:        "broken",
+				TokenSecret: "jx-slack-broken",
+			},
+		},
+	}
+
+	err := o.Run()
+	require.Error(t, err, "should have failed to verify")
+	assert.Equal(t, "found 2 problems in the slack configuration", err.Error())
+
+	text := out.String()
+	t.Logf("verify output:\n%s\n", text)
+	assert.NotContains(t, text, "myorg/valid", "output")
+	assert.Contains(t, text, "myorg/missing-channel: channel acme:#does-not-exist does not exist", "output")
+	assert.Contains(t, text, "myorg/broken-connection: cannot verify channel broken:#builds as the client of connection 'broken' could not be created", "output")
+}
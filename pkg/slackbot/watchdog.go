@@ -0,0 +1,257 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultWatchdogInterval = 5 * time.Minute
+	defaultWatchdogRunning  = time.Hour
+	defaultWatchdogPending  = time.Hour
+	defaultWatchdogMaxAge   = 24 * time.Hour
+
+	// the values of the StuckAnnotation
+	stuckRunning = "running"
+	stuckPending = "pending"
+)
+
+// RunWatchdog periodically warns about the pipelines which have been running or pending for too long until the
+// stopper is closed
+func (o *Options) RunWatchdog(stopper chan struct{}) {
+	interval := durationOrDefault(o.Config.Watchdog.Interval, defaultWatchdogInterval)
+	log.Logger().Infof("Checking for stuck pipelines every %s", interval.String())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		o.serialize(func() {
+			err := o.CheckStuckPipelines(context.TODO(), time.Now())
+			if err != nil {
+				log.Logger().Warnf("failed to check for stuck pipelines: %s", err.Error())
+			}
+		})
+		select {
+		case <-stopper:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckStuckPipelines warns about the PipelineActivities in the watched namespaces which have been running or
+// pending for longer than their thresholds. Activities started before the max age are ignored so that orphaned
+// activities from long ago are not all warned about when the watchdog starts
+func (o *Options) CheckStuckPipelines(ctx context.Context, now time.Time) error {
+	if o.Config == nil || o.Config.Watchdog == nil {
+		return nil
+	}
+	oldest := now.Add(-durationOrDefault(o.Config.Watchdog.MaxAge, defaultWatchdogMaxAge))
+
+	// lets remember the warnings as well as annotating the activities as the annotations are not saved in dry run
+	// mode. Only the activities which are still stuck are kept so that the warnings do not grow forever
+	warned := map[string]string{}
+	for _, ns := range o.watchNamespaces() {
+		list, err := o.JXClient.JenkinsV1().PipelineActivities(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to list PipelineActivities in namespace %s", ns)
+		}
+		for i := range list.Items {
+			activity := &list.Items[i]
+			if activity.Spec.Status.IsTerminated() || activityStartTime(activity).Before(oldest) {
+				continue
+			}
+			stuck, err := o.stuckReason(ctx, activity, now)
+			if err != nil {
+				log.Logger().Warnf("failed to check if %s is stuck: %s", activity.Name, err.Error())
+				continue
+			}
+			if stuck == "" {
+				continue
+			}
+			key := activity.Namespace + "/" + activity.Name
+			if activity.Annotations[StuckAnnotation] == stuck || o.stuckWarnings[key] == stuck {
+				warned[key] = stuck
+				continue
+			}
+			err = o.warnStuckPipeline(ctx, activity, stuck, now)
+			if err != nil {
+				log.Logger().Warnf("failed to warn that %s is stuck: %s", activity.Name, err.Error())
+				continue
+			}
+			warned[key] = stuck
+		}
+	}
+	o.stuckWarnings = warned
+	return nil
+}
+
+// stuckReason returns stuckRunning or stuckPending if the activity has exceeded the threshold of its repository
+func (o *Options) stuckReason(ctx context.Context, activity *jenkinsv1.PipelineActivity, now time.Time) (string, error) {
+	running, pending := o.watchdogThresholds(activity)
+	elapsed := now.Sub(activityStartTime(activity))
+	switch activity.Spec.Status {
+	case jenkinsv1.ActivityStatusTypeRunning:
+		if elapsed > running {
+			return stuckRunning, nil
+		}
+	case jenkinsv1.ActivityStatusTypePending, "":
+		if elapsed <= pending {
+			return "", nil
+		}
+		hasPod, err := o.hasPipelinePod(ctx, activity)
+		if err != nil {
+			return "", err
+		}
+		if !hasPod {
+			return stuckPending, nil
+		}
+		if elapsed > running {
+			return stuckRunning, nil
+		}
+	}
+	return "", nil
+}
+
+// watchdogThresholds returns the running and pending thresholds of the repository of the activity from the watchdog
+// block of its slack configuration in the SourceConfig, falling back to the watchdog configuration
+func (o *Options) watchdogThresholds(activity *jenkinsv1.PipelineActivity) (time.Duration, time.Duration) {
+	w := o.Config.Watchdog
+	running := durationOrDefault(w.Running, defaultWatchdogRunning)
+	pending := durationOrDefault(w.Pending, defaultWatchdogPending)
+	details := CreatePipelineDetails(activity)
+	if r := o.repositoryWatchdogsFor(activity.Namespace)[details.GitOwner+"/"+details.GitRepository]; r != nil {
+		return durationOrDefault(r.Running, running), durationOrDefault(r.Pending, pending)
+	}
+	for i := range w.Repositories {
+		r := &w.Repositories[i]
+		if matchesRepository(r.Repositories, activity) {
+			return durationOrDefault(r.Running, running), durationOrDefault(r.Pending, pending)
+		}
+	}
+	return running, pending
+}
+
+// hasPipelinePod returns true if there is a pod for the build of the activity
+func (o *Options) hasPipelinePod(ctx context.Context, activity *jenkinsv1.PipelineActivity) (bool, error) {
+	pods, err := o.pipelinePods(ctx, activity)
+	if err != nil {
+		return false, err
+	}
+	return len(pods) > 0, nil
+}
+
+// warnStuckPipeline annotates the activity as stuck and then either edits its existing pipeline or checklist message,
+// which shows the warning while the activity is annotated, or posts a new warning message
+func (o *Options) warnStuckPipeline(ctx context.Context, activity *jenkinsv1.PipelineActivity, stuck string, now time.Time) error {
+	err := o.annotatePipelineActivity(ctx, activity, StuckAnnotation, stuck)
+	if err != nil {
+		return errors.Wrapf(err, "failed to annotate %s", activity.Name)
+	}
+	if activity.Annotations == nil {
+		activity.Annotations = map[string]string{}
+	}
+	activity.Annotations[StuckAnnotation] = stuck
+
+	cfg := o.getSlackConfigForPipeline(activity)
+	if cfg == nil || cfg.Channel == "" {
+		return nil
+	}
+	connection, channel := SplitChannel(cfg.Channel)
+	if o.Notifiers[connection] != nil {
+		log.Logger().Debugf("stuck pipeline warnings are only sent to slack so ignoring connection %s for %s", connection, activity.Name)
+		return nil
+	}
+	bot, err := o.forConnection(connection)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the slack connection for %s", activity.Name)
+	}
+	channel = channelName(channel)
+
+	messageType := pipelineMessageType
+	messageActivity := activity
+	if bot.useChecklist(activity) {
+		// the checklist message is stored against the first activity of the pull request commit
+		messageType = checklistMessageType
+		first, _, _, err := bot.checklistActivities(ctx, activity)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find the checklist of %s", activity.Name)
+		}
+		if first != nil {
+			messageActivity = first
+		}
+	}
+	if bot.findMessageRef(messageActivity, channel, messageType) != nil {
+		return bot.pipelineMessage(activity, cfg, channel)
+	}
+
+	attachment := slack.Attachment{
+		CallbackID: "stuck:" + activity.Name,
+		Color:      "warning",
+		Title:      describeStuckPipeline(activity),
+		TitleLink:  o.pipelineBuildURL(activity),
+		Text:       stuckWarning(activity, now),
+	}
+	_, _, _, err = bot.SlackClient.SendMessage(channel, slack.MsgOptionAttachments(attachment))
+	if err != nil {
+		return errors.Wrapf(err, "failed to post stuck pipeline warning to channel %s", channel)
+	}
+	log.Logger().Infof("Stuck pipeline warning for %s sent to %s\n", activity.Name, channel)
+	return nil
+}
+
+// describeStuckPipeline describes the pipeline such as 'myorg/myrepo main (release #3)'
+func describeStuckPipeline(activity *jenkinsv1.PipelineActivity) string {
+	details := CreatePipelineDetails(activity)
+	pipelineCtx := details.Context
+	if pipelineCtx == "" {
+		pipelineCtx = "Build"
+	}
+	return fmt.Sprintf("%s/%s %s (%s #%s)", details.GitOwner, details.GitRepository, details.BranchName, pipelineCtx, details.Build)
+}
+
+// stuckWarning returns the warning shown for a pipeline which the watchdog has annotated as stuck or an empty
+// string if it is not stuck
+func stuckWarning(activity *jenkinsv1.PipelineActivity, now time.Time) string {
+	if activity.Spec.Status.IsTerminated() {
+		return ""
+	}
+	elapsed := describeDuration(now.Sub(activityStartTime(activity)))
+	switch activity.Annotations[StuckAnnotation] {
+	case stuckRunning:
+		return fmt.Sprintf(":hourglass: this pipeline has been running for %s", elapsed)
+	case stuckPending:
+		return fmt.Sprintf(":hourglass: this pipeline has been pending for %s without a pod", elapsed)
+	default:
+		return ""
+	}
+}
+
+// describeDuration describes the duration to the nearest minute such as 1h5m
+func describeDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	switch {
+	case hours > 0 && minutes > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+func durationOrDefault(d *metav1.Duration, defaultValue time.Duration) time.Duration {
+	if d == nil || d.Duration <= 0 {
+		return defaultValue
+	}
+	return d.Duration
+}
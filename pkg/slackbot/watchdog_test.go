@@ -0,0 +1,254 @@
+package slackbot
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker/fakeslack"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/testpipelines"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckStuckPipelines(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	channel := "#builds"
+	now := time.Now()
+
+	createActivity := func(repo, build string, status jenkinsv1.ActivityStatusType, started time.Duration) *jenkinsv1.PipelineActivity {
+		pa := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "main", "release", build, status)
+		pa.CreationTimestamp = metav1.NewTime(now.Add(-started))
+		if status == jenkinsv1.ActivityStatusTypeRunning {
+			startedTime := metav1.NewTime(now.Add(-started))
+			pa.Spec.StartedTimestamp = &startedTime
+		}
+		return pa
+	}
+	stuckRunningActivity := createActivity("myrepo", "1", jenkinsv1.ActivityStatusTypeRunning, 2*time.Hour)
+	stuckPendingActivity := createActivity("myrepo", "2", jenkinsv1.ActivityStatusTypePending, 2*time.Hour)
+	pendingWithPod := createActivity("myrepo", "3", jenkinsv1.ActivityStatusTypePending, 2*time.Hour)
+	recentActivity := createActivity("myrepo", "4", jenkinsv1.ActivityStatusTypeRunning, 10*time.Minute)
+	slowRepoActivity := createActivity("slowrepo", "1", jenkinsv1.ActivityStatusTypeRunning, 2*time.Hour)
+	succeeded := createActivity("myrepo", "5", jenkinsv1.ActivityStatusTypeSucceeded, 3*time.Hour)
+
+	// the running activity already has a pipeline message which should be edited
+	messageChannelID := "C123"
+	messageTimestamp := "1234.5678"
+	stuckRunningActivity.Annotations = map[string]string{
+		annotationKey(channel, pipelineMessageType): annotationValue(messageChannelID, messageTimestamp),
+	}
+
+	// orphaned activities from long ago are ignored
+	orphaned := createActivity("myrepo", "6", jenkinsv1.ActivityStatusTypeRunning, 72*time.Hour)
+
+	pod := createStepPod(ns, owner, "myrepo", "main", "release", "3")
+
+	scmClient, _ := fakescm.NewDefault()
+	slackClient := fakeslack.NewFakeSlack()
+	repo := func(name string) v1alpha1.Repository {
+		return v1alpha1.Repository{
+			Name: name,
+			Slack: &v1alpha1.SlackNotify{
+				Channel:  channel,
+				Kind:     v1alpha1.NotifyKindAlways,
+				Pipeline: v1alpha1.PipelineKindAll,
+			},
+		}
+	}
+	o := &Options{
+		KubeClient:  fake.NewSimpleClientset(pod),
+		JXClient:    fakejx.NewSimpleClientset(stuckRunningActivity, stuckPendingActivity, pendingWithPod, recentActivity, slowRepoActivity, succeeded, orphaned),
+		ScmClient:   scmClient,
+		SlackClient: slackClient,
+		SourceConfigs: &v1alpha1.SourceConfig{
+			Spec: v1alpha1.SourceConfigSpec{
+				Groups: []v1alpha1.RepositoryGroup{
+					{
+						Provider:     "https://fake.git",
+						Owner:        owner,
+						Repositories: []v1alpha1.Repository{repo("myrepo"), repo("slowrepo")},
+					},
+				},
+			},
+		},
+		Config: &Config{
+			Watchdog: &Watchdog{
+				Repositories: []WatchdogThresholds{
+					{
+						Repositories: []string{"myorg/slowrepo"},
+						Running:      &metav1.Duration{Duration: 3 * time.Hour},
+					},
+				},
+			},
+		},
+	}
+	o.Namespace = ns
+
+	ctx := context.TODO()
+	err := o.CheckStuckPipelines(ctx, now)
+	require.NoError(t, err, "failed to check for stuck pipelines")
+
+	// the existing message of the running activity should be edited
+	require.Len(t, slackClient.Messages[messageChannelID], 1, "should have edited the pipeline message")
+	_, values, err := slack.UnsafeApplyMsgOptions("fakeToken", messageChannelID, "fakeapiurl", slackClient.Messages[messageChannelID][0].Options...)
+	require.NoError(t, err, "failed to render message")
+	assert.Equal(t, messageTimestamp, values.Get("ts"), "should have updated the existing message")
+	assert.Contains(t, values.Get("attachments"), "this pipeline has been running for 2h", "attachments")
+
+	// the pending activity without a pod has no message so a new warning is posted
+	require.Len(t, slackClient.Messages[channel], 1, "should have posted a warning for the pending pipeline")
+	_, values, err = slack.UnsafeApplyMsgOptions("fakeToken", channel, "fakeapiurl", slackClient.Messages[channel][0].Options...)
+	require.NoError(t, err, "failed to render message")
+	attachments := values.Get("attachments")
+	assert.Contains(t, attachments, "myorg/myrepo main (release #2)", "attachments")
+	assert.Contains(t, attachments, "this pipeline has been pending for 2h without a pod", "attachments")
+
+	for _, pa := range []*jenkinsv1.PipelineActivity{stuckRunningActivity, stuckPendingActivity, pendingWithPod, recentActivity, slowRepoActivity, orphaned} {
+		current, err := o.JXClient.JenkinsV1().PipelineActivities(ns).Get(ctx, pa.Name, metav1.GetOptions{})
+		require.NoError(t, err, "failed to get %s", pa.Name)
+		expected := ""
+		switch pa {
+		case stuckRunningActivity:
+			expected = stuckRunning
+		case stuckPendingActivity:
+			expected = stuckPending
+		}
+		assert.Equal(t, expected, current.Annotations[StuckAnnotation], "stuck annotation of %s", pa.Name)
+	}
+
+	// the warnings should only be sent once
+	err = o.CheckStuckPipelines(ctx, now.Add(time.Minute))
+	require.NoError(t, err, "failed to check for stuck pipelines")
+	assert.Len(t, slackClient.Messages[messageChannelID], 1, "should not edit the pipeline message again")
+	assert.Len(t, slackClient.Messages[channel], 1, "should not post another warning")
+
+	// in dry run mode the annotations are not saved but the warnings should still only be sent once
+	dryRunPending := createActivity("myrepo", "7", jenkinsv1.ActivityStatusTypePending, 2*time.Hour)
+	_, err = o.JXClient.JenkinsV1().PipelineActivities(ns).Create(ctx, dryRunPending, metav1.CreateOptions{})
+	require.NoError(t, err, "failed to create %s", dryRunPending.Name)
+	o.DryRun = true
+	for i := 2; i < 4; i++ {
+		err = o.CheckStuckPipelines(ctx, now.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, err, "failed to check for stuck pipelines")
+		assert.Len(t, slackClient.Messages[channel], 2, "should only warn once about %s in dry run mode", dryRunPending.Name)
+	}
+}
+
+func TestCheckStuckPipelinesEditsChecklist(t *testing.T) {
+	ns := "jx"
+	owner := "myorg"
+	repo := "myrepo"
+	channel := "#builds"
+	sha := "1234567890abcdef"
+	now := time.Now()
+
+	build := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "PR-1", "pr-build", "1", jenkinsv1.ActivityStatusTypeRunning)
+	lint := testpipelines.CreateTestPipelineActivity(ns, owner, repo, "PR-1", "lint", "1", jenkinsv1.ActivityStatusTypeSucceeded)
+	started := metav1.NewTime(now.Add(-2 * time.Hour))
+	build.CreationTimestamp = started
+	build.Spec.StartedTimestamp = &started
+	lint.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+	for _, a := range []*jenkinsv1.PipelineActivity{build, lint} {
+		a.Spec.LastCommitSHA = sha
+	}
+
+	o, slackClient := createChecklistOptions(ns, owner, repo, channel, build, lint)
+	o.Config.Watchdog = &Watchdog{}
+
+	// lets post the checklist which the stuck context should then be shown in
+	err := o.PipelineMessage(lint)
+	require.NoError(t, err, "failed to process %s", lint.Name)
+	require.Len(t, slackClient.Messages[channel], 1, "should post one checklist message")
+
+	err = o.CheckStuckPipelines(context.TODO(), now)
+	require.NoError(t, err, "failed to check for stuck pipelines")
+	require.Len(t, slackClient.Messages[channel], 2, "should edit the checklist rather than post a warning")
+
+	_, values, err := slack.UnsafeApplyMsgOptions("fakeToken", channel, "fakeapiurl", slackClient.Messages[channel][1].Options...)
+	require.NoError(t, err, "failed to render message")
+	assert.Equal(t, slackClient.Messages[channel][0].Timestamp, values.Get("ts"), "should update the checklist message")
+	attachments := values.Get("attachments")
+	assert.Contains(t, attachments, "checklist:"+build.Name, "attachments")
+	assert.Contains(t, attachments, "this pipeline has been running for 2h", "attachments")
+}
+
+func TestWatchdogThresholdsFromSourceConfig(t *testing.T) {
+	dir := t.TempDir()
+	sourceConfig := `apiVersion: gitops.jenkins-x.io/v1alpha1
+kind: SourceConfig
+spec:
+  slack:
+    channel: "#builds"
+  groups:
+  - owner: myorg
+    provider: https://fake.git
+    slack:
+      channel: "#builds"
+      watchdog:
+        running: 3h
+    repositories:
+    - name: slowrepo
+    - name: myrepo
+      slack:
+        channel: "#myrepo"
+        watchdog:
+          pending: 10m
+  - owner: other
+    provider: https://fake.git
+    repositories:
+    - name: plain
+`
+	path := filepath.Join(dir, ".jx", "gitops", sourceConfigFileName)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), files.DefaultDirWritePermissions), "failed to create dir")
+	require.NoError(t, ioutil.WriteFile(path, []byte(sourceConfig), files.DefaultFileWritePermissions), "failed to write %s", path)
+
+	watchdogs, err := LoadRepositoryWatchdogs(dir)
+	require.NoError(t, err, "failed to load the watchdog thresholds")
+	require.Len(t, watchdogs, 2, "watchdog thresholds %#v", watchdogs)
+
+	o := &Options{
+		Config: &Config{
+			Watchdog: &Watchdog{
+				Pending: &metav1.Duration{Duration: 30 * time.Minute},
+			},
+		},
+		repositoryWatchdogs: watchdogs,
+	}
+	o.Namespace = "jx"
+
+	testCases := []struct {
+		owner           string
+		repo            string
+		expectedRunning time.Duration
+		expectedPending time.Duration
+	}{
+		{owner: "myorg", repo: "slowrepo", expectedRunning: 3 * time.Hour, expectedPending: 30 * time.Minute},
+		{owner: "myorg", repo: "myrepo", expectedRunning: time.Hour, expectedPending: 10 * time.Minute},
+		{owner: "other", repo: "plain", expectedRunning: time.Hour, expectedPending: 30 * time.Minute},
+	}
+	for _, tc := range testCases {
+		pa := testpipelines.CreateTestPipelineActivity("jx", tc.owner, tc.repo, "main", "release", "1", jenkinsv1.ActivityStatusTypeRunning)
+		running, pending := o.watchdogThresholds(pa)
+		assert.Equal(t, tc.expectedRunning, running, "running threshold of %s/%s", tc.owner, tc.repo)
+		assert.Equal(t, tc.expectedPending, pending, "pending threshold of %s/%s", tc.owner, tc.repo)
+	}
+}
+
+func TestDescribeDuration(t *testing.T) {
+	assert.Equal(t, "5m", describeDuration(5*time.Minute+10*time.Second))
+	assert.Equal(t, "2h", describeDuration(2*time.Hour))
+	assert.Equal(t, "1h5m", describeDuration(time.Hour+5*time.Minute))
+}
@@ -0,0 +1,116 @@
+package slackbot
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+const (
+	// webhookQueueSize the number of notifications which can be waiting for a webhook before new ones are dropped
+	webhookQueueSize = 100
+)
+
+// webhookDelivery a notification waiting to be sent to a webhook
+type webhookDelivery struct {
+	activity     *jenkinsv1.PipelineActivity
+	key          string
+	status       string
+	notification *notifiers.Notification
+}
+
+// id identifies the notification of the status of the activity
+func (d *webhookDelivery) id() string {
+	return d.activity.Namespace + "/" + d.activity.Name + "/" + d.status
+}
+
+// webhookQueue sends the notifications to a webhook on its own goroutine so that the retries of a webhook which is
+// down do not hold up the PipelineActivity events or the other webhooks
+type webhookQueue struct {
+	name       string
+	webhook    notifiers.Notifier
+	deliveries chan *webhookDelivery
+
+	// pending the deliveries which are queued or being sent so that the events of the same activity do not queue the
+	// same notification again before the activity is annotated
+	lock    sync.Mutex
+	pending map[string]bool
+}
+
+// deadLetterer is implemented by the webhooks which can record the notifications they could not deliver
+type deadLetterer interface {
+	DeadLetter(notification *notifiers.Notification) error
+}
+
+// startWebhookQueues starts a queue for each webhook. Until they are started the webhooks are notified synchronously
+func (o *Options) startWebhookQueues() {
+	if o.webhookQueues != nil {
+		return
+	}
+	o.webhookQueues = map[string]*webhookQueue{}
+	for name, w := range o.Webhooks {
+		q := &webhookQueue{
+			name:       name,
+			webhook:    w,
+			deliveries: make(chan *webhookDelivery, webhookQueueSize),
+			pending:    map[string]bool{},
+		}
+		o.webhookQueues[name] = q
+		go func() {
+			for d := range q.deliveries {
+				o.deliverWebhook(context.TODO(), q, d)
+			}
+		}()
+	}
+}
+
+// enqueue queues the delivery returning false if it is already pending or the queue is full
+func (q *webhookQueue) enqueue(d *webhookDelivery) bool {
+	id := d.id()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.pending[id] {
+		return false
+	}
+	select {
+	case q.deliveries <- d:
+		q.pending[id] = true
+		return true
+	default:
+		log.Logger().Warnf("dropping the notification of %s as the queue of webhook %s is full", d.activity.Name, q.name)
+		if dl, ok := q.webhook.(deadLetterer); ok {
+			err := dl.DeadLetter(d.notification)
+			if err != nil {
+				log.Logger().Warnf("failed to dead letter the notification of %s for webhook %s: %s", d.activity.Name, q.name, err.Error())
+			}
+		}
+		return false
+	}
+}
+
+// done removes the delivery from the pending deliveries
+func (q *webhookQueue) done(d *webhookDelivery) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	delete(q.pending, d.id())
+}
+
+// deliverWebhook sends the notification to the webhook and annotates the activity once it has been delivered
+func (o *Options) deliverWebhook(ctx context.Context, q *webhookQueue, d *webhookDelivery) {
+	defer q.done(d)
+
+	err := q.webhook.Notify(ctx, "", d.notification)
+	if err != nil {
+		log.Logger().Warnf("failed to notify webhook %s of %s: %s", q.name, d.activity.Name, err.Error())
+		return
+	}
+	err = o.annotatePipelineActivity(ctx, d.activity, d.key, d.status)
+	if err != nil {
+		log.Logger().Warnf("failed to annotate %s: %s", d.activity.Name, err.Error())
+	}
+}
@@ -0,0 +1,178 @@
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-slack/pkg/notifiers"
+	"github.com/jenkins-x-plugins/jx-slack/pkg/slacker"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// MethodPostMessage a new message would have been created
+	MethodPostMessage = "chat.postMessage"
+
+	// MethodUpdate an existing message would have been updated
+	MethodUpdate = "chat.update"
+
+	// MethodNotify a notification would have been sent to a backend other than slack
+	MethodNotify = "notify"
+
+	// DirectMessagePrefix the prefix of the fake channel IDs of direct messages
+	DirectMessagePrefix = "dry-run-dm-"
+)
+
+// Message a message which would have been sent to slack
+type Message struct {
+	Channel string
+	Method  string
+	Payload string
+}
+
+// Client a slack client which logs and records the messages it would have sent instead of sending them.
+// Lookups of users are delegated to the real client if there is one
+type Client struct {
+	Delegate slacker.Interface
+	Messages []Message
+
+	lock  sync.Mutex
+	count int
+}
+
+var _ slacker.Interface = &Client{}
+
+// NewClient creates a new dry run client delegating lookups to the given client which may be nil
+func NewClient(delegate slacker.Interface) *Client {
+	return &Client{Delegate: delegate}
+}
+
+// OpenConversation returns a fake direct message channel for the users
+func (c *Client) OpenConversation(params *slack.OpenConversationParameters) (*slack.Channel, bool, bool, error) {
+	ch := &slack.Channel{}
+	ch.ID = DirectMessagePrefix
+	if params != nil && len(params.Users) > 0 {
+		ch.ID += params.Users[0]
+	}
+	return ch, false, false, nil
+}
+
+// SendMessage logs and records the message returning a fake timestamp
+func (c *Client) SendMessage(channel string, options ...slack.MsgOption) (string, string, string, error) {
+	endpoint, values, err := slack.UnsafeApplyMsgOptions("", channel, "", options...)
+	if err != nil {
+		return "", "", "", err
+	}
+	payload := map[string]interface{}{}
+	for k, v := range values {
+		if k == "token" || len(v) == 0 {
+			continue
+		}
+		if json.Valid([]byte(v[0])) && (k == "attachments" || k == "blocks") {
+			payload[k] = json.RawMessage(v[0])
+		} else {
+			payload[k] = v[0]
+		}
+	}
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return "", "", "", err
+	}
+
+	c.lock.Lock()
+	c.count++
+	timestamp := values.Get("ts")
+	if timestamp == "" {
+		timestamp = strconv.FormatInt(time.Now().Unix(), 10) + "." + strconv.Itoa(c.count)
+	}
+	c.Messages = append(c.Messages, Message{
+		Channel: channel,
+		Method:  endpoint,
+		Payload: string(data),
+	})
+	c.lock.Unlock()
+
+	action := "create"
+	if endpoint == MethodUpdate {
+		action = "update"
+	}
+	log.Logger().Infof("dry run: would %s message in channel %s via %s:\n%s", action, channel, endpoint, string(data))
+	return channel, timestamp, "", nil
+}
+
+// GetUserByEmail delegates to the real client if there is one
+func (c *Client) GetUserByEmail(email string) (*slack.User, error) {
+	if c.Delegate == nil {
+		return &slack.User{ID: "dry-run-" + email}, nil
+	}
+	return c.Delegate.GetUserByEmail(email)
+}
+
+// GetUserInfo delegates to the real client if there is one
+func (c *Client) GetUserInfo(user string) (*slack.User, error) {
+	if c.Delegate == nil {
+		return &slack.User{ID: user}, nil
+	}
+	return c.Delegate.GetUserInfo(user)
+}
+
+// GetConversations delegates to the real client if there is one
+func (c *Client) GetConversations(params *slack.GetConversationsParameters) ([]slack.Channel, string, error) {
+	if c.Delegate == nil {
+		return nil, "", nil
+	}
+	return c.Delegate.GetConversations(params)
+}
+
+// AddPin logs the item instead of pinning it
+func (c *Client) AddPin(channel string, item slack.ItemRef) error {
+	log.Logger().Infof("dry run: would pin message %s in channel %s", item.Timestamp, channel)
+	return nil
+}
+
+// PublishView logs the view instead of publishing it
+func (c *Client) PublishView(userID string, view slack.HomeTabViewRequest, _ string) (*slack.ViewResponse, error) {
+	data, err := json.MarshalIndent(view, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	log.Logger().Infof("dry run: would publish the home tab of %s:\n%s", userID, string(data))
+	return &slack.ViewResponse{}, nil
+}
+
+// Notifier logs and records the notifications it would have sent to another backend instead of sending them
+type Notifier struct {
+	Name     string
+	Messages []Message
+
+	lock sync.Mutex
+}
+
+var _ notifiers.Notifier = &Notifier{}
+
+// NewNotifier creates a new dry run notifier for the backend with the given name
+func NewNotifier(name string) *Notifier {
+	return &Notifier{Name: name}
+}
+
+// Notify logs the notification
+func (n *Notifier) Notify(_ context.Context, channel string, notification *notifiers.Notification) error {
+	data, err := json.MarshalIndent(notification, "", "  ")
+	if err != nil {
+		return err
+	}
+	n.lock.Lock()
+	n.Messages = append(n.Messages, Message{
+		Channel: channel,
+		Method:  MethodNotify,
+		Payload: string(data),
+	})
+	n.lock.Unlock()
+
+	log.Logger().Infof("dry run: would notify %s %s:\n%s", n.Name, channel, string(data))
+	return nil
+}
@@ -2,6 +2,7 @@ package fakeslack
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -19,7 +20,11 @@ import (
 // FakeSlack the fake slack
 type FakeSlack struct {
 	UsersByEmail map[string]*slack.User
+	UsersByID    map[string]*slack.User
 	Messages     map[string][]Message
+	HomeViews    map[string]slack.HomeTabViewRequest
+	Channels     []slack.Channel
+	Pins         map[string][]slack.ItemRef
 }
 
 type Message struct {
@@ -49,8 +54,13 @@ func NewFakeSlack() *FakeSlack {
 	return &FakeSlack{}
 }
 
-func (f *FakeSlack) OpenConversation(_ *slack.OpenConversationParameters) (*slack.Channel, bool, bool, error) {
-	return nil, false, false, nil
+// OpenConversation returns a direct message channel with the same ID as the user
+func (f *FakeSlack) OpenConversation(params *slack.OpenConversationParameters) (*slack.Channel, bool, bool, error) {
+	ch := &slack.Channel{}
+	if params != nil && len(params.Users) > 0 {
+		ch.ID = params.Users[0]
+	}
+	return ch, false, false, nil
 }
 
 func (f *FakeSlack) SendMessage(channel string, options ...slack.MsgOption) (string, string, string, error) {
@@ -76,6 +86,39 @@ func (f *FakeSlack) GetUserByEmail(email string) (*slack.User, error) {
 	return f.UsersByEmail[email], nil
 }
 
+func (f *FakeSlack) GetUserInfo(user string) (*slack.User, error) {
+	if f.UsersByID == nil {
+		f.UsersByID = map[string]*slack.User{}
+	}
+	u := f.UsersByID[user]
+	if u == nil {
+		return nil, errors.New("user_not_found")
+	}
+	return u, nil
+}
+
+func (f *FakeSlack) PublishView(userID string, view slack.HomeTabViewRequest, _ string) (*slack.ViewResponse, error) {
+	if f.HomeViews == nil {
+		f.HomeViews = map[string]slack.HomeTabViewRequest{}
+	}
+	f.HomeViews[userID] = view
+	return &slack.ViewResponse{}, nil
+}
+
+// GetConversations returns the channels of the fake slack in a single page
+func (f *FakeSlack) GetConversations(_ *slack.GetConversationsParameters) ([]slack.Channel, string, error) {
+	return f.Channels, "", nil
+}
+
+// AddPin records the pinned item in the channel
+func (f *FakeSlack) AddPin(channel string, item slack.ItemRef) error {
+	if f.Pins == nil {
+		f.Pins = map[string][]slack.ItemRef{}
+	}
+	f.Pins[channel] = append(f.Pins[channel], item)
+	return nil
+}
+
 // AssertMessageCount asserts the message count for the given channel
 func (f *FakeSlack) AssertMessageCount(t *testing.T, channel string, expectedCount int, expectedMessageDir string, expectedMessagePrefix string, generateTestOutput bool, message string) []Attachment {
 	if f.Messages == nil {
@@ -0,0 +1,166 @@
+package fakesocketmode
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	connectionsOpenPath = "/apps.connections.open"
+	websocketPath       = "/link"
+)
+
+// FakeServer a local stand in for the slack Socket Mode API. It hands out a websocket URL from
+// apps.connections.open, sends requests to the connected client and records its acknowledgements
+type FakeServer struct {
+	Server   *httptest.Server
+	requests chan *socketmode.Request
+
+	lock sync.Mutex
+	acks map[string]socketmode.Response
+}
+
+// NewFakeServer creates and starts a new fake socket mode server
+func NewFakeServer() *FakeServer {
+	f := &FakeServer{
+		requests: make(chan *socketmode.Request, 10),
+		acks:     map[string]socketmode.Response{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(connectionsOpenPath, f.handleConnectionsOpen)
+	mux.HandleFunc(websocketPath, f.handleWebsocket)
+	f.Server = httptest.NewServer(mux)
+	return f
+}
+
+// APIURL returns the slack API URL to use in the slack client
+func (f *FakeServer) APIURL() string {
+	return f.Server.URL + "/"
+}
+
+// Close stops the server
+func (f *FakeServer) Close() {
+	f.Server.Close()
+}
+
+// SendEventsAPI sends an events API request with the given inner event to the client
+func (f *FakeServer) SendEventsAPI(envelopeID string, innerEvent interface{}) error {
+	inner, err := json.Marshal(innerEvent)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":  "event_callback",
+		"event": json.RawMessage(inner),
+	})
+	if err != nil {
+		return err
+	}
+	f.send(socketmode.RequestTypeEventsAPI, envelopeID, payload)
+	return nil
+}
+
+// SendSlashCommand sends a slash command request to the client
+func (f *FakeServer) SendSlashCommand(envelopeID string, cmd *slack.SlashCommand) error {
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	f.send(socketmode.RequestTypeSlashCommands, envelopeID, payload)
+	return nil
+}
+
+// SendInteraction sends an interactive request to the client
+func (f *FakeServer) SendInteraction(envelopeID string, callback *slack.InteractionCallback) error {
+	payload, err := json.Marshal(callback)
+	if err != nil {
+		return err
+	}
+	f.send(socketmode.RequestTypeInteractive, envelopeID, payload)
+	return nil
+}
+
+// AssertAck waits for the client to acknowledge the request with the given envelope ID returning the acknowledgement
+func (f *FakeServer) AssertAck(t *testing.T, envelopeID string, timeout time.Duration) socketmode.Response {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		f.lock.Lock()
+		ack, ok := f.acks[envelopeID]
+		f.lock.Unlock()
+		if ok {
+			return ack
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Fail(t, "no acknowledgement", "no acknowledgement received for envelope %s within %s", envelopeID, timeout.String())
+	return socketmode.Response{}
+}
+
+func (f *FakeServer) send(requestType, envelopeID string, payload json.RawMessage) {
+	f.requests <- &socketmode.Request{
+		Type:       requestType,
+		EnvelopeID: envelopeID,
+		Payload:    payload,
+	}
+}
+
+func (f *FakeServer) handleConnectionsOpen(w http.ResponseWriter, _ *http.Request) {
+	url := "ws" + strings.TrimPrefix(f.Server.URL, "http") + websocketPath
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"ok":  true,
+		"url": url,
+	})
+}
+
+func (f *FakeServer) handleWebsocket(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	err = conn.WriteJSON(&socketmode.Request{
+		Type:           socketmode.RequestTypeHello,
+		NumConnections: 1,
+	})
+	if err != nil {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			ack := socketmode.Response{}
+			if err := conn.ReadJSON(&ack); err != nil {
+				return
+			}
+			f.lock.Lock()
+			f.acks[ack.EnvelopeID] = ack
+			f.lock.Unlock()
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		case req := <-f.requests:
+			if err := conn.WriteJSON(req); err != nil {
+				return
+			}
+		}
+	}
+}
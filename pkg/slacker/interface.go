@@ -3,11 +3,21 @@ package slacker
 import "github.com/slack-go/slack"
 
 // Interface the main slack interface we need
-// which is a small subset of the slack API so its easier to fake
+// which is a small subset of the slack API so its easier to fake.
+// Other chat backends with an API, such as Mattermost, implement it by rendering the slack messages
+// so that the notify logic is shared
 type Interface interface {
 	OpenConversation(params *slack.OpenConversationParameters) (*slack.Channel, bool, bool, error)
 
 	SendMessage(channel string, options ...slack.MsgOption) (string, string, string, error)
 
 	GetUserByEmail(email string) (*slack.User, error)
+
+	GetUserInfo(user string) (*slack.User, error)
+
+	PublishView(userID string, view slack.HomeTabViewRequest, hash string) (*slack.ViewResponse, error)
+
+	GetConversations(params *slack.GetConversationsParameters) ([]slack.Channel, string, error)
+
+	AddPin(channel string, item slack.ItemRef) error
 }
@@ -17,11 +17,21 @@ func CreateTestPipelineActivity(ns, owner, repo, branch, context, build string,
 	name += "-" + build
 	name = naming.ToValidName(name)
 	gitURL := "https://fake.git/" + owner + "/" + repo + ".git"
+	labels := map[string]string{
+		"owner":      owner,
+		"repository": repo,
+		"branch":     branch,
+		"build":      build,
+	}
+	if context != "" {
+		labels["context"] = context
+	}
 
 	return &jenkinsv1.PipelineActivity{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: ns,
+			Labels:    labels,
 			CreationTimestamp: metav1.Time{
 				Time: time.Now(),
 			},